@@ -4,7 +4,10 @@ go 1.23.0
 
 toolchain go1.23.7
 
-require github.com/teilomillet/gollm v0.1.9
+require (
+	github.com/teilomillet/gollm v0.1.9
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/bahlo/generic-list-go v0.2.0 // indirect
@@ -29,5 +32,4 @@ require (
 	golang.org/x/net v0.37.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// ActionTool wraps an Agent or any workflow.Action as a tools.Tool so that a
+// supervisor ToolAgent can call sub-agents the same way it calls tools. Each
+// call runs the action in a fresh child WorkContext. The result is a
+// *tools.ToolResult whose metadata carries the nested token usage.
+type ActionTool struct {
+	action      workflow.Action
+	name        string
+	description string
+	schema      tools.Schema
+	mappings    map[string]interface{} // Tool argument -> WorkContext key
+	inherit     []interface{}          // Keys copied from the parent WorkContext
+	inputMapper func(args map[string]interface{}, wctx workflow.WorkContext) error
+	output      func(report workflow.WorkReport) interface{}
+}
+
+// NewActionTool wraps a workflow action as a tool. By default the tool takes a
+// single "input" string argument that is set as the user input of the action.
+func NewActionTool(action workflow.Action) *ActionTool {
+	return &ActionTool{
+		action:      action,
+		name:        action.Name(),
+		description: fmt.Sprintf("Run the %s workflow with the given input.", action.Name()),
+		schema: tools.Schema{
+			Type: constants.SchemaTypeObject,
+			Properties: map[string]interface{}{
+				"input": map[string]interface{}{
+					"type":        constants.SchemaTypeString,
+					"description": "The input or task to pass on",
+				},
+			},
+			Required: []string{"input"},
+		},
+		mappings: map[string]interface{}{"input": constants.KeyUserInput},
+	}
+}
+
+// NewAgentTool wraps an agent as a tool that delegates a task to it.
+func NewAgentTool(agent Agent) *ActionTool {
+	return NewActionTool(agent).
+		WithDescription(fmt.Sprintf("Delegate a task to the %s agent and return its answer.", agent.Name()))
+}
+
+// WithName sets the tool name offered to the model.
+func (at *ActionTool) WithName(name string) *ActionTool {
+	at.name = name
+	return at
+}
+
+// WithDescription sets the tool description offered to the model.
+func (at *ActionTool) WithDescription(description string) *ActionTool {
+	at.description = description
+	return at
+}
+
+// WithSchema sets the input schema of the tool. Arguments without an explicit
+// mapping are stored in the child WorkContext under their own name.
+func (at *ActionTool) WithSchema(schema tools.Schema) *ActionTool {
+	at.schema = schema
+	return at
+}
+
+// WithArgumentMapping stores the tool argument under the given WorkContext key,
+// e.g. WithArgumentMapping("question", constants.KeyUserInput).
+func (at *ActionTool) WithArgumentMapping(arg string, key interface{}) *ActionTool {
+	at.mappings[arg] = key
+	return at
+}
+
+// WithInheritedKeys copies the given keys from the parent WorkContext into the
+// child, e.g. constants.KeyMessageHistory to share the conversation.
+func (at *ActionTool) WithInheritedKeys(keys ...interface{}) *ActionTool {
+	at.inherit = append(at.inherit, keys...)
+	return at
+}
+
+// WithInputMapper replaces the argument mappings with a custom function that
+// prepares the child WorkContext from the tool arguments.
+func (at *ActionTool) WithInputMapper(mapper func(args map[string]interface{}, wctx workflow.WorkContext) error) *ActionTool {
+	at.inputMapper = mapper
+	return at
+}
+
+// WithOutputMapper sets how the tool output is derived from the action's report.
+// By default the text content of the report data is returned.
+func (at *ActionTool) WithOutputMapper(mapper func(report workflow.WorkReport) interface{}) *ActionTool {
+	at.output = mapper
+	return at
+}
+
+// Name returns the tool name.
+func (at *ActionTool) Name() string {
+	return at.name
+}
+
+// Description returns the tool description.
+func (at *ActionTool) Description() string {
+	return at.description
+}
+
+// Parameters returns the input schema of the tool.
+func (at *ActionTool) Parameters() tools.Schema {
+	return at.schema
+}
+
+// Action returns the wrapped action.
+func (at *ActionTool) Action() workflow.Action {
+	return at.action
+}
+
+// Execute runs the wrapped action in a child WorkContext. A failed run is
+// reported through the Error field of the returned *tools.ToolResult so that
+// the token usage of the attempt is still available to the caller.
+func (at *ActionTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	wctx, err := at.childContext(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	report := at.action.Run(wctx)
+
+	tokens := reportTokens(report)
+	result := &tools.ToolResult{
+		Metadata: map[string]interface{}{
+			"action_name":  at.action.Name(),
+			"status":       report.Status,
+			"total_tokens": tokens,
+			"elapsed":      time.Since(start),
+		},
+	}
+	if usage, ok := report.Metadata["token_usage"]; ok {
+		result.Metadata["token_usage"] = usage
+	}
+
+	if report.Status == workflow.StatusFailure {
+		if len(report.Errors) > 0 {
+			result.Error = report.Errors[0]
+		} else {
+			result.Error = fmt.Errorf("%s failed", at.action.Name())
+		}
+		return result, nil
+	}
+
+	if at.output != nil {
+		result.Data = at.output(report)
+	} else {
+		result.Data = workflow.ExtractContent(report.Data)
+	}
+	return result, nil
+}
+
+// childContext creates the WorkContext for a run from the tool arguments.
+func (at *ActionTool) childContext(ctx context.Context, params map[string]interface{}) (workflow.WorkContext, error) {
+	var wctx workflow.WorkContext
+	if parent, ok := ctx.Value(constants.KeyWorkContext).(workflow.WorkContext); ok {
+		wctx = workflow.NewChildWorkContext(parent)
+		for _, key := range at.inherit {
+			if value, ok := parent.Get(key); ok {
+				wctx.Set(key, value)
+			}
+		}
+	} else {
+		wctx = workflow.NewWorkContext(ctx)
+	}
+
+	if at.inputMapper != nil {
+		if err := at.inputMapper(params, wctx); err != nil {
+			return nil, fmt.Errorf("failed to map arguments for %s: %w", at.name, err)
+		}
+		return wctx, nil
+	}
+
+	for arg, value := range params {
+		if key, ok := at.mappings[arg]; ok {
+			// Agents expect the user input as a string
+			if _, isString := value.(string); key == constants.KeyUserInput && !isString {
+				value = fmt.Sprintf("%v", value)
+			}
+			wctx.Set(key, value)
+		} else {
+			wctx.Set(arg, value)
+		}
+	}
+	return wctx, nil
+}
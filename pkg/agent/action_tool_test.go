@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestActionTool_SupervisorCallsSubAgent(t *testing.T) {
+	researcherClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Go was released in 2009.", Usage: llm.Usage{TotalTokens: 25}},
+	}}
+	researcher := NewChatAgent("researcher").WithClient(researcherClient)
+
+	supervisorClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{
+			ToolCalls: []llm.ToolCall{{ID: "1", Name: "researcher", Args: map[string]interface{}{"input": "When was Go released?"}}},
+			Usage:     llm.Usage{TotalTokens: 10},
+		},
+		{Content: "Go came out in 2009.", Usage: llm.Usage{TotalTokens: 10}},
+	}}
+	supervisor := NewToolAgent("supervisor").
+		WithClient(supervisorClient).
+		WithTools(NewAgentTool(researcher))
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Tell me about Go's history")
+	ctx.Set(constants.KeyMessageHistory, []llm.Message{{Role: constants.RoleUser, Content: "earlier"}})
+
+	report := supervisor.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	subMessages := researcherClient.requests[0].Messages
+	if len(subMessages) != 1 || subMessages[0].Content != "When was Go released?" {
+		t.Errorf("Expected sub-agent to run in a fresh context with the tool input, got %+v", subMessages)
+	}
+
+	toolMessage := supervisorClient.requests[1].Messages[len(supervisorClient.requests[1].Messages)-1]
+	if toolMessage.Content != "Go was released in 2009." {
+		t.Errorf("Expected sub-agent output as tool result, got %q", toolMessage.Content)
+	}
+
+	if report.Metadata["total_tokens"] != 45 {
+		t.Errorf("Expected nested token usage to roll up to 45, got %v", report.Metadata["total_tokens"])
+	}
+}
+
+func TestActionTool_MappingAndErrors(t *testing.T) {
+	var seen map[interface{}]interface{}
+	action := workflow.NewActionFunc("lookup", func(ctx workflow.WorkContext) workflow.WorkReport {
+		seen = map[interface{}]interface{}{}
+		for _, key := range []interface{}{constants.KeyUserInput, "order_id", constants.KeyMessageHistory} {
+			seen[key], _ = ctx.Get(key)
+		}
+		if seen["order_id"] == "missing" {
+			return workflow.NewFailedWorkReport(fmt.Errorf("order not found"))
+		}
+		report := workflow.NewCompletedWorkReport()
+		report.Data = "shipped"
+		return report
+	})
+
+	tool := NewActionTool(action).
+		WithSchema(tools.Schema{Type: "object", Properties: map[string]interface{}{
+			"question": map[string]interface{}{"type": "string"},
+			"order_id": map[string]interface{}{"type": "string"},
+		}}).
+		WithArgumentMapping("question", constants.KeyUserInput).
+		WithInheritedKeys(constants.KeyMessageHistory)
+
+	parent := workflow.NewWorkContext(context.Background())
+	parent.Set(constants.KeyMessageHistory, []llm.Message{{Role: constants.RoleUser, Content: "hi"}})
+	ctx := context.WithValue(context.Background(), constants.KeyWorkContext, parent)
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"question": "status?", "order_id": "42"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.(*tools.ToolResult).Data != "shipped" {
+		t.Errorf("Expected extracted output, got %+v", result)
+	}
+	if seen[constants.KeyUserInput] != "status?" || seen["order_id"] != "42" || seen[constants.KeyMessageHistory] == nil {
+		t.Errorf("Unexpected child context values: %v", seen)
+	}
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"order_id": "missing"})
+	if err != nil {
+		t.Fatalf("Failures should be reported in the tool result, got %v", err)
+	}
+	if toolErr := result.(*tools.ToolResult).Error; toolErr == nil || !strings.Contains(toolErr.Error(), "order not found") {
+		t.Errorf("Expected action error in tool result, got %v", toolErr)
+	}
+}
@@ -18,6 +18,22 @@ const (
 
 	// TypeWorkflow represents an agent that wraps a complex internal workflow.
 	TypeWorkflow AgentType = "workflow"
+
+	// TypePlan represents a plan-and-execute agent that asks the model for an
+	// explicit step plan and runs each step with a sub-agent.
+	TypePlan AgentType = "plan"
+
+	// TypeReflection represents an agent that drafts, critiques and revises
+	// its output until a critic accepts it.
+	TypeReflection AgentType = "reflection"
+
+	// TypeEnsemble represents an agent that runs several members or samples
+	// and aggregates their answers.
+	TypeEnsemble AgentType = "ensemble"
+
+	// TypeRouter represents an agent that picks one of several routes for a
+	// request and runs the action of that route.
+	TypeRouter AgentType = "router"
 )
 
 // Agent represents a specialized workflow action that adds agent-specific capabilities
@@ -0,0 +1,326 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/memory"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// ErrBudgetExceeded is matched by errors.Is for every BudgetExceededError.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetLimit identifies the limit of a Budget that was exceeded.
+type BudgetLimit string
+
+const (
+	// BudgetTokens is the maximum number of total tokens.
+	BudgetTokens BudgetLimit = "tokens"
+	// BudgetCost is the maximum estimated cost.
+	BudgetCost BudgetLimit = "cost"
+	// BudgetDeadline is the wall-clock deadline.
+	BudgetDeadline BudgetLimit = "deadline"
+)
+
+// BudgetExceededError reports that an agent run used up a limit of its budget.
+type BudgetExceededError struct {
+	Agent string
+	Limit BudgetLimit
+	Used  float64 // Tokens, estimated cost or seconds past the deadline
+	Max   float64
+}
+
+// Error implements the error interface.
+func (e *BudgetExceededError) Error() string {
+	switch e.Limit {
+	case BudgetDeadline:
+		return fmt.Sprintf("agent %s: budget exceeded: deadline passed %.1fs ago", e.Agent, e.Used)
+	case BudgetCost:
+		return fmt.Sprintf("agent %s: budget exceeded: estimated cost %.4f of %.4f", e.Agent, e.Used, e.Max)
+	default:
+		return fmt.Sprintf("agent %s: budget exceeded: %.0f of %.0f %s", e.Agent, e.Used, e.Max, e.Limit)
+	}
+}
+
+// Is reports whether the target is ErrBudgetExceeded.
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// ModelPrice is the price of a model per million tokens, used to estimate
+// the cost of a run.
+type ModelPrice struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+// Budget limits the tokens, estimated cost and wall-clock time of agent runs.
+// A budget is safe for concurrent use and may be shared by several agents,
+// for example through the WorkContext, so that a whole workflow draws from
+// the same budget.
+type Budget struct {
+	mu           sync.Mutex
+	maxTokens    int
+	maxCost      float64
+	deadline     time.Time
+	started      time.Time
+	prices       map[string]ModelPrice
+	defaultPrice *ModelPrice
+	wrapUp       float64
+	tokens       int
+	cost         float64
+}
+
+// NewBudget creates a budget without limits. The agent wraps up once 90% of
+// a limit is used.
+func NewBudget() *Budget {
+	return &Budget{
+		prices: make(map[string]ModelPrice),
+		wrapUp: 0.9,
+	}
+}
+
+// WithMaxTokens sets the maximum number of total tokens.
+func (b *Budget) WithMaxTokens(max int) *Budget {
+	b.maxTokens = max
+	return b
+}
+
+// WithMaxCost sets the maximum estimated cost. Costs are estimated from the
+// prices set with WithPrice and WithDefaultPrice.
+func (b *Budget) WithMaxCost(max float64) *Budget {
+	b.maxCost = max
+	return b
+}
+
+// WithDeadline sets the wall-clock deadline.
+func (b *Budget) WithDeadline(deadline time.Time) *Budget {
+	b.deadline = deadline
+	b.started = time.Now()
+	return b
+}
+
+// WithTimeout sets the deadline to the given duration from now.
+func (b *Budget) WithTimeout(timeout time.Duration) *Budget {
+	return b.WithDeadline(time.Now().Add(timeout))
+}
+
+// WithPrice sets the price of a model.
+func (b *Budget) WithPrice(model string, price ModelPrice) *Budget {
+	b.prices[model] = price
+	return b
+}
+
+// WithDefaultPrice sets the price used for models without a price of their own.
+func (b *Budget) WithDefaultPrice(price ModelPrice) *Budget {
+	b.defaultPrice = &price
+	return b
+}
+
+// WithWrapUpThreshold sets the fraction of a limit after which agents make one
+// final completion without tools instead of continuing their loop.
+func (b *Budget) WithWrapUpThreshold(fraction float64) *Budget {
+	if fraction > 0 && fraction <= 1 {
+		b.wrapUp = fraction
+	}
+	return b
+}
+
+// Record adds the usage of a completion by the given model.
+func (b *Budget) Record(model string, usage llm.Usage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += usage.TotalTokens
+	price, ok := b.prices[model]
+	if !ok && b.defaultPrice != nil {
+		price, ok = *b.defaultPrice, true
+	}
+	if ok {
+		b.cost += (float64(usage.PromptTokens)*price.PromptPerMillion +
+			float64(usage.CompletionTokens)*price.CompletionPerMillion) / 1e6
+	}
+}
+
+// Tokens returns the number of tokens used so far.
+func (b *Budget) Tokens() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// Cost returns the estimated cost so far.
+func (b *Budget) Cost() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cost
+}
+
+// Deadline returns the deadline, if one is set.
+func (b *Budget) Deadline() (time.Time, bool) {
+	return b.deadline, !b.deadline.IsZero()
+}
+
+// Check returns a *BudgetExceededError when a limit has been reached.
+func (b *Budget) Check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxTokens > 0 && b.tokens >= b.maxTokens {
+		return &BudgetExceededError{Limit: BudgetTokens, Used: float64(b.tokens), Max: float64(b.maxTokens)}
+	}
+	if b.maxCost > 0 && b.cost >= b.maxCost {
+		return &BudgetExceededError{Limit: BudgetCost, Used: b.cost, Max: b.maxCost}
+	}
+	if !b.deadline.IsZero() {
+		if past := time.Since(b.deadline); past >= 0 {
+			return &BudgetExceededError{Limit: BudgetDeadline, Used: past.Seconds()}
+		}
+	}
+	return nil
+}
+
+// NearlyExhausted reports whether the wrap-up threshold of a limit has been
+// reached. For the deadline the threshold applies to the time between setting
+// the deadline and the deadline itself.
+func (b *Budget) NearlyExhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxTokens > 0 && float64(b.tokens) >= b.wrapUp*float64(b.maxTokens) {
+		return true
+	}
+	if b.maxCost > 0 && b.cost >= b.wrapUp*b.maxCost {
+		return true
+	}
+	if !b.deadline.IsZero() {
+		window := b.deadline.Sub(b.started)
+		return time.Since(b.started) >= time.Duration(b.wrapUp*float64(window))
+	}
+	return false
+}
+
+// budgets are the budgets that apply to an agent run: the agent's own budget
+// and the one stored in the WorkContext.
+type budgets []*Budget
+
+// runBudgets returns the budgets that apply to a run of an agent.
+func runBudgets(wctx workflow.WorkContext, own *Budget) budgets {
+	var bs budgets
+	if own != nil {
+		bs = append(bs, own)
+	}
+	if value, ok := wctx.Get(constants.KeyBudget); ok {
+		if shared, ok := value.(*Budget); ok && shared != nil && shared != own {
+			bs = append(bs, shared)
+		}
+	}
+	return bs
+}
+
+// check returns the first exceeded limit as a *BudgetExceededError.
+func (bs budgets) check(agentName string) error {
+	for _, b := range bs {
+		if err := b.Check(); err != nil {
+			var exceeded *BudgetExceededError
+			if errors.As(err, &exceeded) {
+				exceeded.Agent = agentName
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// nearlyExhausted reports whether any budget reached its wrap-up threshold.
+func (bs budgets) nearlyExhausted() bool {
+	for _, b := range bs {
+		if b.NearlyExhausted() {
+			return true
+		}
+	}
+	return false
+}
+
+// record adds the usage of a completion to every budget. Providers that do
+// not report usage are estimated with the approximate tokenizer so the token
+// limit still holds.
+func (bs budgets) record(req llm.CompletionRequest, response *llm.CompletionResponse) {
+	if len(bs) == 0 || response == nil {
+		return
+	}
+	usage := response.Usage
+	if usage.TotalTokens == 0 {
+		tokenizer := llm.NewApproxTokenizer()
+		usage.PromptTokens = llm.CountMessageTokens(tokenizer, req.Messages) + tokenizer.CountTokens(req.Prompt)
+		usage.CompletionTokens = tokenizer.CountTokens(response.Content)
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	for _, b := range bs {
+		b.Record(req.Model, usage)
+	}
+}
+
+// context returns a context bounded by the earliest deadline of the budgets.
+func (bs budgets) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	var earliest time.Time
+	for _, b := range bs {
+		if deadline, ok := b.Deadline(); ok && (earliest.IsZero() || deadline.Before(earliest)) {
+			earliest = deadline
+		}
+	}
+	if earliest.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, earliest)
+}
+
+// complete performs a completion within the budgets. A completion that fails
+// because the deadline passed is reported as a budget error.
+func (bs budgets) complete(ctx context.Context, client llm.Client, agentName string, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	callCtx, cancel := bs.context(ctx)
+	defer cancel()
+
+	response, err := client.Complete(callCtx, req)
+	if err != nil {
+		if budgetErr := bs.check(agentName); budgetErr != nil && ctx.Err() == nil {
+			return nil, budgetErr
+		}
+		return nil, err
+	}
+	bs.record(req, response)
+	return response, nil
+}
+
+// completer returns a memory.Completer that performs the completions of memory
+// strategies within the budgets.
+func (bs budgets) completer(agentName string) memory.Completer {
+	return func(ctx context.Context, client llm.Client, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+		if err := bs.check(agentName); err != nil {
+			return nil, err
+		}
+		return bs.complete(ctx, client, agentName, req)
+	}
+}
+
+// wrapUpNote asks the model for a final answer when the budget is nearly used up.
+const wrapUpNote = "The budget for this task is nearly used up. Do not call any more tools; give your best final answer now with the information you have."
+
+// budgetFailure builds the failed report for an exceeded budget.
+func budgetFailure(err error, agentName string, agentType AgentType, totalTokens int) workflow.WorkReport {
+	report := workflow.NewFailedWorkReport(err)
+	report.SetMetadata("agent_name", agentName)
+	report.SetMetadata("agent_type", agentType)
+	report.SetMetadata("total_tokens", totalTokens)
+	var exceeded *BudgetExceededError
+	if errors.As(err, &exceeded) {
+		report.SetMetadata("budget_exceeded", exceeded.Limit)
+	}
+	return report
+}
@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/memory"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestBudget_RecordAndCheck(t *testing.T) {
+	budget := NewBudget().
+		WithMaxTokens(1000).
+		WithMaxCost(0.01).
+		WithPrice("gpt-4o", ModelPrice{PromptPerMillion: 2.5, CompletionPerMillion: 10})
+
+	budget.Record("gpt-4o", llm.Usage{PromptTokens: 400, CompletionTokens: 100, TotalTokens: 500})
+	if budget.Tokens() != 500 {
+		t.Errorf("Expected 500 tokens, got %d", budget.Tokens())
+	}
+	if want := 0.002; math.Abs(budget.Cost()-want) > 1e-9 {
+		t.Errorf("Expected cost %v, got %v", want, budget.Cost())
+	}
+	if err := budget.Check(); err != nil {
+		t.Errorf("Expected budget within limits, got %v", err)
+	}
+	if budget.NearlyExhausted() {
+		t.Error("Expected budget not to be nearly exhausted")
+	}
+
+	budget.Record("gpt-4o", llm.Usage{PromptTokens: 400, CompletionTokens: 100, TotalTokens: 500})
+	err := budget.Check()
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+	}
+	var exceeded *BudgetExceededError
+	if !errors.As(err, &exceeded) || exceeded.Limit != BudgetTokens || exceeded.Used != 1000 {
+		t.Errorf("Expected the token limit to be exceeded, got %+v", exceeded)
+	}
+
+	expired := NewBudget().WithDeadline(time.Now().Add(-time.Second))
+	if err := expired.Check(); !errors.As(err, &exceeded) || exceeded.Limit != BudgetDeadline {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestToolAgent_BudgetWrapUp(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{
+			ToolCalls: []llm.ToolCall{{ID: "1", Name: "get_weather", Args: map[string]interface{}{"city": "Paris"}}},
+			Usage:     llm.Usage{TotalTokens: 95},
+		},
+		{
+			Content:   "It is sunny in Paris.",
+			ToolCalls: []llm.ToolCall{{ID: "2", Name: "get_weather", Args: map[string]interface{}{"city": "Rome"}}},
+			Usage:     llm.Usage{TotalTokens: 20},
+		},
+	}}
+	tool := weatherTool()
+
+	budget := NewBudget().WithMaxTokens(100)
+	ta := NewToolAgent("weather").
+		WithClient(client).
+		WithTools(tool).
+		WithBudget(budget)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Weather in Paris?")
+	report := ta.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	final := client.requests[1]
+	if len(final.Tools) != 0 {
+		t.Errorf("Expected the wrap-up request without tools, got %d", len(final.Tools))
+	}
+	if last := final.Messages[len(final.Messages)-1]; last.Content != wrapUpNote {
+		t.Errorf("Expected the wrap-up note, got %+v", last)
+	}
+	if len(tool.calls) != 1 {
+		t.Errorf("Expected tool calls after the wrap-up to be ignored, got %d calls", len(tool.calls))
+	}
+	response := report.Data.(*llm.CompletionResponse)
+	if response.Content != "It is sunny in Paris." || len(response.ToolCalls) != 0 {
+		t.Errorf("Expected the wrap-up answer, got %+v", response)
+	}
+	if report.Metadata["budget_wrap_up"] != true {
+		t.Error("Expected budget_wrap_up metadata")
+	}
+	if budget.Tokens() != 115 {
+		t.Errorf("Expected 115 tokens recorded, got %d", budget.Tokens())
+	}
+}
+
+func TestAgents_SharedBudgetExceeded(t *testing.T) {
+	budget := NewBudget().WithMaxTokens(50)
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyBudget, budget)
+	ctx.Set(constants.KeyUserInput, "Hello")
+
+	// Usage is estimated when the provider does not report it
+	long := make([]byte, 400)
+	for i := range long {
+		long[i] = 'a'
+	}
+	chat := NewChatAgent("writer").WithClient(&recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: string(long)},
+	}})
+	if report := chat.Run(ctx); report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the first agent to complete, got %v", report.Errors)
+	}
+	if budget.Tokens() < 100 {
+		t.Errorf("Expected estimated usage to be recorded, got %d tokens", budget.Tokens())
+	}
+
+	client := &recordingLLMClient{}
+	ta := NewToolAgent("reviewer").WithClient(client).WithTools(weatherTool())
+	report := ta.Run(ctx)
+	if report.Status != workflow.StatusFailure {
+		t.Fatalf("Expected StatusFailure, got %v", report.Status)
+	}
+	var exceeded *BudgetExceededError
+	if !errors.As(report.Errors[0], &exceeded) || exceeded.Agent != "reviewer" || exceeded.Limit != BudgetTokens {
+		t.Errorf("Expected a token budget error for reviewer, got %v", report.Errors[0])
+	}
+	if report.Metadata["budget_exceeded"] != BudgetTokens {
+		t.Errorf("Expected budget_exceeded metadata, got %v", report.Metadata["budget_exceeded"])
+	}
+	if len(client.requests) != 0 {
+		t.Errorf("Expected no LLM call after the budget was exceeded, got %d", len(client.requests))
+	}
+}
+
+func TestToolAgent_BudgetDeadline(t *testing.T) {
+	client := &recordingLLMClient{}
+	ta := NewToolAgent("slow").
+		WithClient(client).
+		WithToolCallingMode(ToolCallingReAct).
+		WithBudget(NewBudget().WithDeadline(time.Now().Add(-time.Millisecond)))
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Hello")
+	report := ta.Run(ctx)
+	if !errors.Is(firstError(report), ErrBudgetExceeded) {
+		t.Fatalf("Expected a budget error, got %v", report.Errors)
+	}
+	if len(client.requests) != 0 {
+		t.Errorf("Expected no LLM call after the deadline, got %d", len(client.requests))
+	}
+}
+
+func TestAgents_InternalCompletionsCountAgainstBudget(t *testing.T) {
+	exceeded := func(t *testing.T, report workflow.WorkReport) {
+		t.Helper()
+		if report.Status != workflow.StatusFailure || !errors.Is(firstError(report), ErrBudgetExceeded) {
+			t.Fatalf("Expected a budget error, got %v", report.Errors)
+		}
+		if report.Metadata["budget_exceeded"] != BudgetTokens {
+			t.Errorf("Expected budget_exceeded metadata, got %v", report.Metadata["budget_exceeded"])
+		}
+	}
+
+	t.Run("plan", func(t *testing.T) {
+		client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+			{Content: `{"steps": [{"description": "Look it up"}]}`, Usage: llm.Usage{TotalTokens: 60}},
+		}}
+		var inputs []string
+		pa := NewPlanAgent("planner").WithClient(client).WithExecutor(draftingAction(&inputs))
+
+		ctx := workflow.NewWorkContext(context.Background())
+		ctx.Set(constants.KeyBudget, NewBudget().WithMaxTokens(50))
+		ctx.Set(constants.KeyUserInput, "Find the answer")
+		exceeded(t, pa.Run(ctx))
+		if len(client.requests) != 1 {
+			t.Errorf("Expected no synthesis after the planner used up the budget, got %d requests", len(client.requests))
+		}
+	})
+
+	t.Run("reflection critic", func(t *testing.T) {
+		client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+			{Content: `{"score": 4, "passed": false, "feedback": "Too vague."}`, Usage: llm.Usage{TotalTokens: 60}},
+		}}
+		var inputs []string
+		ra := NewReflectionAgent("editor", draftingAction(&inputs)).WithClient(client)
+
+		ctx := workflow.NewWorkContext(context.Background())
+		ctx.Set(constants.KeyBudget, NewBudget().WithMaxTokens(50))
+		ctx.Set(constants.KeyUserInput, "Write a tagline")
+		exceeded(t, ra.Run(ctx))
+		if len(client.requests) != 1 {
+			t.Errorf("Expected no second critique after the budget was used up, got %d requests", len(client.requests))
+		}
+	})
+
+	t.Run("summarizing memory", func(t *testing.T) {
+		summarizer := &recordingLLMClient{responses: []*llm.CompletionResponse{
+			{Content: "The user asked about the weather.", Usage: llm.Usage{TotalTokens: 60}},
+		}}
+		client := &recordingLLMClient{}
+		budget := NewBudget().WithMaxTokens(50)
+		ca := NewChatAgent("chat").
+			WithClient(client).
+			WithBudget(budget).
+			WithMemory(memory.NewSummarizingMemory(summarizer, "gpt-4o-mini").WithKeepRecent(1), 500)
+
+		ctx := workflow.NewWorkContext(context.Background())
+		ctx.Set(constants.KeyMessageHistory, conversation(10))
+		ctx.Set(constants.KeyUserInput, "And tomorrow?")
+		exceeded(t, ca.Run(ctx))
+		if budget.Tokens() != 60 || len(client.requests) != 0 {
+			t.Errorf("Expected the summary to use up the budget, got %d tokens and %d requests", budget.Tokens(), len(client.requests))
+		}
+	})
+}
+
+func TestAgents_NestedRunsShareBudget(t *testing.T) {
+	researcherClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Go was released in 2009.", Usage: llm.Usage{TotalTokens: 60}},
+	}}
+	researcher := NewToolAgent("researcher").WithClient(researcherClient).WithTools(weatherTool())
+
+	supervisorClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{
+			ToolCalls: []llm.ToolCall{{ID: "1", Name: "researcher", Args: map[string]interface{}{"input": "When was Go released?"}}},
+			Usage:     llm.Usage{TotalTokens: 10},
+		},
+	}}
+	supervisor := NewToolAgent("supervisor").
+		WithClient(supervisorClient).
+		WithTools(NewAgentTool(researcher))
+
+	budget := NewBudget().WithMaxTokens(50)
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyBudget, budget)
+	ctx.Set(constants.KeyUserInput, "Tell me about Go's history")
+
+	report := supervisor.Run(ctx)
+	if !errors.Is(firstError(report), ErrBudgetExceeded) {
+		t.Fatalf("Expected the nested run to exhaust the budget, got %v", report.Errors)
+	}
+	if budget.Tokens() != 70 {
+		t.Errorf("Expected the nested usage to be recorded in the shared budget, got %d tokens", budget.Tokens())
+	}
+	if len(supervisorClient.requests) != 1 {
+		t.Errorf("Expected no supervisor call after the budget was used up, got %d", len(supervisorClient.requests))
+	}
+}
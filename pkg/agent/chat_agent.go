@@ -1,11 +1,17 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/guardrail"
 	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/memory"
+	"github.com/ratlabs-io/go-agent-kit/pkg/output"
+	"github.com/ratlabs-io/go-agent-kit/pkg/prompt"
+	"github.com/ratlabs-io/go-agent-kit/pkg/session"
 	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
 	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
 )
@@ -24,6 +30,15 @@ type ChatAgent struct {
 	maxTokens    int
 	temperature  float64
 	topP         float64
+	memory       memory.Strategy
+	memoryTokens int
+	context      contextFitting
+	guardrails   guardrail.Pipeline
+	template     *prompt.Template
+	inputs       interface{}
+	budget       *Budget
+	sessions     session.Store
+	output       outputParsing
 }
 
 // NewChatAgent creates a new ChatAgent with the given name.
@@ -73,6 +88,9 @@ func (ca *ChatAgent) Configure(config map[string]interface{}) error {
 	if topP, ok := config["top_p"].(float64); ok {
 		ca.topP = topP
 	}
+	if window, ok := config["context_window"].(int); ok {
+		ca.context.window = window
+	}
 	// Note: LLM client must be provided via WithClient() - no default implementation
 	return nil
 }
@@ -140,9 +158,91 @@ func (ca *ChatAgent) WithTopP(topP float64) *ChatAgent {
 	return ca
 }
 
+// WithMemory sets the memory strategy used to keep the message history within
+// maxTokens, e.g. a memory.SummarizingMemory that compresses older turns.
+func (ca *ChatAgent) WithMemory(strategy memory.Strategy, maxTokens int) *ChatAgent {
+	ca.memory = strategy
+	ca.memoryTokens = maxTokens
+	return ca
+}
+
+// WithContextWindow sets the context window of the model in tokens. The
+// message history is fitted into the window, after room for the output, the
+// prompt and the user input. By default the window is looked up with
+// llm.ContextWindow; a negative window disables fitting.
+func (ca *ChatAgent) WithContextWindow(tokens int) *ChatAgent {
+	ca.context.window = tokens
+	return ca
+}
+
+// WithContextStrategy sets how a history that does not fit the context window
+// is reduced, e.g. memory.NewTrimmingMemory().WithMaxTurns(10) or a
+// memory.SummarizingMemory. The turn limit of a TrimmingMemory applies even
+// when the history fits. By default the oldest turns are dropped.
+func (ca *ChatAgent) WithContextStrategy(strategy memory.Strategy) *ChatAgent {
+	ca.context.strategy = strategy
+	return ca
+}
+
+// WithPromptTemplate sets a system prompt template that is rendered on every
+// run. Variables are resolved from the prompt inputs, then from WorkContext
+// values stored under the variable name, then from the template defaults.
+// The template takes precedence over WithPrompt.
+func (ca *ChatAgent) WithPromptTemplate(tmpl *prompt.Template) *ChatAgent {
+	ca.template = tmpl
+	return ca
+}
+
+// WithPromptInputs sets the inputs of the prompt template, as a map or a struct.
+func (ca *ChatAgent) WithPromptInputs(inputs interface{}) *ChatAgent {
+	ca.inputs = inputs
+	return ca
+}
+
+// WithGuardrails adds guardrails that check the user messages before they are
+// sent to the provider and the final output before it is returned. The system
+// prompt and the assistant and tool messages of the message history are sent
+// unchecked.
+func (ca *ChatAgent) WithGuardrails(guardrails ...guardrail.Guardrail) *ChatAgent {
+	ca.guardrails = append(ca.guardrails, guardrails...)
+	return ca
+}
+
+// WithBudget sets a token, cost and time budget that the completion is
+// checked against. A budget stored in the WorkContext under
+// constants.KeyBudget applies as well.
+func (ca *ChatAgent) WithBudget(budget *Budget) *ChatAgent {
+	ca.budget = budget
+	return ca
+}
+
+// WithOutputParser parses the final response with the parser. The report
+// data becomes a *ParsedOutput and the parsed value is stored in the
+// WorkContext under constants.KeyParsedOutput. A response that does not parse
+// fails the run unless a format-fix retry succeeds.
+func (ca *ChatAgent) WithOutputParser(parser output.Parser) *ChatAgent {
+	ca.output.parser = parser
+	return ca
+}
+
+// WithParseRetries sets how often the model is asked to fix a response that
+// does not parse. The default is no retries.
+func (ca *ChatAgent) WithParseRetries(retries int) *ChatAgent {
+	ca.output.retries = retries
+	return ca
+}
+
+// WithSessionStore sets the store of the sessions the agent resumes. When
+// the WorkContext holds a session ID under constants.KeySessionID, the
+// session is loaded at the start of the run and saved with the conversation
+// at the end.
+func (ca *ChatAgent) WithSessionStore(store session.Store) *ChatAgent {
+	ca.sessions = store
+	return ca
+}
+
 // Run executes the ChatAgent by performing a single LLM completion.
 func (ca *ChatAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
-	startTime := time.Now()
 	logger := wctx.Logger().With("agent", "ChatAgent", "name", ca.name)
 
 	if ca.client == nil {
@@ -150,9 +250,35 @@ func (ca *ChatAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 		return workflow.NewFailedWorkReport(fmt.Errorf("no LLM client configured for agent %s", ca.name))
 	}
 
+	// Resume the session of the conversation, if any
+	sess, err := openSession(wctx, ca.sessions)
+	if err != nil {
+		logger.Error("session loading failed", "error", err)
+		return workflow.NewFailedWorkReport(err)
+	}
+
+	report := ca.complete(wctx, logger)
+	if sess != nil {
+		saveSession(wctx, ca.sessions, sess, &report)
+	}
+	return report
+}
+
+// complete performs the completion of a run.
+func (ca *ChatAgent) complete(wctx workflow.WorkContext, logger workflow.Logger) workflow.WorkReport {
+	startTime := time.Now()
+	budget := runBudgets(wctx, ca.budget)
+
 	// ChatAgent doesn't support tools - use ToolAgent for tool-calling
 	var toolDefs []llm.ToolDefinition
 
+	// Render the system prompt for this run
+	systemPrompt, err := renderPrompt(wctx, ca.prompt, ca.template, ca.inputs)
+	if err != nil {
+		logger.Error("prompt rendering failed", "error", err)
+		return workflow.NewFailedWorkReport(err)
+	}
+
 	// Build messages for the completion request
 	var messages []llm.Message
 
@@ -164,17 +290,22 @@ func (ca *ChatAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 		}
 	}
 
-	// Add message history first
-	if len(messageHistory) > 0 {
-		messages = append(messages, messageHistory...)
+	// Keep the history within the memory budget if a strategy is configured
+	messageHistory, err = compactHistory(wctx, budget, ca.name, ca.memory, ca.memoryTokens, messageHistory)
+	if err != nil {
+		logger.Error("memory compaction failed", "error", err)
+		if errors.Is(err, ErrBudgetExceeded) {
+			return budgetFailure(err, ca.name, ca.agentType, 0)
+		}
+		return workflow.NewFailedWorkReport(err)
 	}
 
 	// Add system prompt if provided (only if not already in history)
-	if ca.prompt != "" {
+	if systemPrompt != "" {
 		// Check if system prompt already exists in history
 		systemPromptExists := false
 		for _, msg := range messageHistory {
-			if msg.Role == constants.RoleSystem && msg.Content == ca.prompt {
+			if msg.Role == constants.RoleSystem && msg.Content == systemPrompt {
 				systemPromptExists = true
 				break
 			}
@@ -182,7 +313,7 @@ func (ca *ChatAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 		if !systemPromptExists {
 			messages = append(messages, llm.Message{
 				Role:    constants.RoleSystem,
-				Content: ca.prompt,
+				Content: systemPrompt,
 			})
 		}
 	}
@@ -197,10 +328,31 @@ func (ca *ChatAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 		}
 	}
 
+	// Fit the history into what the context window leaves for it
+	reserved := ca.context.reserved(ca.maxTokens, messages, toolDefs, ca.jsonSchema)
+	messageHistory, err = ca.context.fit(wctx, budget, ca.name, ca.model, messageHistory, reserved)
+	if err != nil {
+		logger.Error("context fitting failed", "error", err)
+		if errors.Is(err, ErrBudgetExceeded) {
+			return budgetFailure(err, ca.name, ca.agentType, 0)
+		}
+		return workflow.NewFailedWorkReport(err)
+	}
+
+	// Message history goes first
+	messages = append(append([]llm.Message{}, messageHistory...), messages...)
+
 	// If no messages were built, fall back to prompt-only mode
 	var prompt string
-	if len(messages) == 0 && ca.prompt != "" {
-		prompt = ca.prompt
+	if len(messages) == 0 && systemPrompt != "" {
+		prompt = systemPrompt
+	}
+
+	// Check the user messages before they reach the provider
+	messages, inputModified, err := guardMessages(wctx.Context(), ca.guardrails, ca.name, messages)
+	if err != nil {
+		logger.Warn("input blocked by guardrail", "error", err)
+		return guardrailFailure(err, ca.name, ca.agentType)
 	}
 
 	// Prepare the completion request
@@ -220,20 +372,58 @@ func (ca *ChatAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 		},
 	}
 
+	// The completion must fit in the remaining budget
+	if err := budget.check(ca.name); err != nil {
+		logger.Warn("budget exceeded", "error", err)
+		return budgetFailure(err, ca.name, ca.agentType, 0)
+	}
+
 	// Perform the LLM completion
-	response, err := ca.client.Complete(wctx.Context(), req)
+	response, err := budget.complete(wctx.Context(), ca.client, ca.name, req)
 	if err != nil {
 		elapsed := time.Since(startTime)
 		logger.Error("LLM completion failed", "elapsed", elapsed, "error", err)
+		if errors.Is(err, ErrBudgetExceeded) {
+			return budgetFailure(err, ca.name, ca.agentType, 0)
+		}
 		return workflow.NewFailedWorkReport(fmt.Errorf("LLM completion failed: %w", err))
 	}
 
+	// Check the output before it is returned
+	response, outputModified, err := guardOutput(wctx.Context(), ca.guardrails, ca.name, response)
+	if err != nil {
+		logger.Warn("output blocked by guardrail", "error", err)
+		report := guardrailFailure(err, ca.name, ca.agentType)
+		report.SetMetadata("token_usage", response.Usage)
+		return report
+	}
+
+	// Parse the response, asking the model to fix its format if needed
+	var parsed *ParsedOutput
+	totalTokens := response.Usage.TotalTokens
+	if ca.output.parser != nil {
+		var parseGuarded []string
+		parsed, parseGuarded, err = ca.output.parse(wctx, budget, ca.client, ca.guardrails, ca.name, req, response, nil)
+		if err != nil {
+			logger.Warn("output parsing failed", "retries", parsed.Retries, "error", err)
+			return parseFailure(err, ca.name, ca.agentType, parsed, response.Usage.TotalTokens+parsed.tokens)
+		}
+		response = parsed.Response
+		totalTokens += parsed.tokens
+		outputModified = append(outputModified, parseGuarded...)
+	}
+
 	elapsed := time.Since(startTime)
 	logger.Info("LLM completion successful", "elapsed", elapsed, "tokens", response.Usage.TotalTokens)
 
 	// Create the work report
 	report := workflow.NewCompletedWorkReport()
 	report.Data = response
+	if parsed != nil {
+		report.Data = parsed
+		report.SetMetadata("parse_retries", parsed.Retries)
+		report.SetMetadata("total_tokens", totalTokens)
+	}
 
 	// Emit event if WorkContext supports events
 	// Check if this WorkContext has event capabilities by looking at the context value
@@ -258,6 +448,10 @@ func (ca *ChatAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 	report.SetMetadata("agent_type", ca.agentType)
 	report.SetMetadata("elapsed", elapsed)
 	report.SetMetadata("token_usage", response.Usage)
+	addPromptMetadata(&report, ca.template)
+	if modified := append(inputModified, outputModified...); len(modified) > 0 {
+		report.SetMetadata("guardrails_modified", modified)
+	}
 
 	// Wait for callbacks to complete if WorkContext supports waiting
 	if ctxValue := wctx.Context().Value(constants.KeyWorkContext); ctxValue != nil {
@@ -4,7 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
 	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/prompt"
 	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
 )
 
@@ -188,3 +190,32 @@ type LLMError struct {
 func (e *LLMError) Error() string {
 	return e.Message
 }
+
+func TestChatAgent_PromptTemplate(t *testing.T) {
+	client := &recordingLLMClient{}
+	tmpl := prompt.MustNew("support", "You help {{.customer}} in {{.language}}.").WithVersion("3")
+	ca := NewChatAgent("support").
+		WithClient(client).
+		WithPromptTemplate(tmpl).
+		WithPromptInputs(map[string]interface{}{"customer": "Ada"})
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set("language", "German")
+	ctx.Set(constants.KeyUserInput, "Hallo")
+	report := ca.Run(ctx)
+
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+	if system := client.requests[0].Messages[0].Content; system != "You help Ada in German." {
+		t.Errorf("Expected rendered system prompt, got %q", system)
+	}
+	if report.Metadata["prompt_name"] != "support" || report.Metadata["prompt_version"] != "3" {
+		t.Errorf("Expected prompt name and version in metadata, got %v", report.Metadata)
+	}
+
+	missing := workflow.NewWorkContext(context.Background())
+	if report := ca.Run(missing); report.Status != workflow.StatusFailure {
+		t.Error("Expected failure when a template variable is missing")
+	}
+}
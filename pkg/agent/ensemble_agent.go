@@ -0,0 +1,468 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// Aggregation selects how an EnsembleAgent combines the votes of its members.
+type Aggregation string
+
+const (
+	// AggregateMajority picks the most frequent normalized answer.
+	AggregateMajority Aggregation = "majority"
+
+	// AggregateJSONField picks the most frequent value of a field of JSON answers.
+	AggregateJSONField Aggregation = "json_field"
+
+	// AggregateJudge lets an LLM judge select the best answer.
+	AggregateJudge Aggregation = "judge"
+)
+
+// DefaultJudgePrompt is the system prompt of the LLM judge of an EnsembleAgent.
+const DefaultJudgePrompt = `You are an impartial judge. Several candidate answers were given to the same task.
+Select the candidate that answers the task most correctly and completely.
+Respond with JSON only: {"choice": <candidate number>, "rationale": "..."}`
+
+// JudgmentSchema is the structured output schema of the LLM judge.
+var JudgmentSchema = &llm.JSONSchema{
+	Name:        "judgment",
+	Description: "The selected candidate answer",
+	Strict:      true,
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"choice":    map[string]interface{}{"type": "integer"},
+			"rationale": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"choice", "rationale"},
+		"additionalProperties": false,
+	},
+}
+
+// Vote is the answer of one ensemble member run.
+type Vote struct {
+	Member  string        `json:"member"`
+	Sample  int           `json:"sample"`  // 1-based sample of the member
+	Content string        `json:"content"` // Raw output of the member
+	Answer  string        `json:"answer"`  // Normalized answer used for voting; empty if invalid
+	Error   string        `json:"error,omitempty"`
+	Tokens  int           `json:"tokens"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// EnsembleResult is the report data of an EnsembleAgent.
+type EnsembleResult struct {
+	Content    string         `json:"content"`    // Output of a winning vote, for chaining
+	Answer     string         `json:"answer"`     // Winning normalized answer
+	Confidence float64        `json:"confidence"` // Share of valid votes that agree with the answer
+	Tally      map[string]int `json:"tally"`
+	Votes      []Vote         `json:"votes"`
+	Rationale  string         `json:"rationale,omitempty"` // Judge rationale
+}
+
+// EnsembleAgent runs several members, or the same member several times, on
+// the same input and aggregates their answers. Sampling one agent N times
+// gives self-consistency; running different agents or models gives an
+// ensemble. Members run concurrently on the shared WorkContext.
+type EnsembleAgent struct {
+	name          string
+	agentType     AgentType
+	members       []workflow.Action
+	samples       int
+	aggregation   Aggregation
+	field         string
+	normalize     func(string) string
+	minConfidence float64
+	client        llm.Client
+	model         string
+	judgePrompt   string
+	maxTokens     int
+	temperature   float64
+}
+
+// NewEnsembleAgent creates a new EnsembleAgent that aggregates the members by
+// majority vote.
+func NewEnsembleAgent(name string, members ...workflow.Action) *EnsembleAgent {
+	return &EnsembleAgent{
+		name:        name,
+		agentType:   TypeEnsemble,
+		members:     members,
+		samples:     1,
+		aggregation: AggregateMajority,
+		normalize:   NormalizeAnswer,
+		judgePrompt: DefaultJudgePrompt,
+		maxTokens:   500, // Default max tokens for the judge
+		temperature: 0,   // The judge should be deterministic
+	}
+}
+
+// Name returns the name of the EnsembleAgent.
+func (ea *EnsembleAgent) Name() string {
+	return ea.name
+}
+
+// Type returns the type of the agent.
+func (ea *EnsembleAgent) Type() AgentType {
+	return ea.agentType
+}
+
+// Tools returns the tools of the members that are agents.
+func (ea *EnsembleAgent) Tools() []tools.Tool {
+	var all []tools.Tool
+	for _, member := range ea.members {
+		if agent, ok := member.(Agent); ok {
+			all = append(all, agent.Tools()...)
+		}
+	}
+	return all
+}
+
+// Configure configures the EnsembleAgent with the provided settings.
+func (ea *EnsembleAgent) Configure(config map[string]interface{}) error {
+	if samples, ok := config["samples"].(int); ok {
+		if samples <= 0 {
+			return fmt.Errorf("samples must be positive, got %d", samples)
+		}
+		ea.samples = samples
+	}
+	if aggregation, ok := config["aggregation"].(string); ok {
+		ea.aggregation = Aggregation(aggregation)
+	}
+	if field, ok := config["field"].(string); ok {
+		ea.field = field
+	}
+	if confidence, ok := config["min_confidence"].(float64); ok {
+		ea.minConfidence = confidence
+	}
+	if model, ok := config["model"].(string); ok {
+		ea.model = model
+	}
+	return nil
+}
+
+// WithMembers adds members to the ensemble.
+func (ea *EnsembleAgent) WithMembers(members ...workflow.Action) *EnsembleAgent {
+	ea.members = append(ea.members, members...)
+	return ea
+}
+
+// WithSamples runs every member n times, e.g. to sample several completions
+// of one agent for self-consistency.
+func (ea *EnsembleAgent) WithSamples(n int) *EnsembleAgent {
+	if n > 0 {
+		ea.samples = n
+	}
+	return ea
+}
+
+// WithMajorityVote aggregates by majority vote on the normalized answers.
+func (ea *EnsembleAgent) WithMajorityVote() *EnsembleAgent {
+	ea.aggregation = AggregateMajority
+	return ea
+}
+
+// WithJSONFieldVote aggregates by majority vote on a field of JSON answers.
+// Nested fields are separated by dots, e.g. "result.label".
+func (ea *EnsembleAgent) WithJSONFieldVote(field string) *EnsembleAgent {
+	ea.aggregation = AggregateJSONField
+	ea.field = field
+	return ea
+}
+
+// WithJudge lets an LLM judge select the best answer.
+func (ea *EnsembleAgent) WithJudge(client llm.Client, model string) *EnsembleAgent {
+	ea.aggregation = AggregateJudge
+	ea.client = client
+	ea.model = model
+	return ea
+}
+
+// WithJudgePrompt sets the system prompt of the LLM judge.
+func (ea *EnsembleAgent) WithJudgePrompt(prompt string) *EnsembleAgent {
+	ea.judgePrompt = prompt
+	return ea
+}
+
+// WithNormalizer sets the function that normalizes answers before voting.
+// A nil function compares the answers as they are.
+func (ea *EnsembleAgent) WithNormalizer(normalize func(string) string) *EnsembleAgent {
+	if normalize == nil {
+		normalize = func(answer string) string { return answer }
+	}
+	ea.normalize = normalize
+	return ea
+}
+
+// WithMinConfidence fails the run when the share of agreeing votes is below
+// the given ratio.
+func (ea *EnsembleAgent) WithMinConfidence(ratio float64) *EnsembleAgent {
+	ea.minConfidence = ratio
+	return ea
+}
+
+// Run runs all members, aggregates their votes and returns an EnsembleResult.
+func (ea *EnsembleAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	startTime := time.Now()
+	logger := wctx.Logger().With("agent", "EnsembleAgent", "name", ea.name)
+
+	if len(ea.members) == 0 {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no members configured for agent %s", ea.name))
+	}
+	if ea.aggregation == AggregateJudge && ea.client == nil {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no LLM client configured for the judge of agent %s", ea.name))
+	}
+
+	budget := runBudgets(wctx, nil)
+	if err := budget.check(ea.name); err != nil {
+		return budgetFailure(err, ea.name, ea.agentType, 0)
+	}
+
+	result := &EnsembleResult{Votes: ea.collectVotes(wctx)}
+	totalTokens := 0
+	valid := 0
+	for _, vote := range result.Votes {
+		totalTokens += vote.Tokens
+		if vote.Answer != "" {
+			valid++
+		}
+	}
+	if valid == 0 {
+		err := fmt.Errorf("ensemble %s: no member produced a valid answer", ea.name)
+		if len(result.Votes) > 0 && result.Votes[0].Error != "" {
+			err = fmt.Errorf("%w: %s", err, result.Votes[0].Error)
+		}
+		logger.Error("no valid votes", "votes", len(result.Votes))
+		return ea.failed(result, err, totalTokens, startTime)
+	}
+
+	result.Tally = make(map[string]int)
+	for _, vote := range result.Votes {
+		if vote.Answer != "" {
+			result.Tally[vote.Answer]++
+		}
+	}
+
+	winner := ea.majority(result.Votes, result.Tally)
+	if ea.aggregation == AggregateJudge {
+		choice, rationale, tokens, err := ea.judge(wctx, budget, result.Votes)
+		totalTokens += tokens
+		if err != nil {
+			logger.Error("judge failed", "error", err)
+			return ea.failed(result, fmt.Errorf("judge failed: %w", err), totalTokens, startTime)
+		}
+		winner, result.Rationale = choice, rationale
+	}
+
+	result.Content = winner.Content
+	result.Answer = winner.Answer
+	result.Confidence = float64(result.Tally[winner.Answer]) / float64(valid)
+	logger.Info("ensemble decided", "answer", result.Answer, "confidence", result.Confidence, "votes", len(result.Votes))
+
+	if ea.minConfidence > 0 && result.Confidence < ea.minConfidence {
+		return ea.failed(result, fmt.Errorf("ensemble %s: confidence %.2f is below %.2f", ea.name, result.Confidence, ea.minConfidence), totalTokens, startTime)
+	}
+
+	report := workflow.NewCompletedWorkReport()
+	report.Data = result
+	ea.addMetadata(&report, result, totalTokens, startTime)
+	return report
+}
+
+// collectVotes runs every sample of every member concurrently and returns
+// the votes in member and sample order.
+func (ea *EnsembleAgent) collectVotes(wctx workflow.WorkContext) []Vote {
+	votes := make([]Vote, len(ea.members)*ea.samples)
+	var wg sync.WaitGroup
+	for m, member := range ea.members {
+		for s := 0; s < ea.samples; s++ {
+			wg.Add(1)
+			go func(index int, member workflow.Action, sample int) {
+				defer wg.Done()
+				votes[index] = ea.vote(wctx, member, sample)
+			}(m*ea.samples+s, member, s+1)
+		}
+	}
+	wg.Wait()
+	return votes
+}
+
+// vote runs one member sample and extracts its answer.
+func (ea *EnsembleAgent) vote(wctx workflow.WorkContext, member workflow.Action, sample int) Vote {
+	start := time.Now()
+	report := member.Run(wctx)
+	vote := Vote{
+		Member:  member.Name(),
+		Sample:  sample,
+		Tokens:  reportTokens(report),
+		Elapsed: time.Since(start),
+	}
+	if report.Status == workflow.StatusFailure {
+		vote.Error = firstError(report).Error()
+		return vote
+	}
+	vote.Content = workflow.ExtractContent(report.Data)
+
+	answer := vote.Content
+	if ea.aggregation == AggregateJSONField {
+		value, err := jsonField(vote.Content, ea.field)
+		if err != nil {
+			vote.Error = err.Error()
+			return vote
+		}
+		answer = value
+	}
+	vote.Answer = ea.normalize(answer)
+	return vote
+}
+
+// majority returns the first vote of the most frequent answer. Ties go to
+// the answer that appears first.
+func (ea *EnsembleAgent) majority(votes []Vote, tally map[string]int) Vote {
+	var winner Vote
+	best := 0
+	for _, vote := range votes {
+		if vote.Answer != "" && tally[vote.Answer] > best {
+			winner, best = vote, tally[vote.Answer]
+		}
+	}
+	return winner
+}
+
+// judge asks the LLM judge to select one of the distinct valid answers.
+func (ea *EnsembleAgent) judge(wctx workflow.WorkContext, budget budgets, votes []Vote) (Vote, string, int, error) {
+	var candidates []Vote
+	seen := make(map[string]bool)
+	for _, vote := range votes {
+		if vote.Answer != "" && !seen[vote.Answer] {
+			seen[vote.Answer] = true
+			candidates = append(candidates, vote)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], "all members agreed", 0, nil
+	}
+
+	task := ""
+	if input, ok := wctx.Get(constants.KeyUserInput); ok {
+		task, _ = input.(string)
+	}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Task:\n%s\n\n", task)
+	for i, candidate := range candidates {
+		fmt.Fprintf(&prompt, "Candidate %d:\n%s\n\n", i+1, candidate.Content)
+	}
+
+	// The members may have used up the budget
+	if err := budget.check(ea.name); err != nil {
+		return Vote{}, "", 0, err
+	}
+	response, err := budget.complete(wctx.Context(), ea.client, ea.name, llm.CompletionRequest{
+		Model: ea.model,
+		Messages: []llm.Message{
+			{Role: constants.RoleSystem, Content: ea.judgePrompt},
+			{Role: constants.RoleUser, Content: strings.TrimSpace(prompt.String())},
+		},
+		JSONSchema:   JudgmentSchema,
+		ResponseType: llm.ResponseTypeJSONSchema,
+		MaxTokens:    ea.maxTokens,
+		Temperature:  ea.temperature,
+		Metadata: map[string]interface{}{
+			"agent_name": ea.name,
+			"agent_type": ea.agentType,
+			"phase":      "judge",
+		},
+	})
+	if err != nil {
+		return Vote{}, "", 0, err
+	}
+
+	var judgment struct {
+		Choice    int    `json:"choice"`
+		Rationale string `json:"rationale"`
+	}
+	if err := decodeJSONObject(response.Content, &judgment); err != nil {
+		return Vote{}, "", response.Usage.TotalTokens, err
+	}
+	if judgment.Choice < 1 || judgment.Choice > len(candidates) {
+		return Vote{}, "", response.Usage.TotalTokens, fmt.Errorf("judge chose candidate %d of %d", judgment.Choice, len(candidates))
+	}
+	return candidates[judgment.Choice-1], judgment.Rationale, response.Usage.TotalTokens, nil
+}
+
+// failed returns a failed report carrying the votes collected so far. An
+// exceeded budget is reported like in the other agents.
+func (ea *EnsembleAgent) failed(result *EnsembleResult, err error, totalTokens int, startTime time.Time) workflow.WorkReport {
+	report := workflow.NewFailedWorkReport(err)
+	if errors.Is(err, ErrBudgetExceeded) {
+		report = budgetFailure(err, ea.name, ea.agentType, totalTokens)
+	}
+	report.Data = result
+	ea.addMetadata(&report, result, totalTokens, startTime)
+	return report
+}
+
+// addMetadata records the ensemble details in the report.
+func (ea *EnsembleAgent) addMetadata(report *workflow.WorkReport, result *EnsembleResult, totalTokens int, startTime time.Time) {
+	report.SetMetadata("agent_name", ea.name)
+	report.SetMetadata("agent_type", ea.agentType)
+	report.SetMetadata("elapsed", time.Since(startTime))
+	report.SetMetadata("total_tokens", totalTokens)
+	report.SetMetadata("aggregation", ea.aggregation)
+	report.SetMetadata("votes", result.Votes)
+	report.SetMetadata("confidence", result.Confidence)
+	report.SetMetadata("execution_type", "ensemble")
+}
+
+// NormalizeAnswer is the default answer normalization of an EnsembleAgent:
+// it lowercases the answer, collapses whitespace and trims surrounding
+// punctuation and quotes, so that "Positive." and "positive" count as the
+// same vote.
+func NormalizeAnswer(answer string) string {
+	answer = strings.ToLower(strings.Join(strings.Fields(answer), " "))
+	return strings.TrimFunc(answer, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+}
+
+// jsonField returns a field of the JSON object in the content as a string.
+func jsonField(content, field string) (string, error) {
+	var object map[string]interface{}
+	if err := decodeJSONObject(content, &object); err != nil {
+		return "", err
+	}
+	var value interface{} = object
+	for _, key := range strings.Split(field, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("field %s not found in response", field)
+		}
+		if value, ok = m[key]; !ok {
+			return "", fmt.Errorf("field %s not found in response", field)
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []interface{}:
+		// Lists vote as sets, independent of order
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		sort.Strings(items)
+		return strings.Join(items, ","), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// cyclingLLMClient returns its responses in turn and is safe for concurrent use.
+type cyclingLLMClient struct {
+	mu        sync.Mutex
+	responses []string
+	calls     int
+}
+
+func (c *cyclingLLMClient) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	content := c.responses[c.calls%len(c.responses)]
+	c.calls++
+	return &llm.CompletionResponse{Content: content, Usage: llm.Usage{TotalTokens: 10}}, nil
+}
+
+func (c *cyclingLLMClient) Close() error { return nil }
+
+// answer returns an action that answers with fixed content.
+func answer(name, content string) workflow.Action {
+	return workflow.NewActionFunc(name, func(workflow.WorkContext) workflow.WorkReport {
+		report := workflow.NewCompletedWorkReport()
+		report.Data = &llm.CompletionResponse{Content: content}
+		return report
+	})
+}
+
+func TestEnsembleAgent_SelfConsistency(t *testing.T) {
+	client := &cyclingLLMClient{responses: []string{"Positive.", "negative", " positive", "POSITIVE!", "neutral"}}
+	classifier := NewChatAgent("classifier").WithClient(client)
+
+	ea := NewEnsembleAgent("sentiment", classifier).WithSamples(5)
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I love it")
+	report := ea.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+
+	result := report.Data.(*EnsembleResult)
+	if result.Answer != "positive" || result.Confidence != 0.6 {
+		t.Errorf("Expected positive with confidence 0.6, got %q %v", result.Answer, result.Confidence)
+	}
+	if len(result.Votes) != 5 || result.Tally["positive"] != 3 || result.Tally["negative"] != 1 {
+		t.Errorf("Unexpected votes %+v, tally %v", result.Votes, result.Tally)
+	}
+	if report.Metadata["total_tokens"] != 50 {
+		t.Errorf("Expected 50 tokens, got %v", report.Metadata["total_tokens"])
+	}
+	if workflow.ExtractContent(report.Data) == "" {
+		t.Error("Expected the winning content for chaining")
+	}
+}
+
+func TestEnsembleAgent_InvalidSettings(t *testing.T) {
+	ea := NewEnsembleAgent("sentiment", answer("a", "Yes"), answer("b", "yes"), answer("c", "No"))
+	if err := ea.Configure(map[string]interface{}{"samples": -1}); err == nil {
+		t.Error("Expected an error for a negative number of samples")
+	}
+
+	// Without a normalizer the answers are compared as they are
+	ea.WithNormalizer(nil)
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Well?")
+	report := ea.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+	if result := report.Data.(*EnsembleResult); len(result.Votes) != 3 || result.Tally["yes"] != 1 || result.Tally["Yes"] != 1 {
+		t.Errorf("Expected one vote per answer, got %v", result.Tally)
+	}
+}
+
+func TestEnsembleAgent_JSONFieldVote(t *testing.T) {
+	failing := workflow.NewActionFunc("broken", func(workflow.WorkContext) workflow.WorkReport {
+		return workflow.NewFailedWorkReport(errors.New("model unavailable"))
+	})
+	ea := NewEnsembleAgent("intent",
+		answer("gpt", `{"intent": "refund", "reason": "charged twice"}`),
+		answer("claude", "Sure! ```json\n{\"intent\": \"Refund\", \"reason\": \"double charge\"}\n```"),
+		answer("llama", `{"intent": "billing"}`),
+		answer("mistral", `no json here`),
+		failing,
+	).WithJSONFieldVote("intent").WithMinConfidence(0.6)
+
+	report := ea.Run(workflow.NewWorkContext(context.Background()))
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+	result := report.Data.(*EnsembleResult)
+	if result.Answer != "refund" || result.Confidence < 0.66 || result.Confidence > 0.67 {
+		t.Errorf("Expected refund with 2 of 3 valid votes, got %q %v", result.Answer, result.Confidence)
+	}
+	if result.Votes[3].Error == "" || result.Votes[4].Error != "model unavailable" {
+		t.Errorf("Expected invalid votes to carry their error, got %+v", result.Votes[3:])
+	}
+
+	strict := NewEnsembleAgent("intent", answer("a", `{"intent": "refund"}`), answer("b", `{"intent": "billing"}`)).
+		WithJSONFieldVote("intent").
+		WithMinConfidence(0.75)
+	report = strict.Run(workflow.NewWorkContext(context.Background()))
+	if report.Status != workflow.StatusFailure || !strings.Contains(firstError(report).Error(), "confidence") {
+		t.Errorf("Expected a low confidence failure, got %v: %v", report.Status, report.Errors)
+	}
+}
+
+func TestEnsembleAgent_Judge(t *testing.T) {
+	judge := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"choice": 2, "rationale": "Candidate 2 cites the statute."}`, Usage: llm.Usage{TotalTokens: 30}},
+	}}
+	ea := NewEnsembleAgent("legal",
+		answer("fast", "It is allowed."),
+		answer("careful", "It is allowed under section 12."),
+		answer("fast-2", "It is allowed."),
+	).WithJudge(judge, "judge-model")
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Is it allowed?")
+	report := ea.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+
+	result := report.Data.(*EnsembleResult)
+	if result.Content != "It is allowed under section 12." || result.Rationale != "Candidate 2 cites the statute." {
+		t.Errorf("Expected the judge's choice, got %+v", result)
+	}
+	if result.Confidence < 0.33 || result.Confidence > 0.34 {
+		t.Errorf("Expected the agreement ratio of the chosen answer, got %v", result.Confidence)
+	}
+	prompt := judge.requests[0].Messages[1].Content
+	if strings.Count(prompt, "Candidate") != 2 || !strings.Contains(prompt, "Is it allowed?") {
+		t.Errorf("Expected distinct candidates and the task in the judge prompt, got %q", prompt)
+	}
+	if judge.requests[0].JSONSchema != JudgmentSchema {
+		t.Error("Expected the judgment schema")
+	}
+}
+
+func TestEnsembleAgent_JudgeWithinBudget(t *testing.T) {
+	members := &cyclingLLMClient{responses: []string{"Paris", "Lyon"}}
+	judge := &recordingLLMClient{}
+	ea := NewEnsembleAgent("capital",
+		NewChatAgent("a").WithClient(members),
+		NewChatAgent("b").WithClient(members),
+	).WithJudge(judge, "gpt-4o")
+
+	budget := NewBudget().WithMaxTokens(20)
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyBudget, budget)
+	ctx.Set(constants.KeyUserInput, "Capital of France?")
+
+	report := ea.Run(ctx)
+	if !errors.Is(firstError(report), ErrBudgetExceeded) || report.Metadata["budget_exceeded"] != BudgetTokens {
+		t.Fatalf("Expected the members to use up the budget before the judge, got %v", report.Errors)
+	}
+	if len(judge.requests) != 0 {
+		t.Errorf("Expected no judge call, got %d", len(judge.requests))
+	}
+	if report.Metadata["total_tokens"] != 20 {
+		t.Errorf("Expected the member tokens, got %v", report.Metadata["total_tokens"])
+	}
+
+	// An exhausted budget fails before any member runs
+	if report := ea.Run(ctx); !errors.Is(firstError(report), ErrBudgetExceeded) || members.calls != 2 {
+		t.Errorf("Expected no member run after the budget was used up, got %d calls", members.calls)
+	}
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/guardrail"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// guardMessages runs the input guardrails on the user messages. The returned
+// messages contain any modifications; the slice passed in is not changed.
+func guardMessages(ctx context.Context, pipeline guardrail.Pipeline, agentName string, messages []llm.Message) ([]llm.Message, []string, error) {
+	if len(pipeline) == 0 {
+		return messages, nil, nil
+	}
+
+	guarded := make([]llm.Message, len(messages))
+	copy(guarded, messages)
+
+	var modified []string
+	for i, msg := range guarded {
+		if msg.Role != constants.RoleUser {
+			continue
+		}
+		outcome, err := pipeline.Run(ctx, guardrail.Check{
+			Stage:   guardrail.StageInput,
+			Content: msg.Content,
+			Agent:   agentName,
+			Role:    msg.Role,
+		})
+		if err != nil {
+			return nil, modified, err
+		}
+		guarded[i].Content = outcome.Content
+		modified = append(modified, outcome.Modified...)
+	}
+	return guarded, modified, nil
+}
+
+// guardOutput runs the output guardrails on a final response. A modified
+// response is returned as a copy.
+func guardOutput(ctx context.Context, pipeline guardrail.Pipeline, agentName string, response *llm.CompletionResponse) (*llm.CompletionResponse, []string, error) {
+	if len(pipeline) == 0 || response == nil {
+		return response, nil, nil
+	}
+
+	outcome, err := pipeline.Run(ctx, guardrail.Check{
+		Stage:   guardrail.StageOutput,
+		Content: response.Content,
+		Agent:   agentName,
+	})
+	if err != nil {
+		return response, nil, err
+	}
+	if len(outcome.Modified) > 0 {
+		guarded := *response
+		guarded.Content = outcome.Content
+		return &guarded, outcome.Modified, nil
+	}
+	return response, nil, nil
+}
+
+// guardrailFailure returns a failed report for a guardrail block.
+func guardrailFailure(err error, agentName string, agentType AgentType) workflow.WorkReport {
+	report := workflow.NewFailedWorkReport(err)
+	report.SetMetadata("agent_name", agentName)
+	report.SetMetadata("agent_type", agentType)
+
+	var blocked *guardrail.BlockedError
+	if errors.As(err, &blocked) {
+		report.SetMetadata("guardrail_blocked", blocked.Guardrail)
+		report.SetMetadata("guardrail_stage", blocked.Stage)
+	}
+	return report
+}
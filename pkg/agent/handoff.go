@@ -0,0 +1,299 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// handoffToolPrefix is the prefix of the synthetic tools that trigger a handoff.
+const handoffToolPrefix = "transfer_to_"
+
+// defaultMaxHandoffs bounds nested handoffs within a single run to prevent
+// agents from bouncing the conversation between each other forever.
+const defaultMaxHandoffs = 5
+
+// handoffDepthKey stores the number of nested handoffs in the WorkContext.
+type handoffDepthKey struct{}
+
+// Handoff describes an agent that a ToolAgent may transfer the conversation to.
+type Handoff struct {
+	// Agent receives the conversation when the handoff is chosen.
+	Agent Agent
+
+	// Description tells the model when to choose this handoff.
+	Description string
+}
+
+// HandoffToolName returns the name of the synthetic tool offered to the model
+// for a handoff to the named agent.
+func HandoffToolName(agentName string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(agentName) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return handoffToolPrefix + sb.String()
+}
+
+// handoffTool exposes a handoff to the model as a tool. It is intercepted by
+// the ToolAgent and never executed directly.
+type handoffTool struct {
+	handoff *Handoff
+}
+
+func (h *handoffTool) Name() string {
+	return HandoffToolName(h.handoff.Agent.Name())
+}
+
+func (h *handoffTool) Description() string {
+	return h.handoff.Description
+}
+
+func (h *handoffTool) Parameters() tools.Schema {
+	return tools.Schema{
+		Type: constants.SchemaTypeObject,
+		Properties: map[string]interface{}{
+			"reason": map[string]interface{}{
+				"type":        constants.SchemaTypeString,
+				"description": "Why the conversation is being transferred",
+			},
+		},
+	}
+}
+
+func (h *handoffTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("handoff to %s must be performed by the calling agent", h.handoff.Agent.Name())
+}
+
+// WithHandoffs allows the ToolAgent to transfer the conversation to the given agents.
+// Each target is offered to the model as a "transfer_to_<name>" tool.
+func (ta *ToolAgent) WithHandoffs(targets ...Agent) *ToolAgent {
+	for _, target := range targets {
+		ta.WithHandoff(target, fmt.Sprintf("Transfer the conversation to the %s agent.", target.Name()))
+	}
+	return ta
+}
+
+// WithHandoff adds a handoff target with a description of when to use it.
+func (ta *ToolAgent) WithHandoff(target Agent, description string) *ToolAgent {
+	ta.handoffs = append(ta.handoffs, &Handoff{Agent: target, Description: description})
+	return ta
+}
+
+// WithMaxHandoffs sets the maximum number of nested handoffs in a single run.
+func (ta *ToolAgent) WithMaxHandoffs(max int) *ToolAgent {
+	ta.maxHandoffs = max
+	return ta
+}
+
+// Handoffs returns the handoff targets of the ToolAgent.
+func (ta *ToolAgent) Handoffs() []*Handoff {
+	return ta.handoffs
+}
+
+// availableTools returns the agent's tools followed by the built-in tools.
+func (ta *ToolAgent) availableTools() []tools.Tool {
+	builtin := ta.builtinTools()
+	if len(builtin) == 0 && ta.registry == nil {
+		return ta.tools
+	}
+	candidates := ta.candidateTools()
+	available := make([]tools.Tool, 0, len(candidates)+len(builtin))
+	available = append(available, candidates...)
+	return append(available, builtin...)
+}
+
+// builtinTools returns the synthetic handoff tools, the tool that reads
+// result pages when results are paged and the tool that searches tools when
+// the model selects them.
+func (ta *ToolAgent) builtinTools() []tools.Tool {
+	var builtin []tools.Tool
+	for _, handoff := range ta.handoffs {
+		builtin = append(builtin, &handoffTool{handoff: handoff})
+	}
+	if ta.pagesResults() {
+		builtin = append(builtin, readResultTool{})
+	}
+	if ta.selector != nil && ta.selector.Selection == SelectBySearch {
+		builtin = append(builtin, searchToolsTool{agent: ta})
+	}
+	return builtin
+}
+
+// handoffFor returns the handoff selected by a tool call, if any.
+func (ta *ToolAgent) handoffFor(toolCall llm.ToolCall) (*Handoff, bool) {
+	if !strings.HasPrefix(toolCall.Name, handoffToolPrefix) {
+		return nil, false
+	}
+	for _, handoff := range ta.handoffs {
+		if HandoffToolName(handoff.Agent.Name()) == toolCall.Name {
+			return handoff, true
+		}
+	}
+	return nil, false
+}
+
+// handoffAllowed reports whether another nested handoff may be performed.
+func (ta *ToolAgent) handoffAllowed(wctx workflow.WorkContext) bool {
+	depth, _ := wctx.Get(handoffDepthKey{})
+	current, _ := depth.(int)
+	return current < ta.maxHandoffs
+}
+
+// runHandoff moves the conversation to the handoff target and runs it. The
+// target's report is returned with the handoff chain, the combined token
+// usage of both agents and the transcript of this agent's part of the run.
+// The target receives the whole conversation, including turns that were left
+// out of this agent's requests to fit the context window, and fits it into its
+// own requests.
+func (ta *ToolAgent) runHandoff(wctx workflow.WorkContext, handoff *Handoff, toolCall llm.ToolCall, transcript *Transcript, messages []llm.Message, tokens int, startTime time.Time) workflow.WorkReport {
+	target := handoff.Agent
+	reason, _ := toolCall.Args["reason"].(string)
+
+	// Extend the chain of agents that handled this conversation
+	chain := []string{ta.name}
+	if existing, ok := wctx.Get(constants.KeyHandoffChain); ok {
+		if names, ok := existing.([]string); ok && len(names) > 0 && names[len(names)-1] == ta.name {
+			chain = append([]string{}, names...)
+		}
+	}
+	chain = append(chain, target.Name())
+	wctx.Set(constants.KeyHandoffChain, chain)
+
+	ta.log.Info("handing off conversation", "target", target.Name(), "reason", reason, "chain", chain)
+	wctx.EmitEvent(workflow.Event{
+		Type:      workflow.EventAgentHandoff,
+		Source:    ta.name,
+		Timestamp: time.Now(),
+		Payload:   reason,
+		Metadata: map[string]interface{}{
+			"from":          ta.name,
+			"to":            target.Name(),
+			"handoff_chain": chain,
+		},
+	})
+
+	// The target continues the conversation from the shared history. When
+	// the current input is no longer the last message it stays in the history
+	// and the input is cleared for the target run to keep the turn order.
+	userInput, _ := wctx.Get(constants.KeyUserInput)
+	transcript.systemPrompt, _ = ta.systemPrompt(wctx)
+	transcript.finish(messages, nil)
+	conversation := transcript.history()
+	history, keepInput := ta.handoffHistory(conversation, userInput)
+	stored, _ := wctx.Get(constants.KeyMessageHistory)
+	wctx.Set(constants.KeyMessageHistory, history)
+	if !keepInput {
+		wctx.Set(constants.KeyUserInput, "")
+	}
+
+	depth, _ := wctx.Get(handoffDepthKey{})
+	current, _ := depth.(int)
+	wctx.Set(handoffDepthKey{}, current+1)
+	report := target.Run(wctx)
+	wctx.Set(handoffDepthKey{}, current)
+
+	if !keepInput {
+		wctx.Set(constants.KeyUserInput, userInput)
+	}
+	written, _ := wctx.Get(constants.KeyMessageHistory)
+	wctx.Set(constants.KeyMessageHistory, mergeHandoffHistory(stored, written, conversation, history))
+
+	if finalChain, ok := wctx.Get(constants.KeyHandoffChain); ok {
+		if names, ok := finalChain.([]string); ok && len(names) > 0 {
+			chain = names
+		}
+	}
+
+	report.SetMetadata("handoff_chain", chain)
+	report.SetMetadata("handoff_from", ta.name)
+	report.SetMetadata("handoff_reason", reason)
+	report.SetMetadata("active_agent", chain[len(chain)-1])
+	report.SetMetadata("total_tokens", tokens+reportTokens(report))
+	report.SetMetadata("elapsed", time.Since(startTime))
+
+	// The transcripts of the agents that handed off precede the target's own
+	transcripts := []*Transcript{transcript}
+	transcripts = append(transcripts, HandoffTranscripts(report)...)
+	report.SetMetadata("handoff_transcripts", transcripts)
+
+	return report
+}
+
+// handoffHistory returns the conversation to hand to the target agent. The
+// source agent's raw tool exchanges are left behind. When the current user
+// input is the last message it is removed from the history and keepInput is
+// true, because the target reads it from the WorkContext.
+func (ta *ToolAgent) handoffHistory(conversation []llm.Message, userInput interface{}) (history []llm.Message, keepInput bool) {
+	for _, msg := range conversation {
+		switch {
+		case msg.Role == constants.RoleTool:
+			continue
+		case strings.TrimSpace(msg.Content) == "":
+			continue
+		}
+		history = append(history, msg)
+	}
+
+	input, _ := userInput.(string)
+	if input == "" {
+		return history, true
+	}
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		if last.Role == constants.RoleUser && last.Content == input {
+			return history[:len(history)-1], true
+		}
+	}
+	return history, false
+}
+
+// mergeHandoffHistory returns the message history to keep after a handoff.
+// When the target wrote its conversation back, its new turns follow this
+// agent's whole conversation, tool exchanges included. When it wrote nothing
+// back the history stored before the handoff is kept.
+func mergeHandoffHistory(stored, written interface{}, conversation, handed []llm.Message) interface{} {
+	messages, ok := written.([]llm.Message)
+	switch {
+	case !ok || (len(messages) == len(handed) && hasMessagePrefix(messages, handed)):
+		return stored
+	case !hasMessagePrefix(messages, handed):
+		// The target rewrote the history, for example with a summary
+		return messages
+	}
+
+	// The target repeats the current input, which the conversation already holds
+	added := messages[len(handed):]
+	for i := len(conversation) - 1; i >= 0; i-- {
+		if conversation[i].Role == constants.RoleUser {
+			if added[0] == conversation[i] {
+				added = added[1:]
+			}
+			break
+		}
+	}
+	return append(append([]llm.Message{}, conversation...), added...)
+}
+
+// hasMessagePrefix reports whether messages starts with prefix.
+func hasMessagePrefix(messages, prefix []llm.Message) bool {
+	if len(messages) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if messages[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestToolAgent_Handoff(t *testing.T) {
+	billingClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Your refund has been issued.", Usage: llm.Usage{TotalTokens: 30}},
+	}}
+	billing := NewChatAgent("billing").WithPrompt("You handle billing.").WithClient(billingClient)
+
+	triageClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{
+			ToolCalls: []llm.ToolCall{{ID: "1", Name: "transfer_to_billing", Args: map[string]interface{}{"reason": "refund request"}}},
+			Usage:     llm.Usage{TotalTokens: 20},
+		},
+	}}
+	triage := NewToolAgent("triage").
+		WithPrompt("You route customers.").
+		WithClient(triageClient).
+		WithHandoffs(billing)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyMessageHistory, []llm.Message{
+		{Role: constants.RoleUser, Content: "Hi"},
+		{Role: constants.RoleAssistant, Content: "Hello, how can I help?"},
+	})
+	ctx.Set(constants.KeyUserInput, "I want a refund")
+
+	report := triage.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	if response := report.Data.(*llm.CompletionResponse); response.Content != "Your refund has been issued." {
+		t.Errorf("Expected billing answer, got %q", response.Content)
+	}
+
+	toolNames := []string{}
+	for _, def := range triageClient.requests[0].Tools {
+		toolNames = append(toolNames, def.Name)
+	}
+	if !reflect.DeepEqual(toolNames, []string{"transfer_to_billing"}) {
+		t.Errorf("Expected handoff tool definition, got %v", toolNames)
+	}
+
+	want := []string{"triage", "billing"}
+	if !reflect.DeepEqual(report.Metadata["handoff_chain"], want) {
+		t.Errorf("Expected handoff chain %v, got %v", want, report.Metadata["handoff_chain"])
+	}
+	if chain, _ := ctx.Get(constants.KeyHandoffChain); !reflect.DeepEqual(chain, want) {
+		t.Errorf("Expected handoff chain in context, got %v", chain)
+	}
+	if report.Metadata["total_tokens"] != 50 {
+		t.Errorf("Expected combined token usage of 50, got %v", report.Metadata["total_tokens"])
+	}
+	transcripts := HandoffTranscripts(report)
+	if len(transcripts) != 1 || transcripts[0].Agent != "triage" {
+		t.Fatalf("Expected the triage transcript, got %+v", transcripts)
+	}
+	if calls := transcripts[0].Iterations[0].ToolCalls; len(calls) != 1 || calls[0].Name != "transfer_to_billing" {
+		t.Errorf("Expected the handoff call in the transcript, got %+v", calls)
+	}
+
+	messages := billingClient.requests[0].Messages
+	if len(messages) != 4 {
+		t.Fatalf("Expected history, billing prompt and input, got %+v", messages)
+	}
+	for _, msg := range messages {
+		if msg.Content == "You route customers." {
+			t.Error("Triage prompt should not be handed to the billing agent")
+		}
+	}
+	if messages[2].Content != "You handle billing." || messages[3].Content != "I want a refund" {
+		t.Errorf("Unexpected billing conversation: %+v", messages)
+	}
+}
+
+func TestToolAgent_HandoffLimit(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "1", Name: "transfer_to_other"}}},
+		{Content: "I'll help you myself."},
+	}}
+	other := NewChatAgent("other").WithClient(&recordingLLMClient{})
+	ta := NewToolAgent("front").WithClient(client).WithHandoffs(other).WithMaxHandoffs(0)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Help")
+	report := ta.Run(ctx)
+
+	if response := report.Data.(*llm.CompletionResponse); response.Content != "I'll help you myself." {
+		t.Errorf("Expected the front agent to continue, got %q", response.Content)
+	}
+	if _, ok := report.Metadata["handoff_chain"]; ok {
+		t.Error("No handoff should have been recorded")
+	}
+	if transcript, _ := TranscriptFromReport(report); !strings.Contains(transcript.Iterations[0].ToolCalls[0].Result, "refused") {
+		t.Errorf("Expected the refused handoff in the transcript, got %+v", transcript.Iterations[0].ToolCalls)
+	}
+}
+
+func TestToolAgent_HandoffKeepsWholeConversation(t *testing.T) {
+	billingClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Your refund has been issued."},
+	}}
+	billing := NewToolAgent("billing").
+		WithModel("local-model").
+		WithClient(billingClient).
+		WithMaxTokens(500).
+		WithContextWindow(2000).
+		WithTranscriptHistory(true)
+
+	triageClient := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "1", Name: "transfer_to_billing"}}},
+	}}
+	triage := NewToolAgent("triage").
+		WithModel("local-model").
+		WithClient(triageClient).
+		WithMaxTokens(500).
+		WithContextWindow(2000).
+		WithHandoffs(billing)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyMessageHistory, conversation(10))
+	ctx.Set(constants.KeyUserInput, "I want a refund")
+	if report := triage.Run(ctx); report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+
+	// Both requests are fitted into the context window
+	tokenizer := llm.NewApproxTokenizer()
+	for _, req := range []llm.CompletionRequest{triageClient.requests[0], billingClient.requests[0]} {
+		if tokens := llm.CountMessageTokens(tokenizer, req.Messages); tokens > 1500 || len(req.Messages) >= len(conversation(10)) {
+			t.Errorf("Expected a fitted request, got %d messages and %d tokens", len(req.Messages), tokens)
+		}
+	}
+
+	// The stored conversation keeps the dropped turns, the tool exchanges and the new turn
+	value, _ := ctx.Get(constants.KeyMessageHistory)
+	history := value.([]llm.Message)
+	if len(history) != len(conversation(10))+3 {
+		t.Fatalf("Expected the whole conversation with the new turn, got %d messages: %+v", len(history), history)
+	}
+	if !strings.HasPrefix(history[1].Content, "Question 0") || history[3].Role != constants.RoleTool {
+		t.Errorf("Expected the first turn with its tool result, got %+v", history[1:4])
+	}
+	last := history[len(history)-3:]
+	if last[0].Content != "I want a refund" || last[1].Role != constants.RoleAssistant || last[2].Content != "Your refund has been issued." {
+		t.Errorf("Expected the input, the handoff and the answer, got %+v", last)
+	}
+}
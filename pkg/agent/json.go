@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// decodeJSONObject decodes the first JSON object found in model output into v.
+// Models occasionally wrap structured output in prose or Markdown code fences
+// even when asked not to, so the object is located before decoding.
+func decodeJSONObject(content string, v interface{}) error {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON object found in response")
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), v); err != nil {
+		return fmt.Errorf("invalid JSON in response: %w", err)
+	}
+	return nil
+}
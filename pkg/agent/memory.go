@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/memory"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// compactHistory applies the memory strategy to the message history.
+// When the strategy produced a new summary, the compacted version is written
+// back to the WorkContext so that the rolling summary carries over to the next
+// run.
+// Completions of the strategy count against the budgets of the run.
+func compactHistory(wctx workflow.WorkContext, budget budgets, agentName string, strategy memory.Strategy, maxTokens int, history []llm.Message) ([]llm.Message, error) {
+	if strategy == nil || len(history) == 0 {
+		return history, nil
+	}
+
+	ctx := context.WithValue(wctx.Context(), constants.KeyCompleter, budget.completer(agentName))
+	compacted, err := strategy.Fit(ctx, history, maxTokens)
+	if err != nil {
+		return nil, fmt.Errorf("memory compaction failed: %w", err)
+	}
+
+	if summary := rollingSummary(compacted); summary != "" && summary != rollingSummary(history) {
+		wctx.Set(constants.KeyMessageHistory, compacted)
+	}
+
+	return compacted, nil
+}
+
+// rollingSummary returns the content of the summary message of a history.
+func rollingSummary(history []llm.Message) string {
+	for _, msg := range history {
+		if memory.IsSummary(msg) {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+// contextFitting fits the message history into the context window of the
+// model, leaving room for the rest of the request and the output.
+type contextFitting struct {
+	window    int             // Context window in tokens; 0 looks up the model, negative disables fitting
+	strategy  memory.Strategy // Strategy for histories that do not fit; drops the oldest turns by default
+	tokenizer llm.Tokenizer
+}
+
+// fit reduces the history to the tokens left in the context window after the
+// reserved tokens. A configured strategy always sees the history, so that
+// limits such as the turn limit of a TrimmingMemory apply even when the
+// history fits. The history is left alone when the window of the model is
+// unknown. The fitted history is meant for the outgoing request only; it is
+// written back to the WorkContext only when the strategy produced a summary.
+func (cf contextFitting) fit(wctx workflow.WorkContext, budget budgets, agentName, model string, history []llm.Message, reserved int) ([]llm.Message, error) {
+	window := cf.window
+	if window == 0 {
+		window = llm.ContextWindow(model)
+	}
+	if window <= 0 || len(history) == 0 {
+		return history, nil
+	}
+
+	tokenizer := cf.tokenizer
+	if tokenizer == nil {
+		tokenizer = llm.NewApproxTokenizer()
+	}
+	available := window - reserved
+	if available <= 0 {
+		return nil, fmt.Errorf("context window of %d tokens is too small: %d tokens are needed for the prompt, tools and output", window, reserved)
+	}
+	strategy := cf.strategy
+	if strategy == nil {
+		if llm.CountMessageTokens(tokenizer, history) <= available {
+			return history, nil
+		}
+		strategy = memory.NewTrimmingMemory().WithTokenizer(tokenizer)
+	}
+	fitted, err := compactHistory(wctx, budget, agentName, strategy, available, history)
+	if err != nil {
+		return nil, err
+	}
+	if used := llm.CountMessageTokens(tokenizer, fitted); used > available {
+		// The latest turn alone is too large; let the provider decide
+		wctx.Logger().Warn("message history exceeds the context window", "tokens", used, "available", available)
+	}
+	return fitted, nil
+}
+
+// reserved returns the tokens a request needs besides the message history:
+// the output, the other messages, the tool definitions and the JSON schema.
+func (cf contextFitting) reserved(maxTokens int, messages []llm.Message, toolDefs []llm.ToolDefinition, schema *llm.JSONSchema) int {
+	tokenizer := cf.tokenizer
+	if tokenizer == nil {
+		tokenizer = llm.NewApproxTokenizer()
+	}
+	reserved := maxTokens + llm.CountMessageTokens(tokenizer, messages)
+	if len(toolDefs) > 0 {
+		data, _ := json.Marshal(toolDefs)
+		reserved += tokenizer.CountTokens(string(data))
+	}
+	if schema != nil {
+		data, _ := json.Marshal(schema)
+		reserved += tokenizer.CountTokens(string(data))
+	}
+	return reserved
+}
@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/memory"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// conversation returns a history of turns with a tool call each, of about
+// 300 tokens per turn.
+func conversation(turns int) []llm.Message {
+	filler := strings.Repeat("lorem ipsum ", 30)
+	history := []llm.Message{{Role: constants.RoleSystem, Content: "You are a weather assistant."}}
+	for i := 0; i < turns; i++ {
+		history = append(history,
+			llm.Message{Role: constants.RoleUser, Content: fmt.Sprintf("Question %d: %s", i, filler)},
+			llm.Message{Role: constants.RoleAssistant, Content: ""},
+			llm.Message{Role: constants.RoleTool, Name: "get_weather", Content: fmt.Sprintf("Result %d: %s", i, filler)},
+			llm.Message{Role: constants.RoleAssistant, Content: fmt.Sprintf("Answer %d: %s", i, filler)},
+		)
+	}
+	return history
+}
+
+// firstTurnSummary replaces the first message after the system prompt with a
+// summary of it, keeping the length of the history.
+type firstTurnSummary struct{}
+
+func (firstTurnSummary) Fit(ctx context.Context, messages []llm.Message, maxTokens int) ([]llm.Message, error) {
+	fitted := append([]llm.Message{}, messages...)
+	fitted[1] = memory.NewSummaryMessage("The user asked a question.")
+	return fitted, nil
+}
+
+func TestChatAgent_WritesBackNewSummaries(t *testing.T) {
+	history := []llm.Message{
+		{Role: constants.RoleSystem, Content: "You are a weather assistant."},
+		{Role: constants.RoleUser, Content: "Weather in Paris?"},
+		{Role: constants.RoleAssistant, Content: "Sunny."},
+	}
+	ca := NewChatAgent("chat").WithClient(&recordingLLMClient{}).WithMemory(firstTurnSummary{}, 1)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyMessageHistory, history)
+	ctx.Set(constants.KeyUserInput, "And tomorrow?")
+	ca.Run(ctx)
+
+	stored, _ := ctx.Get(constants.KeyMessageHistory)
+	if compacted := stored.([]llm.Message); len(compacted) != 3 || !memory.IsSummary(compacted[1]) {
+		t.Errorf("Expected the summarized history to be written back, got %+v", compacted)
+	}
+
+	// Strategies that only drop turns leave the stored history alone
+	ca.WithMemory(memory.NewTrimmingMemory().WithMaxTurns(1), 1)
+	ctx.Set(constants.KeyMessageHistory, conversation(3))
+	ca.Run(ctx)
+	if stored, _ := ctx.Get(constants.KeyMessageHistory); len(stored.([]llm.Message)) != len(conversation(3)) {
+		t.Errorf("Expected the trimmed turns to stay in the stored history, got %d messages", len(stored.([]llm.Message)))
+	}
+}
+
+func TestChatAgent_FitsHistoryIntoContextWindow(t *testing.T) {
+	client := &recordingLLMClient{}
+	ca := NewChatAgent("chat").
+		WithModel("gpt-4"). // 8192 token window
+		WithPrompt("You are a weather assistant.").
+		WithClient(client)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyMessageHistory, conversation(20))
+	ctx.Set(constants.KeyUserInput, "And tomorrow?")
+	if report := ca.Run(ctx); report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the run to complete, got %v", report.Errors)
+	}
+
+	req := client.requests[0]
+	if tokens := llm.CountMessageTokens(llm.NewApproxTokenizer(), req.Messages); tokens+req.MaxTokens > 8192 {
+		t.Errorf("Expected the request to fit the window, got %d tokens and %d for the output", tokens, req.MaxTokens)
+	}
+	if req.Messages[0].Role != constants.RoleSystem || req.Messages[1].Role != constants.RoleUser || !strings.HasPrefix(req.Messages[1].Content, "Question") {
+		t.Errorf("Expected the system prompt followed by a whole turn, got %+v", req.Messages[:2])
+	}
+	if last := req.Messages[len(req.Messages)-1]; last.Content != "And tomorrow?" {
+		t.Errorf("Expected the user input last, got %+v", last)
+	}
+	if !strings.HasPrefix(req.Messages[len(req.Messages)-2].Content, "Answer 19") {
+		t.Errorf("Expected the latest turn to be kept, got %+v", req.Messages[len(req.Messages)-2])
+	}
+	history, _ := ctx.Get(constants.KeyMessageHistory)
+	if len(history.([]llm.Message)) != len(conversation(20)) {
+		t.Error("Expected the dropped turns to stay in the stored history")
+	}
+
+	// Unknown models and disabled fitting leave the history alone
+	for _, agent := range []*ChatAgent{
+		NewChatAgent("local").WithModel("local-model").WithClient(client),
+		NewChatAgent("off").WithModel("gpt-4").WithContextWindow(-1).WithClient(client),
+	} {
+		ctx := workflow.NewWorkContext(context.Background())
+		ctx.Set(constants.KeyMessageHistory, conversation(20))
+		agent.Run(ctx)
+		if got := len(client.requests[len(client.requests)-1].Messages); got != len(conversation(20)) {
+			t.Errorf("Expected %s to send the whole history, got %d messages", agent.Name(), got)
+		}
+	}
+}
+
+func TestToolAgent_FitsHistoryIntoContextWindow(t *testing.T) {
+	client := &recordingLLMClient{}
+	ta := NewToolAgent("weather").
+		WithModel("local-model").
+		WithClient(client).
+		WithTools(weatherTool()).
+		WithMaxTokens(500).
+		WithContextWindow(2000).
+		WithContextStrategy(memory.NewTrimmingMemory().WithMaxTurns(2))
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyMessageHistory, conversation(10))
+	ctx.Set(constants.KeyUserInput, "And tomorrow?")
+	report := ta.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the run to complete, got %v", report.Errors)
+	}
+
+	// The conversation to store keeps the turns left out of the request
+	transcript, _ := TranscriptFromReport(report)
+	if history := transcript.history(); len(history) != len(conversation(10))+2 || !strings.HasPrefix(history[1].Content, "Question 0") {
+		t.Errorf("Expected the whole conversation with the new turn, got %d messages", len(history))
+	}
+
+	req := client.requests[0]
+	// System prompt, two whole turns and the user input
+	if len(req.Messages) != 10 || !strings.HasPrefix(req.Messages[1].Content, "Question 8") {
+		t.Fatalf("Expected the last two turns, got %d messages: %+v", len(req.Messages), req.Messages)
+	}
+	for i, msg := range req.Messages {
+		if msg.Role == constants.RoleTool && req.Messages[i-1].Role != constants.RoleAssistant {
+			t.Errorf("Expected the tool result %d to follow its call", i)
+		}
+	}
+	tools, _ := json.Marshal(req.Tools)
+	tokenizer := llm.NewApproxTokenizer()
+	if total := llm.CountMessageTokens(tokenizer, req.Messages) + tokenizer.CountTokens(string(tools)) + req.MaxTokens; total > 2000 {
+		t.Errorf("Expected the request to fit the window, got %d tokens", total)
+	}
+
+	// The turn limit applies even when the whole history fits
+	ta.WithContextWindow(100000)
+	ctx.Set(constants.KeyMessageHistory, conversation(10))
+	if report := ta.Run(ctx); report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the run to complete, got %v", report.Errors)
+	}
+	if req := client.requests[len(client.requests)-1]; len(req.Messages) != 10 || !strings.HasPrefix(req.Messages[1].Content, "Question 8") {
+		t.Errorf("Expected the last two turns in a large window, got %d messages", len(req.Messages))
+	}
+
+	// The window cannot even hold the output
+	ta.WithContextWindow(400)
+	ctx.Set(constants.KeyMessageHistory, conversation(10))
+	if report := ta.Run(ctx); report.Status != workflow.StatusFailure || !strings.Contains(report.Errors[0].Error(), "too small") {
+		t.Errorf("Expected a context window error, got %v", report.Errors)
+	}
+}
@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/guardrail"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/output"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// ParsedOutput is the report data of an agent with an output parser. Content
+// holds the text of the response, so the report still chains into the next
+// agent; Value holds the parsed value.
+type ParsedOutput struct {
+	Content  string
+	Value    interface{}
+	Response *llm.CompletionResponse
+	Retries  int // Format-fix retries needed to parse the response
+
+	tokens int // Tokens used by the retries
+}
+
+// ParsedValue returns the parsed value of an agent report.
+func ParsedValue(report workflow.WorkReport) (interface{}, bool) {
+	parsed, ok := report.Data.(*ParsedOutput)
+	if !ok || parsed == nil {
+		return nil, false
+	}
+	return parsed.Value, true
+}
+
+// formatFixNote asks the model to repeat its answer in the expected format.
+const formatFixNote = "Your previous response could not be parsed: %v\n\n%s\nRepeat your answer in this format."
+
+// outputParsing holds the output parser settings of an agent.
+type outputParsing struct {
+	parser  output.Parser
+	retries int
+}
+
+// parse parses a final response. When the response does not parse, the
+// model is shown the error and the format instructions and asked to answer
+// again, without tools, up to the configured number of retries. req is the
+// request that produced the response; clean extracts the answer from the
+// content of a retry response and may be nil. The result holds the last
+// response received, also when parsing failed.
+func (op outputParsing) parse(wctx workflow.WorkContext, bs budgets, client llm.Client, pipeline guardrail.Pipeline, agentName string, req llm.CompletionRequest, response *llm.CompletionResponse, clean func(string) string) (*ParsedOutput, []string, error) {
+	parsed := &ParsedOutput{}
+	var guarded []string
+
+	// Prompt-only requests continue as a conversation
+	messages := append([]llm.Message{}, req.Messages...)
+	if req.Prompt != "" {
+		messages = append(messages, llm.Message{Role: constants.RoleUser, Content: req.Prompt})
+		req.Prompt = ""
+	}
+	req.Tools = nil
+
+	for attempt := 0; ; attempt++ {
+		parsed.Content, parsed.Response, parsed.Retries = response.Content, response, attempt
+		value, err := op.parser.Parse(response.Content)
+		if err == nil {
+			parsed.Value = value
+			wctx.Set(constants.KeyParsedOutput, value)
+			return parsed, guarded, nil
+		}
+		if attempt >= op.retries {
+			return parsed, guarded, err
+		}
+		if err := bs.check(agentName); err != nil {
+			return parsed, guarded, err
+		}
+
+		messages = append(messages, llm.Message{
+			Role:    constants.RoleAssistant,
+			Content: response.Content,
+		}, llm.Message{
+			Role:    constants.RoleUser,
+			Content: fmt.Sprintf(formatFixNote, err, op.parser.FormatInstructions()),
+		})
+		req.Messages = messages
+		metadata := map[string]interface{}{"parse_retry": attempt + 1}
+		for key, value := range req.Metadata {
+			if key != "parse_retry" {
+				metadata[key] = value
+			}
+		}
+		req.Metadata = metadata
+
+		retry, err := bs.complete(wctx.Context(), client, agentName, req)
+		if err != nil {
+			if errors.Is(err, ErrBudgetExceeded) {
+				return parsed, guarded, err
+			}
+			return parsed, guarded, fmt.Errorf("format fix completion failed: %w", err)
+		}
+		parsed.tokens += retry.Usage.TotalTokens
+		if clean != nil {
+			fixed := *retry
+			fixed.Content = clean(retry.Content)
+			retry = &fixed
+		}
+
+		retry, modified, err := guardOutput(wctx.Context(), pipeline, agentName, retry)
+		if err != nil {
+			return parsed, guarded, err
+		}
+		guarded = append(guarded, modified...)
+		response = retry
+	}
+}
+
+// parseFailure returns a failed report for a response that did not parse.
+// The response stays available as the report data.
+func parseFailure(err error, agentName string, agentType AgentType, parsed *ParsedOutput, totalTokens int) workflow.WorkReport {
+	var report workflow.WorkReport
+	switch {
+	case errors.Is(err, ErrBudgetExceeded):
+		report = budgetFailure(err, agentName, agentType, totalTokens)
+	case guardrail.IsBlocked(err):
+		report = guardrailFailure(err, agentName, agentType)
+	default:
+		report = workflow.NewFailedWorkReport(err)
+		report.SetMetadata("agent_name", agentName)
+		report.SetMetadata("agent_type", agentType)
+		report.SetMetadata("output_parse_failed", true)
+	}
+	report.Data = parsed.Response
+	report.SetMetadata("parse_retries", parsed.Retries)
+	report.SetMetadata("total_tokens", totalTokens)
+	return report
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/output"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+type triage struct {
+	Category string `json:"category"`
+	Urgent   bool   `json:"urgent"`
+}
+
+func TestChatAgent_OutputParser(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `Here it is: {"category": "billing", "urgent": true}`},
+	}}
+	agent := NewChatAgent("triage").WithClient(client).
+		WithOutputParser(output.NewJSONParser(&triage{}).WithRequired("category"))
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I was charged twice")
+	report := agent.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+
+	value, ok := ParsedValue(report)
+	if got, _ := value.(*triage); !ok || got == nil || got.Category != "billing" || !got.Urgent {
+		t.Errorf("Expected the parsed ticket in the report, got %+v", value)
+	}
+	if stored, _ := ctx.Get(constants.KeyParsedOutput); stored != value {
+		t.Errorf("Expected the parsed value in the context, got %v", stored)
+	}
+	if content := workflow.ExtractContent(report.Data); !strings.Contains(content, "billing") {
+		t.Errorf("Expected the report content to chain, got %q", content)
+	}
+}
+
+func TestChatAgent_OutputParserRetries(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "The category is billing.", Usage: llm.Usage{TotalTokens: 30}},
+		{Content: `{"category": "billing"}`, Usage: llm.Usage{TotalTokens: 20}},
+	}}
+	agent := NewChatAgent("triage").WithClient(client).
+		WithOutputParser(output.NewJSONParser(&triage{}).WithRequired("category")).
+		WithParseRetries(1)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I was charged twice")
+	report := agent.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the retry to fix the format, got %v", report.Errors)
+	}
+	if report.Metadata["parse_retries"] != 1 {
+		t.Errorf("Expected one retry, got %v", report.Metadata["parse_retries"])
+	}
+	if report.Metadata["total_tokens"] != 50 {
+		t.Errorf("Expected the tokens of both completions, got %v", report.Metadata["total_tokens"])
+	}
+
+	fix := client.requests[1].Messages
+	if len(fix) != 3 || fix[1].Content != "The category is billing." || !strings.Contains(fix[2].Content, "must contain the fields category") {
+		t.Errorf("Expected the fix request to show the response and the format, got %+v", fix)
+	}
+
+	client = &recordingLLMClient{responses: []*llm.CompletionResponse{{Content: "billing"}}}
+	report = agent.WithClient(client).WithParseRetries(0).Run(ctx)
+	var parseErr *output.ParseError
+	if report.Status != workflow.StatusFailure || !errors.As(firstError(report), &parseErr) {
+		t.Fatalf("Expected a parse failure, got %v", report.Errors)
+	}
+	if response, ok := report.Data.(*llm.CompletionResponse); !ok || response.Content != "billing" {
+		t.Errorf("Expected the unparsed response as report data, got %v", report.Data)
+	}
+}
+
+func TestToolAgent_OutputParser(t *testing.T) {
+	weather := weatherTool()
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "get_weather", Args: map[string]interface{}{"city": "Paris"}}}},
+		{Content: "It is sunny in Paris."},
+		{Content: "- Paris: sunny\n- Advice: no umbrella"},
+	}}
+	agent := NewToolAgent("weather").WithClient(client).WithTools(weather).
+		WithOutputParser(output.NewListParser().WithMinItems(2)).
+		WithParseRetries(2)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Weather in Paris?")
+	report := agent.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+	if items, _ := ParsedValue(report); len(items.([]string)) != 2 {
+		t.Errorf("Expected two list items, got %v", items)
+	}
+	if len(client.requests[2].Tools) != 0 {
+		t.Error("Expected the format fix request without tools")
+	}
+}
+
+func TestToolAgent_ReActOutputParser(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Thought: I know this.\nFinal Answer: billing"},
+		{Content: "Final Answer: <category>billing</category>"},
+	}}
+	agent := NewToolAgent("triage").WithClient(client).
+		WithToolCallingMode(ToolCallingReAct).
+		WithOutputParser(output.NewXMLTagParser("category")).
+		WithParseRetries(1)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I was charged twice")
+	report := agent.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+	if sections, _ := ParsedValue(report); sections.(map[string]string)["category"] != "billing" {
+		t.Errorf("Expected the category section, got %v", sections)
+	}
+}
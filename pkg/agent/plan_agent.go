@@ -0,0 +1,541 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// DefaultPlannerPrompt is the system prompt used to request a step plan.
+const DefaultPlannerPrompt = `You are a planner. Break the user's goal into a short, ordered list of concrete steps.
+Each step must be independently executable by an assistant that has access to tools.
+Do not include steps for summarizing the final answer; that happens automatically.
+Respond with JSON only: {"steps": [{"description": "..."}]}`
+
+// planSchema is the structured output schema for plans.
+var planSchema = &llm.JSONSchema{
+	Name:        "plan",
+	Description: "An ordered list of steps to achieve a goal",
+	Strict:      true,
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"steps": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"description": map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"description"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"steps"},
+		"additionalProperties": false,
+	},
+}
+
+// PlanStep is a single step of a plan.
+type PlanStep struct {
+	Description string `json:"description"`
+}
+
+// Plan is an ordered list of steps produced by the planner.
+type Plan struct {
+	Goal  string     `json:"goal"`
+	Steps []PlanStep `json:"steps"`
+}
+
+// StepResult records the outcome of an executed plan step.
+type StepResult struct {
+	Step        int           `json:"step"` // 1-based position in execution order
+	Description string        `json:"description"`
+	Completed   bool          `json:"completed"`
+	Output      string        `json:"output,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Elapsed     time.Duration `json:"elapsed"`
+}
+
+// PlanRevision records a change to the plan made during execution.
+type PlanRevision struct {
+	Revision  int      `json:"revision"`
+	AfterStep int      `json:"after_step"` // Number of steps executed before the revision
+	Reason    string   `json:"reason"`
+	Remaining []string `json:"remaining"` // Step descriptions that replaced the remaining plan
+}
+
+// PlanResult is the report data of a PlanAgent.
+type PlanResult struct {
+	Content   string         `json:"content"` // Final answer, for chaining
+	Plan      Plan           `json:"plan"`    // Initial plan
+	Steps     []StepResult   `json:"steps"`
+	Revisions []PlanRevision `json:"revisions,omitempty"`
+}
+
+// PlanAgent is a plan-and-execute agent. It asks the model for an explicit
+// step plan, runs each step with an executor (typically a ToolAgent), records
+// step outcomes in the WorkContext and re-plans when a step fails or, if
+// enabled, after every step so that new information can change the plan.
+type PlanAgent struct {
+	name            string
+	agentType       AgentType
+	model           string
+	prompt          string
+	client          llm.Client
+	executor        workflow.Action
+	maxSteps        int
+	maxReplans      int
+	replanEachStep  bool
+	synthesize      bool
+	maxTokens       int
+	temperature     float64
+	plannerResponse llm.ResponseType
+}
+
+// NewPlanAgent creates a new PlanAgent with the given name.
+func NewPlanAgent(name string) *PlanAgent {
+	return &PlanAgent{
+		name:            name,
+		agentType:       TypePlan,
+		prompt:          DefaultPlannerPrompt,
+		maxSteps:        8,    // Default maximum executed steps
+		maxReplans:      2,    // Default maximum plan revisions
+		synthesize:      true, // Produce a final answer from the step results
+		maxTokens:       4000, // Default max tokens
+		temperature:     0.2,  // Planning benefits from low temperature
+		plannerResponse: llm.ResponseTypeJSONSchema,
+	}
+}
+
+// Name returns the name of the PlanAgent.
+func (pa *PlanAgent) Name() string {
+	return pa.name
+}
+
+// Type returns the type of the agent.
+func (pa *PlanAgent) Type() AgentType {
+	return pa.agentType
+}
+
+// Tools returns the tools of the executor when it is an agent.
+func (pa *PlanAgent) Tools() []tools.Tool {
+	if executor, ok := pa.executor.(Agent); ok {
+		return executor.Tools()
+	}
+	return nil
+}
+
+// Configure configures the PlanAgent with the provided settings.
+func (pa *PlanAgent) Configure(config map[string]interface{}) error {
+	if model, ok := config["model"].(string); ok {
+		pa.model = model
+	}
+	if prompt, ok := config["planner_prompt"].(string); ok {
+		pa.prompt = prompt
+	}
+	if maxSteps, ok := config["max_steps"].(int); ok {
+		pa.maxSteps = maxSteps
+	}
+	if maxReplans, ok := config["max_replans"].(int); ok {
+		pa.maxReplans = maxReplans
+	}
+	if replan, ok := config["replan_each_step"].(bool); ok {
+		pa.replanEachStep = replan
+	}
+	if maxTokens, ok := config["max_tokens"].(int); ok {
+		pa.maxTokens = maxTokens
+	}
+	if temperature, ok := config["temperature"].(float64); ok {
+		pa.temperature = temperature
+	}
+	return nil
+}
+
+// WithModel sets the model used for planning and synthesis.
+func (pa *PlanAgent) WithModel(model string) *PlanAgent {
+	pa.model = model
+	return pa
+}
+
+// WithPlannerPrompt sets the system prompt used to request plans.
+func (pa *PlanAgent) WithPlannerPrompt(prompt string) *PlanAgent {
+	pa.prompt = prompt
+	return pa
+}
+
+// WithClient sets the LLM client used for planning and synthesis.
+func (pa *PlanAgent) WithClient(client llm.Client) *PlanAgent {
+	pa.client = client
+	return pa
+}
+
+// WithExecutor sets the agent or action that executes each step.
+// The step instructions are passed as the user input.
+func (pa *PlanAgent) WithExecutor(executor workflow.Action) *PlanAgent {
+	pa.executor = executor
+	return pa
+}
+
+// WithMaxSteps sets the maximum number of steps executed in one run. A run that
+// reaches the limit with steps pending fails and reports them as "remaining_steps".
+func (pa *PlanAgent) WithMaxSteps(max int) *PlanAgent {
+	pa.maxSteps = max
+	return pa
+}
+
+// WithMaxReplans sets the maximum number of plan revisions in one run.
+func (pa *PlanAgent) WithMaxReplans(max int) *PlanAgent {
+	pa.maxReplans = max
+	return pa
+}
+
+// WithReplanEachStep asks the planner to revise the remaining steps after every
+// successful step, so that new information can change the plan.
+func (pa *PlanAgent) WithReplanEachStep(enabled bool) *PlanAgent {
+	pa.replanEachStep = enabled
+	return pa
+}
+
+// WithSynthesis controls whether a final answer is generated from the step results.
+// When disabled, the output of the last step is the final answer.
+func (pa *PlanAgent) WithSynthesis(enabled bool) *PlanAgent {
+	pa.synthesize = enabled
+	return pa
+}
+
+// WithJSONObjectPlanning requests plans as plain JSON objects instead of using a
+// strict JSON schema, for providers without structured output support.
+func (pa *PlanAgent) WithJSONObjectPlanning() *PlanAgent {
+	pa.plannerResponse = llm.ResponseTypeJSONObject
+	return pa
+}
+
+// WithMaxTokens sets the maximum number of tokens to generate per LLM call.
+func (pa *PlanAgent) WithMaxTokens(maxTokens int) *PlanAgent {
+	pa.maxTokens = maxTokens
+	return pa
+}
+
+// WithTemperature sets the sampling temperature for planning and synthesis.
+func (pa *PlanAgent) WithTemperature(temperature float64) *PlanAgent {
+	pa.temperature = temperature
+	return pa
+}
+
+// Run plans the user's goal, executes the steps and returns a PlanResult.
+func (pa *PlanAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	startTime := time.Now()
+	logger := wctx.Logger().With("agent", "PlanAgent", "name", pa.name)
+
+	if pa.client == nil {
+		logger.Error("no LLM client configured")
+		return workflow.NewFailedWorkReport(fmt.Errorf("no LLM client configured for agent %s", pa.name))
+	}
+	if pa.executor == nil {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no executor configured for agent %s", pa.name))
+	}
+
+	goal := ""
+	if input, ok := wctx.Get(constants.KeyUserInput); ok {
+		if inputStr, ok := input.(string); ok {
+			goal = inputStr
+		}
+	}
+	if goal == "" {
+		return workflow.NewFailedWorkReport(fmt.Errorf("plan agent %s: no goal provided in user input", pa.name))
+	}
+	// Step executors receive their instructions as user input; restore the goal afterwards
+	defer wctx.Set(constants.KeyUserInput, goal)
+
+	totalTokens := 0
+	steps, tokens, err := pa.requestPlan(wctx, goal, nil, "")
+	totalTokens += tokens
+	if err != nil {
+		logger.Error("planning failed", "error", err)
+		if errors.Is(err, ErrBudgetExceeded) {
+			return budgetFailure(err, pa.name, pa.agentType, totalTokens)
+		}
+		return workflow.NewFailedWorkReport(fmt.Errorf("planning failed: %w", err))
+	}
+
+	result := &PlanResult{Plan: Plan{Goal: goal, Steps: steps}}
+	wctx.Set(constants.KeyPlan, result.Plan)
+	logger.Info("plan created", "steps", len(steps))
+
+	remaining := steps
+	replans := 0
+	for len(remaining) > 0 {
+		if len(result.Steps) >= pa.maxSteps {
+			logger.Warn("reached maximum plan steps", "max_steps", pa.maxSteps, "remaining_steps", len(remaining))
+			report := pa.failed(result, fmt.Errorf("plan stopped after %d steps with %d steps remaining", pa.maxSteps, len(remaining)), totalTokens, startTime)
+			report.SetMetadata("remaining_steps", remaining)
+			return report
+		}
+
+		step := remaining[0]
+		remaining = remaining[1:]
+		stepResult, report := pa.executeStep(wctx, goal, step, result.Steps, len(remaining))
+		totalTokens += reportTokens(report)
+		result.Steps = append(result.Steps, stepResult)
+		wctx.Set(constants.KeyPlanStepResults, result.Steps)
+
+		reason := ""
+		switch {
+		case !stepResult.Completed:
+			logger.Warn("plan step failed", "step", stepResult.Step, "error", stepResult.Error)
+			// An exhausted budget would fail the re-planning as well
+			if err := stepError(report); replans >= pa.maxReplans || errors.Is(err, ErrBudgetExceeded) {
+				return pa.failed(result, fmt.Errorf("plan step %d failed: %w", stepResult.Step, err), totalTokens, startTime)
+			}
+			reason = fmt.Sprintf("Step %d failed: %s", stepResult.Step, stepResult.Error)
+		case pa.replanEachStep && len(remaining) > 0 && replans < pa.maxReplans:
+			reason = fmt.Sprintf("Step %d completed; revise the remaining steps if its result changes what is needed.", stepResult.Step)
+		default:
+			continue
+		}
+
+		revised, tokens, err := pa.requestPlan(wctx, goal, result.Steps, reason)
+		totalTokens += tokens
+		if err != nil {
+			logger.Error("re-planning failed", "error", err)
+			return pa.failed(result, fmt.Errorf("re-planning failed: %w", err), totalTokens, startTime)
+		}
+		replans++
+
+		if !sameSteps(revised, remaining) {
+			revision := PlanRevision{
+				Revision:  len(result.Revisions) + 1,
+				AfterStep: len(result.Steps),
+				Reason:    reason,
+			}
+			for _, s := range revised {
+				revision.Remaining = append(revision.Remaining, s.Description)
+			}
+			result.Revisions = append(result.Revisions, revision)
+			logger.Info("plan revised", "revision", revision.Revision, "remaining_steps", len(revised))
+		}
+		remaining = revised
+		wctx.Set(constants.KeyPlan, Plan{Goal: goal, Steps: append(executedSteps(result.Steps), remaining...)})
+	}
+
+	if pa.synthesize {
+		content, tokens, err := pa.synthesizeAnswer(wctx, goal, result.Steps)
+		totalTokens += tokens
+		if err != nil {
+			logger.Error("synthesis failed", "error", err)
+			return pa.failed(result, fmt.Errorf("synthesis failed: %w", err), totalTokens, startTime)
+		}
+		result.Content = content
+	} else if len(result.Steps) > 0 {
+		result.Content = result.Steps[len(result.Steps)-1].Output
+	}
+
+	report := workflow.NewCompletedWorkReport()
+	report.Data = result
+	pa.addMetadata(&report, result, totalTokens, startTime)
+	logger.Info("plan executed", "steps", len(result.Steps), "revisions", len(result.Revisions), "elapsed", time.Since(startTime))
+
+	return report
+}
+
+// executeStep runs a single step with the executor.
+func (pa *PlanAgent) executeStep(wctx workflow.WorkContext, goal string, step PlanStep, done []StepResult, remaining int) (StepResult, workflow.WorkReport) {
+	stepNumber := len(done) + 1
+	var input strings.Builder
+	fmt.Fprintf(&input, "Overall goal: %s\n\n", goal)
+	if len(done) > 0 {
+		input.WriteString("Results of previous steps:\n")
+		input.WriteString(formatStepResults(done))
+		input.WriteString("\n")
+	}
+	fmt.Fprintf(&input, "Current step (%d of %d): %s\n", stepNumber, stepNumber+remaining, step.Description)
+	input.WriteString("Complete only the current step and report its result.")
+
+	wctx.Set(constants.KeyUserInput, input.String())
+	stepStart := time.Now()
+	report := pa.executor.Run(wctx)
+
+	result := StepResult{
+		Step:        stepNumber,
+		Description: step.Description,
+		Completed:   report.Status != workflow.StatusFailure,
+		Elapsed:     time.Since(stepStart),
+	}
+	if report.Data != nil {
+		result.Output = workflow.ExtractContent(report.Data)
+	}
+	if !result.Completed {
+		var errs []string
+		for _, err := range report.Errors {
+			errs = append(errs, err.Error())
+		}
+		result.Error = strings.Join(errs, "; ")
+		if result.Error == "" {
+			result.Error = "step failed"
+		}
+	}
+	return result, report
+}
+
+// stepError returns the error of a failed step report.
+func stepError(report workflow.WorkReport) error {
+	switch len(report.Errors) {
+	case 0:
+		return errors.New("step failed")
+	case 1:
+		return report.Errors[0]
+	}
+	return errors.Join(report.Errors...)
+}
+
+// requestPlan asks the model for a plan, or for the remaining steps when re-planning.
+func (pa *PlanAgent) requestPlan(wctx workflow.WorkContext, goal string, done []StepResult, reason string) ([]PlanStep, int, error) {
+	var user strings.Builder
+	fmt.Fprintf(&user, "Goal: %s\n", goal)
+	if len(done) > 0 {
+		user.WriteString("\nSteps executed so far:\n")
+		user.WriteString(formatStepResults(done))
+		fmt.Fprintf(&user, "\n%s\nReturn only the steps that remain to be done (an empty list if the goal is achieved).", reason)
+	}
+
+	req := llm.CompletionRequest{
+		Model: pa.model,
+		Messages: []llm.Message{
+			{Role: constants.RoleSystem, Content: pa.prompt},
+			{Role: constants.RoleUser, Content: user.String()},
+		},
+		ResponseType: pa.plannerResponse,
+		MaxTokens:    pa.maxTokens,
+		Temperature:  pa.temperature,
+		Metadata: map[string]interface{}{
+			"agent_name": pa.name,
+			"agent_type": pa.agentType,
+			"phase":      "plan",
+		},
+	}
+	if pa.plannerResponse == llm.ResponseTypeJSONSchema {
+		req.JSONSchema = planSchema
+	}
+
+	budget := runBudgets(wctx, nil)
+	if err := budget.check(pa.name); err != nil {
+		return nil, 0, err
+	}
+	response, err := budget.complete(wctx.Context(), pa.client, pa.name, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var plan struct {
+		Steps []PlanStep `json:"steps"`
+	}
+	if err := decodeJSONObject(response.Content, &plan); err != nil {
+		return nil, response.Usage.TotalTokens, err
+	}
+
+	steps := make([]PlanStep, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		if strings.TrimSpace(step.Description) != "" {
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 && len(done) == 0 {
+		return nil, response.Usage.TotalTokens, fmt.Errorf("planner returned an empty plan")
+	}
+	return steps, response.Usage.TotalTokens, nil
+}
+
+// synthesizeAnswer asks the model for a final answer based on the step results.
+func (pa *PlanAgent) synthesizeAnswer(wctx workflow.WorkContext, goal string, steps []StepResult) (string, int, error) {
+	req := llm.CompletionRequest{
+		Model: pa.model,
+		Messages: []llm.Message{
+			{Role: constants.RoleSystem, Content: "Write the final answer to the user's goal using the results of the executed steps. Do not mention the plan itself."},
+			{Role: constants.RoleUser, Content: fmt.Sprintf("Goal: %s\n\nStep results:\n%s", goal, formatStepResults(steps))},
+		},
+		MaxTokens:   pa.maxTokens,
+		Temperature: pa.temperature,
+		Metadata: map[string]interface{}{
+			"agent_name": pa.name,
+			"agent_type": pa.agentType,
+			"phase":      "synthesis",
+		},
+	}
+
+	budget := runBudgets(wctx, nil)
+	if err := budget.check(pa.name); err != nil {
+		return "", 0, err
+	}
+	response, err := budget.complete(wctx.Context(), pa.client, pa.name, req)
+	if err != nil {
+		return "", 0, err
+	}
+	return response.Content, response.Usage.TotalTokens, nil
+}
+
+// failed returns a failed report carrying the steps executed so far. An
+// exceeded budget is reported like in the other agents.
+func (pa *PlanAgent) failed(result *PlanResult, err error, totalTokens int, startTime time.Time) workflow.WorkReport {
+	report := workflow.NewFailedWorkReport(err)
+	if errors.Is(err, ErrBudgetExceeded) {
+		report = budgetFailure(err, pa.name, pa.agentType, totalTokens)
+	}
+	report.Data = result
+	pa.addMetadata(&report, result, totalTokens, startTime)
+	return report
+}
+
+// addMetadata records the plan execution details in the report.
+func (pa *PlanAgent) addMetadata(report *workflow.WorkReport, result *PlanResult, totalTokens int, startTime time.Time) {
+	report.SetMetadata("agent_name", pa.name)
+	report.SetMetadata("agent_type", pa.agentType)
+	report.SetMetadata("elapsed", time.Since(startTime))
+	report.SetMetadata("total_tokens", totalTokens)
+	report.SetMetadata("plan", result.Plan)
+	report.SetMetadata("plan_steps", result.Steps)
+	report.SetMetadata("plan_revisions", result.Revisions)
+	report.SetMetadata("execution_type", "plan_and_execute")
+}
+
+// formatStepResults renders step outcomes for planner and executor prompts.
+func formatStepResults(steps []StepResult) string {
+	var sb strings.Builder
+	for _, step := range steps {
+		if step.Completed {
+			fmt.Fprintf(&sb, "%d. %s\n   Result: %s\n", step.Step, step.Description, step.Output)
+		} else {
+			fmt.Fprintf(&sb, "%d. %s\n   FAILED: %s\n", step.Step, step.Description, step.Error)
+		}
+	}
+	return sb.String()
+}
+
+// executedSteps returns the plan steps that have already been executed.
+func executedSteps(results []StepResult) []PlanStep {
+	steps := make([]PlanStep, 0, len(results))
+	for _, r := range results {
+		steps = append(steps, PlanStep{Description: r.Description})
+	}
+	return steps
+}
+
+// sameSteps reports whether two step lists have the same descriptions.
+func sameSteps(a, b []PlanStep) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Description != b[i].Description {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestPlanAgent_ExecutesSteps(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"steps":[{"description":"find the order"},{"description":"check shipping"}]}`},
+		{Content: "Your order ships tomorrow."},
+	}}
+
+	var inputs []string
+	executor := workflow.NewActionFunc("executor", func(ctx workflow.WorkContext) workflow.WorkReport {
+		input, _ := ctx.Get(constants.KeyUserInput)
+		inputs = append(inputs, input.(string))
+		report := workflow.NewCompletedWorkReport()
+		report.Data = &llm.CompletionResponse{Content: fmt.Sprintf("result %d", len(inputs))}
+		return report
+	})
+
+	pa := NewPlanAgent("planner").WithModel("test-model").WithClient(client).WithExecutor(executor)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Where is my order?")
+	report := pa.Run(ctx)
+
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	result, ok := report.Data.(*PlanResult)
+	if !ok {
+		t.Fatalf("Expected *PlanResult, got %T", report.Data)
+	}
+	if len(result.Plan.Steps) != 2 || len(result.Steps) != 2 {
+		t.Fatalf("Expected 2 planned and executed steps, got %+v", result)
+	}
+	if result.Content != "Your order ships tomorrow." {
+		t.Errorf("Unexpected final answer: %s", result.Content)
+	}
+	if !strings.Contains(inputs[1], "result 1") || !strings.Contains(inputs[1], "Current step (2 of 2): check shipping") {
+		t.Errorf("Expected second step input to include previous results, got: %s", inputs[1])
+	}
+
+	if stored, ok := ctx.Get(constants.KeyPlanStepResults); !ok || len(stored.([]StepResult)) != 2 {
+		t.Error("Expected step results in the WorkContext")
+	}
+	if input, _ := ctx.Get(constants.KeyUserInput); input != "Where is my order?" {
+		t.Errorf("Expected user input to be restored, got %v", input)
+	}
+	if client.requests[0].JSONSchema == nil {
+		t.Error("Expected planner request to use the plan schema")
+	}
+}
+
+func TestPlanAgent_ReplansOnFailure(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"steps":[{"description":"call broken api"},{"description":"report"}]}`},
+		{Content: "```json\n{\"steps\":[{\"description\":\"use backup api\"},{\"description\":\"report\"}]}\n```"},
+		{Content: "final"},
+	}}
+
+	executor := workflow.NewActionFunc("executor", func(ctx workflow.WorkContext) workflow.WorkReport {
+		input, _ := ctx.Get(constants.KeyUserInput)
+		if strings.Contains(input.(string), "Current step (1 of 2): call broken api") {
+			return workflow.NewFailedWorkReport(fmt.Errorf("api unavailable"))
+		}
+		report := workflow.NewCompletedWorkReport()
+		report.Data = &llm.CompletionResponse{Content: "ok"}
+		return report
+	})
+
+	pa := NewPlanAgent("planner").WithClient(client).WithExecutor(executor)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Fetch data")
+	report := pa.Run(ctx)
+
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	result := report.Data.(*PlanResult)
+	if len(result.Steps) != 3 {
+		t.Fatalf("Expected 3 executed steps, got %d", len(result.Steps))
+	}
+	if result.Steps[0].Completed || result.Steps[0].Error != "api unavailable" {
+		t.Errorf("Expected first step to be recorded as failed, got %+v", result.Steps[0])
+	}
+	if len(result.Revisions) != 1 || result.Revisions[0].Remaining[0] != "use backup api" {
+		t.Errorf("Expected one revision switching to the backup api, got %+v", result.Revisions)
+	}
+}
+
+func TestPlanAgent_FailsAfterMaxReplans(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"steps":[{"description":"always fails"}]}`},
+	}}
+	executor := workflow.NewActionFunc("executor", func(ctx workflow.WorkContext) workflow.WorkReport {
+		return workflow.NewFailedWorkReport(fmt.Errorf("boom"))
+	})
+
+	pa := NewPlanAgent("planner").WithClient(client).WithExecutor(executor).WithMaxReplans(0)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Do it")
+	report := pa.Run(ctx)
+
+	if report.Status != workflow.StatusFailure {
+		t.Errorf("Expected StatusFailure, got %v", report.Status)
+	}
+	if _, ok := report.Data.(*PlanResult); !ok {
+		t.Error("Expected partial PlanResult in failed report")
+	}
+}
+
+func TestPlanAgent_FailsWhenMaxStepsLeavesStepsPending(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"steps":[{"description":"find the order"},{"description":"check shipping"},{"description":"notify the customer"}]}`},
+	}}
+	executor := workflow.NewActionFunc("executor", func(ctx workflow.WorkContext) workflow.WorkReport {
+		report := workflow.NewCompletedWorkReport()
+		report.Data = &llm.CompletionResponse{Content: "done"}
+		return report
+	})
+
+	pa := NewPlanAgent("planner").WithClient(client).WithExecutor(executor).WithMaxSteps(1)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Where is my order?")
+	report := pa.Run(ctx)
+
+	if report.Status != workflow.StatusFailure {
+		t.Fatalf("Expected StatusFailure, got %v", report.Status)
+	}
+	if result, ok := report.Data.(*PlanResult); !ok || len(result.Steps) != 1 {
+		t.Errorf("Expected the executed step in the failed report, got %+v", report.Data)
+	}
+	remaining, _ := report.Metadata["remaining_steps"].([]PlanStep)
+	if len(remaining) != 2 || remaining[0].Description != "check shipping" {
+		t.Errorf("Expected the pending steps in the metadata, got %+v", report.Metadata["remaining_steps"])
+	}
+}
+
+func TestPlanAgent_StepBudgetFailure(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"steps":[{"description":"look it up"}]}`},
+	}}
+	executor := workflow.NewActionFunc("executor", func(ctx workflow.WorkContext) workflow.WorkReport {
+		return workflow.NewFailedWorkReport(&BudgetExceededError{Agent: "executor", Limit: BudgetTokens, Used: 120, Max: 100})
+	})
+
+	pa := NewPlanAgent("planner").WithClient(client).WithExecutor(executor)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Do it")
+	report := pa.Run(ctx)
+
+	if report.Status != workflow.StatusFailure || !errors.Is(report.Errors[0], ErrBudgetExceeded) {
+		t.Fatalf("Expected a budget failure, got %v", report.Errors)
+	}
+	if report.Metadata["budget_exceeded"] != BudgetTokens {
+		t.Errorf("Expected the exceeded limit in the metadata, got %v", report.Metadata["budget_exceeded"])
+	}
+	if len(client.requests) != 1 {
+		t.Errorf("Expected no re-planning after the budget was exhausted, got %d requests", len(client.requests))
+	}
+}
@@ -0,0 +1,25 @@
+package agent
+
+import (
+	"github.com/ratlabs-io/go-agent-kit/pkg/prompt"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// renderPrompt returns the system prompt for a run. A template, when set,
+// is rendered from the inputs and WorkContext values and takes precedence
+// over the static prompt.
+func renderPrompt(wctx workflow.WorkContext, static string, tmpl *prompt.Template, inputs interface{}) (string, error) {
+	if tmpl == nil {
+		return static, nil
+	}
+	return tmpl.RenderContext(wctx, inputs)
+}
+
+// addPromptMetadata records which prompt template produced a report.
+func addPromptMetadata(report *workflow.WorkReport, tmpl *prompt.Template) {
+	if tmpl == nil {
+		return
+	}
+	report.SetMetadata("prompt_name", tmpl.Name())
+	report.SetMetadata("prompt_version", tmpl.Version())
+}
@@ -0,0 +1,374 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// DefaultCriticPrompt is the system prompt of the built-in LLM critic.
+const DefaultCriticPrompt = `You are a strict reviewer. Evaluate the draft against the task.
+Give a score from 0 to 10, set "passed" to true only if the draft fully satisfies the task,
+and give specific, actionable feedback on what to change.
+Respond with JSON only: {"score": 0-10, "passed": true|false, "feedback": "..."}`
+
+// CritiqueSchema is the structured output schema for critiques. Use it with
+// WithJSONSchema when the critic is a ChatAgent.
+var CritiqueSchema = &llm.JSONSchema{
+	Name:        "critique",
+	Description: "Structured feedback on a draft",
+	Strict:      true,
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"score":    map[string]interface{}{"type": "number"},
+			"passed":   map[string]interface{}{"type": "boolean"},
+			"feedback": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"score", "passed", "feedback"},
+		"additionalProperties": false,
+	},
+}
+
+// Critique is the structured feedback on a draft.
+type Critique struct {
+	Score    float64 `json:"score"`
+	Passed   bool    `json:"passed"`
+	Feedback string  `json:"feedback"`
+}
+
+// ScoreFunc evaluates a draft for a task without an LLM critic,
+// e.g. by running tests or checking constraints.
+type ScoreFunc func(ctx context.Context, task, draft string) (Critique, error)
+
+// ReflectionRound records one draft and its critique.
+type ReflectionRound struct {
+	Round    int           `json:"round"` // 1 for the first draft
+	Draft    string        `json:"draft"`
+	Critique Critique      `json:"critique"`
+	Elapsed  time.Duration `json:"elapsed"`
+}
+
+// ReflectionResult is the report data of a ReflectionAgent.
+type ReflectionResult struct {
+	Content string            `json:"content"` // Selected draft, for chaining
+	Score   float64           `json:"score"`   // Score of the selected draft
+	Passed  bool              `json:"passed"`
+	Rounds  []ReflectionRound `json:"rounds"`
+}
+
+// ReflectionAgent runs a generate, critique and revise loop. A generator
+// writes a draft, a critic returns a score and feedback, and the generator
+// revises the draft with that feedback for up to the configured number of
+// revisions. The loop stops as soon as a draft passes.
+type ReflectionAgent struct {
+	name          string
+	agentType     AgentType
+	generator     workflow.Action
+	critic        workflow.Action
+	scorer        ScoreFunc
+	client        llm.Client
+	model         string
+	criticPrompt  string
+	maxRevisions  int
+	passThreshold float64
+	maxTokens     int
+	temperature   float64
+}
+
+// NewReflectionAgent creates a new ReflectionAgent with the given name and generator.
+func NewReflectionAgent(name string, generator workflow.Action) *ReflectionAgent {
+	return &ReflectionAgent{
+		name:         name,
+		agentType:    TypeReflection,
+		generator:    generator,
+		criticPrompt: DefaultCriticPrompt,
+		maxRevisions: 2,    // Default maximum revision rounds
+		maxTokens:    1000, // Default max tokens for the built-in critic
+		temperature:  0.2,  // Critiques benefit from low temperature
+	}
+}
+
+// Name returns the name of the ReflectionAgent.
+func (ra *ReflectionAgent) Name() string {
+	return ra.name
+}
+
+// Type returns the type of the agent.
+func (ra *ReflectionAgent) Type() AgentType {
+	return ra.agentType
+}
+
+// Tools returns the tools of the generator when it is an agent.
+func (ra *ReflectionAgent) Tools() []tools.Tool {
+	if generator, ok := ra.generator.(Agent); ok {
+		return generator.Tools()
+	}
+	return nil
+}
+
+// Configure configures the ReflectionAgent with the provided settings.
+func (ra *ReflectionAgent) Configure(config map[string]interface{}) error {
+	if model, ok := config["model"].(string); ok {
+		ra.model = model
+	}
+	if prompt, ok := config["critic_prompt"].(string); ok {
+		ra.criticPrompt = prompt
+	}
+	if maxRevisions, ok := config["max_revisions"].(int); ok {
+		ra.WithMaxRevisions(maxRevisions)
+	}
+	if threshold, ok := config["pass_threshold"].(float64); ok {
+		ra.passThreshold = threshold
+	}
+	return nil
+}
+
+// WithCritic sets an agent or action that critiques drafts. It receives the
+// task and the draft as user input and must answer with a Critique JSON object.
+func (ra *ReflectionAgent) WithCritic(critic workflow.Action) *ReflectionAgent {
+	ra.critic = critic
+	return ra
+}
+
+// WithScorer sets a function that critiques drafts instead of a critic agent.
+func (ra *ReflectionAgent) WithScorer(scorer ScoreFunc) *ReflectionAgent {
+	ra.scorer = scorer
+	return ra
+}
+
+// WithClient sets the LLM client of the built-in critic, used when neither a
+// critic nor a scorer is configured.
+func (ra *ReflectionAgent) WithClient(client llm.Client) *ReflectionAgent {
+	ra.client = client
+	return ra
+}
+
+// WithModel sets the model of the built-in critic.
+func (ra *ReflectionAgent) WithModel(model string) *ReflectionAgent {
+	ra.model = model
+	return ra
+}
+
+// WithCriticPrompt sets the system prompt of the built-in critic.
+func (ra *ReflectionAgent) WithCriticPrompt(prompt string) *ReflectionAgent {
+	ra.criticPrompt = prompt
+	return ra
+}
+
+// WithMaxRevisions sets the maximum number of revision rounds after the first draft.
+// Negative values are treated as 0, so that at least one draft is written.
+func (ra *ReflectionAgent) WithMaxRevisions(max int) *ReflectionAgent {
+	if max < 0 {
+		max = 0
+	}
+	ra.maxRevisions = max
+	return ra
+}
+
+// WithPassThreshold also treats drafts scoring at least the threshold as passing.
+func (ra *ReflectionAgent) WithPassThreshold(score float64) *ReflectionAgent {
+	ra.passThreshold = score
+	return ra
+}
+
+// Run drafts, critiques and revises until a draft passes or the revision
+// limit is reached, and returns a ReflectionResult.
+func (ra *ReflectionAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	startTime := time.Now()
+	logger := wctx.Logger().With("agent", "ReflectionAgent", "name", ra.name)
+
+	if ra.generator == nil {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no generator configured for agent %s", ra.name))
+	}
+	if ra.critic == nil && ra.scorer == nil && ra.client == nil {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no critic, scorer or LLM client configured for agent %s", ra.name))
+	}
+
+	task := ""
+	if input, ok := wctx.Get(constants.KeyUserInput); ok {
+		if inputStr, ok := input.(string); ok {
+			task = inputStr
+		}
+	}
+	if task == "" {
+		return workflow.NewFailedWorkReport(fmt.Errorf("reflection agent %s: no task provided in user input", ra.name))
+	}
+	// The generator and critic receive their instructions as user input; restore the task afterwards
+	defer wctx.Set(constants.KeyUserInput, task)
+
+	result := &ReflectionResult{}
+	totalTokens := 0
+
+	for round := 1; round <= ra.maxRevisions+1; round++ {
+		roundStart := time.Now()
+
+		wctx.Set(constants.KeyUserInput, ra.generatorInput(task, result.Rounds))
+		report := ra.generator.Run(wctx)
+		totalTokens += reportTokens(report)
+		if report.Status == workflow.StatusFailure {
+			logger.Error("draft generation failed", "round", round, "errors", report.Errors)
+			return ra.failed(result, fmt.Errorf("draft generation failed in round %d: %w", round, firstError(report)), totalTokens, startTime)
+		}
+		draft := workflow.ExtractContent(report.Data)
+
+		critique, tokens, err := ra.critique(wctx, task, draft)
+		totalTokens += tokens
+		if err != nil {
+			logger.Error("critique failed", "round", round, "error", err)
+			result.Rounds = append(result.Rounds, ReflectionRound{Round: round, Draft: draft, Elapsed: time.Since(roundStart)})
+			return ra.failed(result, fmt.Errorf("critique failed in round %d: %w", round, err), totalTokens, startTime)
+		}
+		if ra.passThreshold > 0 && critique.Score >= ra.passThreshold {
+			critique.Passed = true
+		}
+
+		result.Rounds = append(result.Rounds, ReflectionRound{
+			Round:    round,
+			Draft:    draft,
+			Critique: critique,
+			Elapsed:  time.Since(roundStart),
+		})
+		logger.Info("draft critiqued", "round", round, "score", critique.Score, "passed", critique.Passed)
+
+		if critique.Passed {
+			break
+		}
+	}
+
+	best := ra.selectRound(result.Rounds)
+	result.Content = best.Draft
+	result.Score = best.Critique.Score
+	result.Passed = best.Critique.Passed
+
+	report := workflow.NewCompletedWorkReport()
+	report.Data = result
+	ra.addMetadata(&report, result, totalTokens, startTime)
+	logger.Info("reflection completed", "rounds", len(result.Rounds), "passed", result.Passed, "elapsed", time.Since(startTime))
+
+	return report
+}
+
+// generatorInput builds the generator's input, including the previous draft
+// and its critique for revision rounds.
+func (ra *ReflectionAgent) generatorInput(task string, rounds []ReflectionRound) string {
+	if len(rounds) == 0 {
+		return task
+	}
+	last := rounds[len(rounds)-1]
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "Task: %s\n\n", task)
+	fmt.Fprintf(&input, "Your previous draft:\n%s\n\n", last.Draft)
+	fmt.Fprintf(&input, "Reviewer feedback (score %g):\n%s\n\n", last.Critique.Score, last.Critique.Feedback)
+	input.WriteString("Write an improved version of the draft that addresses the feedback. Respond with the revised draft only.")
+	return input.String()
+}
+
+// critique evaluates a draft with the scorer, the critic agent or the built-in LLM critic.
+func (ra *ReflectionAgent) critique(wctx workflow.WorkContext, task, draft string) (Critique, int, error) {
+	if ra.scorer != nil {
+		critique, err := ra.scorer(wctx.Context(), task, draft)
+		return critique, 0, err
+	}
+
+	input := fmt.Sprintf("Task:\n%s\n\nDraft:\n%s", task, draft)
+	var content string
+	var tokens int
+
+	if ra.critic != nil {
+		wctx.Set(constants.KeyUserInput, input)
+		report := ra.critic.Run(wctx)
+		tokens = reportTokens(report)
+		if report.Status == workflow.StatusFailure {
+			return Critique{}, tokens, firstError(report)
+		}
+		content = workflow.ExtractContent(report.Data)
+	} else {
+		budget := runBudgets(wctx, nil)
+		if err := budget.check(ra.name); err != nil {
+			return Critique{}, 0, err
+		}
+		response, err := budget.complete(wctx.Context(), ra.client, ra.name, llm.CompletionRequest{
+			Model: ra.model,
+			Messages: []llm.Message{
+				{Role: constants.RoleSystem, Content: ra.criticPrompt},
+				{Role: constants.RoleUser, Content: input},
+			},
+			JSONSchema:   CritiqueSchema,
+			ResponseType: llm.ResponseTypeJSONSchema,
+			MaxTokens:    ra.maxTokens,
+			Temperature:  ra.temperature,
+			Metadata: map[string]interface{}{
+				"agent_name": ra.name,
+				"agent_type": ra.agentType,
+				"phase":      "critique",
+			},
+		})
+		if err != nil {
+			return Critique{}, 0, err
+		}
+		content = response.Content
+		tokens = response.Usage.TotalTokens
+	}
+
+	var critique Critique
+	if err := decodeJSONObject(content, &critique); err != nil {
+		return Critique{}, tokens, err
+	}
+	return critique, tokens, nil
+}
+
+// selectRound returns the passing round, or the highest scoring one
+// (the latest on ties) when no draft passed.
+func (ra *ReflectionAgent) selectRound(rounds []ReflectionRound) ReflectionRound {
+	best := rounds[len(rounds)-1]
+	if best.Critique.Passed {
+		return best
+	}
+	for _, round := range rounds {
+		if round.Critique.Score > best.Critique.Score {
+			best = round
+		}
+	}
+	return best
+}
+
+// failed returns a failed report carrying the rounds completed so far. An
+// exceeded budget is reported like in the other agents.
+func (ra *ReflectionAgent) failed(result *ReflectionResult, err error, totalTokens int, startTime time.Time) workflow.WorkReport {
+	report := workflow.NewFailedWorkReport(err)
+	if errors.Is(err, ErrBudgetExceeded) {
+		report = budgetFailure(err, ra.name, ra.agentType, totalTokens)
+	}
+	report.Data = result
+	ra.addMetadata(&report, result, totalTokens, startTime)
+	return report
+}
+
+// addMetadata records the reflection details in the report.
+func (ra *ReflectionAgent) addMetadata(report *workflow.WorkReport, result *ReflectionResult, totalTokens int, startTime time.Time) {
+	report.SetMetadata("agent_name", ra.name)
+	report.SetMetadata("agent_type", ra.agentType)
+	report.SetMetadata("elapsed", time.Since(startTime))
+	report.SetMetadata("total_tokens", totalTokens)
+	report.SetMetadata("reflection_rounds", result.Rounds)
+	report.SetMetadata("passed", result.Passed)
+	report.SetMetadata("final_score", result.Score)
+	report.SetMetadata("execution_type", "reflection")
+}
+
+// firstError returns the first error of a failed report.
+func firstError(report workflow.WorkReport) error {
+	if len(report.Errors) > 0 {
+		return report.Errors[0]
+	}
+	return fmt.Errorf("action failed")
+}
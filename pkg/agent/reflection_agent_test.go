@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// draftingAction returns numbered drafts and records the inputs it receives
+func draftingAction(inputs *[]string) workflow.Action {
+	return workflow.NewActionFunc("writer", func(ctx workflow.WorkContext) workflow.WorkReport {
+		input, _ := ctx.Get(constants.KeyUserInput)
+		*inputs = append(*inputs, input.(string))
+		report := workflow.NewCompletedWorkReport()
+		report.Data = &llm.CompletionResponse{Content: fmt.Sprintf("draft %d", len(*inputs))}
+		report.SetMetadata("total_tokens", 10)
+		return report
+	})
+}
+
+func TestReflectionAgent_StopsWhenCriticPasses(t *testing.T) {
+	var inputs []string
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"score": 4, "passed": false, "feedback": "Too vague."}`, Usage: llm.Usage{TotalTokens: 5}},
+		{Content: `{"score": 9, "passed": true, "feedback": "Good."}`, Usage: llm.Usage{TotalTokens: 5}},
+	}}
+
+	ra := NewReflectionAgent("editor", draftingAction(&inputs)).WithClient(client).WithMaxRevisions(3)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Write a tagline")
+	report := ra.Run(ctx)
+
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	result := report.Data.(*ReflectionResult)
+	if len(result.Rounds) != 2 || !result.Passed || result.Content != "draft 2" {
+		t.Errorf("Expected to stop after the passing second draft, got %+v", result)
+	}
+	if !strings.Contains(inputs[1], "draft 1") || !strings.Contains(inputs[1], "Too vague.") {
+		t.Errorf("Expected revision input to include draft and feedback, got %q", inputs[1])
+	}
+	if client.requests[0].JSONSchema != CritiqueSchema {
+		t.Error("Expected built-in critic to request the critique schema")
+	}
+	if report.Metadata["total_tokens"] != 30 {
+		t.Errorf("Expected 30 total tokens, got %v", report.Metadata["total_tokens"])
+	}
+	if input, _ := ctx.Get(constants.KeyUserInput); input != "Write a tagline" {
+		t.Errorf("Expected user input to be restored, got %v", input)
+	}
+}
+
+func TestReflectionAgent_ScorerKeepsBestDraft(t *testing.T) {
+	var inputs []string
+	scores := map[string]float64{"draft 1": 3, "draft 2": 7, "draft 3": 5}
+	scorer := func(ctx context.Context, task, draft string) (Critique, error) {
+		return Critique{Score: scores[draft], Feedback: "more detail"}, nil
+	}
+
+	ra := NewReflectionAgent("editor", draftingAction(&inputs)).WithScorer(scorer).WithMaxRevisions(2)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Write a tagline")
+	report := ra.Run(ctx)
+
+	result := report.Data.(*ReflectionResult)
+	if len(result.Rounds) != 3 {
+		t.Fatalf("Expected 3 rounds, got %d", len(result.Rounds))
+	}
+	if result.Passed || result.Content != "draft 2" || result.Score != 7 {
+		t.Errorf("Expected best non-passing draft to be selected, got %+v", result)
+	}
+
+	// Negative revisions still write one draft
+	inputs = nil
+	ra.WithMaxRevisions(-1)
+	if err := ra.Configure(map[string]interface{}{"max_revisions": -3}); err != nil {
+		t.Fatal(err)
+	}
+	report = ra.Run(ctx)
+	if result := report.Data.(*ReflectionResult); report.Status != workflow.StatusCompleted || len(result.Rounds) != 1 {
+		t.Errorf("Expected a single round, got %v", report.Errors)
+	}
+}
+
+func TestReflectionAgent_CriticAgentAndThreshold(t *testing.T) {
+	var inputs []string
+	critic := NewChatAgent("critic").WithClient(&recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Review: {\"score\": 8, \"passed\": false, \"feedback\": \"Fine.\"}"},
+	}}).WithJSONSchema(CritiqueSchema)
+
+	ra := NewReflectionAgent("editor", draftingAction(&inputs)).WithCritic(critic).WithPassThreshold(8)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Write a tagline")
+	report := ra.Run(ctx)
+
+	result := report.Data.(*ReflectionResult)
+	if len(result.Rounds) != 1 || !result.Passed {
+		t.Errorf("Expected threshold to pass the first draft, got %+v", result)
+	}
+}
@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/retrieval"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// RetrievalResult is the report data of a RetrievalAgent without an inner agent.
+// Content holds the rendered prompt so it can be chained like any agent output.
+type RetrievalResult struct {
+	Content   string               `json:"content"`
+	Chunks    []retrieval.Chunk    `json:"chunks"`
+	Citations []retrieval.Citation `json:"citations"`
+}
+
+// RetrievalAgent grounds an agent in retrieved documents. It retrieves chunks for
+// the user input, renders them into the prompt with a template and runs the
+// wrapped agent with the augmented input. Citations are returned in the report
+// metadata under "citations".
+//
+// Without a wrapped agent, RetrievalAgent acts as a plain retrieval step whose
+// output is the rendered prompt, which makes it usable with SequentialFlow.ThenChain.
+type RetrievalAgent struct {
+	name        string
+	retriever   retrieval.Retriever
+	agent       workflow.Action
+	topK        int
+	minScore    float64
+	template    *template.Template
+	templateErr error
+}
+
+// NewRetrievalAgent creates a new RetrievalAgent with the given name and retriever.
+func NewRetrievalAgent(name string, retriever retrieval.Retriever) *RetrievalAgent {
+	tmpl, err := retrieval.ParseTemplate(retrieval.DefaultPromptTemplate)
+	return &RetrievalAgent{
+		name:        name,
+		retriever:   retriever,
+		topK:        4, // Default number of chunks
+		template:    tmpl,
+		templateErr: err,
+	}
+}
+
+// Name returns the name of the RetrievalAgent.
+func (ra *RetrievalAgent) Name() string {
+	return ra.name
+}
+
+// Type returns the type of the wrapped agent, or TypeChat when no agent is wrapped.
+func (ra *RetrievalAgent) Type() AgentType {
+	if inner, ok := ra.agent.(Agent); ok {
+		return inner.Type()
+	}
+	return TypeChat
+}
+
+// Tools returns the tools of the wrapped agent.
+func (ra *RetrievalAgent) Tools() []tools.Tool {
+	if inner, ok := ra.agent.(Agent); ok {
+		return inner.Tools()
+	}
+	return nil
+}
+
+// Configure configures the RetrievalAgent and passes the configuration on to the wrapped agent.
+func (ra *RetrievalAgent) Configure(config map[string]interface{}) error {
+	if topK, ok := config["top_k"].(int); ok {
+		ra.topK = topK
+	}
+	if minScore, ok := config["min_score"].(float64); ok {
+		ra.minScore = minScore
+	}
+	if tmpl, ok := config["retrieval_template"].(string); ok {
+		ra.WithTemplate(tmpl)
+	}
+	if inner, ok := ra.agent.(Agent); ok {
+		return inner.Configure(config)
+	}
+	return nil
+}
+
+// WithAgent sets the agent (or any action) that answers using the retrieved context.
+func (ra *RetrievalAgent) WithAgent(agent workflow.Action) *RetrievalAgent {
+	ra.agent = agent
+	return ra
+}
+
+// WithTopK sets the maximum number of chunks put into the prompt.
+func (ra *RetrievalAgent) WithTopK(topK int) *RetrievalAgent {
+	ra.topK = topK
+	return ra
+}
+
+// WithMinScore discards chunks that score below the given threshold.
+func (ra *RetrievalAgent) WithMinScore(minScore float64) *RetrievalAgent {
+	ra.minScore = minScore
+	return ra
+}
+
+// WithTemplate sets the Go text/template used to render the augmented input.
+// The template receives a retrieval.PromptData value. Parse errors are reported when the agent runs.
+func (ra *RetrievalAgent) WithTemplate(text string) *RetrievalAgent {
+	ra.template, ra.templateErr = retrieval.ParseTemplate(text)
+	return ra
+}
+
+// Run retrieves chunks for the user input and runs the wrapped agent with the augmented input.
+func (ra *RetrievalAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	startTime := time.Now()
+	logger := wctx.Logger().With("agent", "RetrievalAgent", "name", ra.name)
+
+	if ra.retriever == nil {
+		logger.Error("no retriever configured")
+		return workflow.NewFailedWorkReport(fmt.Errorf("no retriever configured for agent %s", ra.name))
+	}
+	if ra.templateErr != nil {
+		return workflow.NewFailedWorkReport(ra.templateErr)
+	}
+
+	userInput := ""
+	if input, ok := wctx.Get(constants.KeyUserInput); ok {
+		if inputStr, ok := input.(string); ok {
+			userInput = inputStr
+		}
+	}
+	if userInput == "" {
+		return workflow.NewFailedWorkReport(fmt.Errorf("retrieval agent %s: no user input to retrieve for", ra.name))
+	}
+
+	chunks, err := ra.retriever.Retrieve(wctx.Context(), userInput, ra.topK)
+	if err != nil {
+		logger.Error("retrieval failed", "error", err)
+		return workflow.NewFailedWorkReport(fmt.Errorf("retrieval failed: %w", err))
+	}
+	chunks = ra.filterChunks(chunks)
+	citations := retrieval.Citations(chunks)
+
+	augmented, err := retrieval.RenderPrompt(ra.template, userInput, chunks)
+	if err != nil {
+		return workflow.NewFailedWorkReport(err)
+	}
+
+	wctx.Set(constants.KeyRetrievedChunks, chunks)
+	logger.Info("retrieved context", "chunks", len(chunks), "elapsed", time.Since(startTime))
+
+	var report workflow.WorkReport
+	if ra.agent == nil {
+		report = workflow.NewCompletedWorkReport()
+		report.Data = &RetrievalResult{
+			Content:   augmented,
+			Chunks:    chunks,
+			Citations: citations,
+		}
+	} else {
+		// Run the wrapped agent with the augmented input, then restore the original
+		// input so that later actions and message history see what the user wrote
+		wctx.Set(constants.KeyUserInput, augmented)
+		report = ra.agent.Run(wctx)
+		wctx.Set(constants.KeyUserInput, userInput)
+	}
+
+	report.SetMetadata("citations", citations)
+	report.SetMetadata("retrieved_chunks", len(chunks))
+	report.SetMetadata("retrieval_agent", ra.name)
+	report.SetMetadata("retrieval_elapsed", time.Since(startTime))
+
+	return report
+}
+
+// filterChunks applies the score threshold and the top-k limit.
+func (ra *RetrievalAgent) filterChunks(chunks []retrieval.Chunk) []retrieval.Chunk {
+	filtered := make([]retrieval.Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Score < ra.minScore {
+			continue
+		}
+		filtered = append(filtered, chunk)
+		if ra.topK > 0 && len(filtered) >= ra.topK {
+			break
+		}
+	}
+	return filtered
+}
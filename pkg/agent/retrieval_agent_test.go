@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/retrieval"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// recordingLLMClient records requests and returns the queued responses in order
+type recordingLLMClient struct {
+	requests  []llm.CompletionRequest
+	responses []*llm.CompletionResponse
+}
+
+func (r *recordingLLMClient) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	r.requests = append(r.requests, req)
+	if len(r.responses) == 0 {
+		return &llm.CompletionResponse{Content: "done"}, nil
+	}
+	response := r.responses[0]
+	r.responses = r.responses[1:]
+	return response, nil
+}
+
+func (r *recordingLLMClient) Close() error {
+	return nil
+}
+
+func testRetriever() retrieval.Retriever {
+	return retrieval.RetrieverFunc(func(ctx context.Context, query string, limit int) ([]retrieval.Chunk, error) {
+		return []retrieval.Chunk{
+			{ID: "doc-1", Content: "Refunds are processed within 5 days.", Score: 0.9, Metadata: map[string]interface{}{"source": "refunds.md"}},
+			{ID: "doc-2", Content: "Shipping is free over $50.", Score: 0.4, Metadata: map[string]interface{}{"source": "shipping.md"}},
+			{ID: "doc-3", Content: "Unrelated.", Score: 0.1},
+		}, nil
+	})
+}
+
+func TestRetrievalAgent_AugmentsInnerAgent(t *testing.T) {
+	client := &recordingLLMClient{}
+	chat := NewChatAgent("answerer").WithModel("test-model").WithClient(client)
+
+	ra := NewRetrievalAgent("rag", testRetriever()).
+		WithAgent(chat).
+		WithMinScore(0.3)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "How long do refunds take?")
+
+	report := ra.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	if len(client.requests) != 1 {
+		t.Fatalf("Expected 1 LLM request, got %d", len(client.requests))
+	}
+	sent := client.requests[0].Messages[len(client.requests[0].Messages)-1].Content
+	if !strings.Contains(sent, "[1] (refunds.md) Refunds are processed") {
+		t.Errorf("Expected chunk in prompt, got: %s", sent)
+	}
+	if strings.Contains(sent, "Unrelated.") {
+		t.Error("Expected low-scoring chunk to be filtered out")
+	}
+
+	citations, ok := report.Metadata["citations"].([]retrieval.Citation)
+	if !ok || len(citations) != 2 {
+		t.Fatalf("Expected 2 citations in metadata, got %v", report.Metadata["citations"])
+	}
+	if citations[0].Source != "refunds.md" || citations[0].Index != 1 {
+		t.Errorf("Unexpected first citation: %+v", citations[0])
+	}
+
+	if input, _ := ctx.Get(constants.KeyUserInput); input != "How long do refunds take?" {
+		t.Errorf("Expected original user input to be restored, got %v", input)
+	}
+}
+
+func TestRetrievalAgent_StandaloneChains(t *testing.T) {
+	ra := NewRetrievalAgent("rag", testRetriever()).
+		WithTopK(1).
+		WithTemplate("{{range .Chunks}}{{.Content}}{{end}} | {{.Query}}")
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "refunds?")
+
+	flow := workflow.NewSequentialFlow("rag-flow", ra)
+	report := flow.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	output, _ := ctx.Get(constants.KeyPreviousOutput)
+	if output != "Refunds are processed within 5 days. | refunds?" {
+		t.Errorf("Unexpected chained output: %v", output)
+	}
+}
+
+func TestRetrievalAgent_InvalidTemplate(t *testing.T) {
+	ra := NewRetrievalAgent("rag", testRetriever()).WithTemplate("{{.Query")
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "refunds?")
+
+	report := ra.Run(ctx)
+	if report.Status != workflow.StatusFailure {
+		t.Errorf("Expected StatusFailure for invalid template, got %v", report.Status)
+	}
+}
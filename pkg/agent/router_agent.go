@@ -0,0 +1,294 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// NoRoute is the route a model picks when no route fits the request.
+const NoRoute = "none"
+
+// DefaultRouterPrompt is the system prompt of a RouterAgent. The routes are
+// appended to it.
+const DefaultRouterPrompt = `You route user requests to the handler best suited to answer them.
+Pick exactly one handler by name, or "none" if no handler fits.
+Respond with JSON only: {"route": "<handler name>", "confidence": <number from 0 to 1>, "rationale": "<one sentence>"}`
+
+// Route is a named destination of a router.
+type Route struct {
+	Name        string
+	Description string
+	Action      workflow.Action
+}
+
+// RouteDecision is the routing decision recorded in the report of a router.
+type RouteDecision struct {
+	Route      string  `json:"route"`      // Route that ran; empty if none did
+	Picked     string  `json:"picked"`     // Route the model or the similarity search picked
+	Confidence float64 `json:"confidence"` // Confidence or similarity of the pick
+	Rationale  string  `json:"rationale,omitempty"`
+	Fallback   bool    `json:"fallback"` // The default route ran instead of the pick
+}
+
+// RouterAgent asks a model to pick one of several named routes for the user
+// input and runs the action of that route. Picks below the confidence
+// threshold, unknown routes and "none" go to the default route. The report is
+// the report of the routed action, with the decision in its metadata and in
+// the WorkContext under constants.KeyRoute.
+type RouterAgent struct {
+	name          string
+	agentType     AgentType
+	client        llm.Client
+	model         string
+	prompt        string
+	routes        []Route
+	fallback      workflow.Action
+	minConfidence float64
+	maxTokens     int
+	temperature   float64
+}
+
+// NewRouterAgent creates a new RouterAgent that picks routes with the client.
+func NewRouterAgent(name string, client llm.Client) *RouterAgent {
+	return &RouterAgent{
+		name:        name,
+		agentType:   TypeRouter,
+		client:      client,
+		prompt:      DefaultRouterPrompt,
+		maxTokens:   300, // Default max tokens for the decision
+		temperature: 0,   // Routing should be deterministic
+	}
+}
+
+// Name returns the name of the RouterAgent.
+func (ra *RouterAgent) Name() string {
+	return ra.name
+}
+
+// Type returns the type of the agent.
+func (ra *RouterAgent) Type() AgentType {
+	return ra.agentType
+}
+
+// Tools returns the tools of the routes that are agents.
+func (ra *RouterAgent) Tools() []tools.Tool {
+	var all []tools.Tool
+	for _, action := range ra.Children() {
+		if agent, ok := action.(Agent); ok {
+			all = append(all, agent.Tools()...)
+		}
+	}
+	return all
+}
+
+// Children returns the actions of the routes and the default route.
+func (ra *RouterAgent) Children() []workflow.Action {
+	children := make([]workflow.Action, 0, len(ra.routes)+1)
+	for _, route := range ra.routes {
+		children = append(children, route.Action)
+	}
+	if ra.fallback != nil {
+		children = append(children, ra.fallback)
+	}
+	return children
+}
+
+// Configure configures the RouterAgent with the provided settings.
+func (ra *RouterAgent) Configure(config map[string]interface{}) error {
+	if model, ok := config["model"].(string); ok {
+		ra.model = model
+	}
+	if prompt, ok := config["prompt"].(string); ok {
+		ra.prompt = prompt
+	}
+	if confidence, ok := config["min_confidence"].(float64); ok {
+		ra.minConfidence = confidence
+	}
+	return nil
+}
+
+// WithModel sets the model that picks the route.
+func (ra *RouterAgent) WithModel(model string) *RouterAgent {
+	ra.model = model
+	return ra
+}
+
+// WithPrompt sets the system prompt that precedes the list of routes.
+func (ra *RouterAgent) WithPrompt(prompt string) *RouterAgent {
+	ra.prompt = prompt
+	return ra
+}
+
+// WithRoute adds a route. The description tells the model which requests
+// the route handles.
+func (ra *RouterAgent) WithRoute(name, description string, action workflow.Action) *RouterAgent {
+	ra.routes = append(ra.routes, Route{Name: name, Description: description, Action: action})
+	return ra
+}
+
+// WithDefaultRoute sets the action that runs when no route is picked with
+// enough confidence. Without a default route such requests fail.
+func (ra *RouterAgent) WithDefaultRoute(action workflow.Action) *RouterAgent {
+	ra.fallback = action
+	return ra
+}
+
+// WithMinConfidence sets the confidence a pick needs to be followed.
+func (ra *RouterAgent) WithMinConfidence(confidence float64) *RouterAgent {
+	ra.minConfidence = confidence
+	return ra
+}
+
+// Run picks a route for the user input and runs it.
+func (ra *RouterAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	startTime := time.Now()
+	logger := wctx.Logger().With("agent", "RouterAgent", "name", ra.name)
+
+	if ra.client == nil {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no LLM client configured for agent %s", ra.name))
+	}
+	if len(ra.routes) == 0 {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no routes configured for agent %s", ra.name))
+	}
+	input, ok := userInput(wctx)
+	if !ok {
+		return workflow.NewFailedWorkReport(fmt.Errorf("router %s: no user input to route", ra.name))
+	}
+
+	budget := runBudgets(wctx, nil)
+	if err := budget.check(ra.name); err != nil {
+		return budgetFailure(err, ra.name, ra.agentType, 0)
+	}
+	decision, tokens, err := ra.decide(wctx, budget, input)
+	if err != nil {
+		logger.Error("routing failed", "error", err)
+		err = fmt.Errorf("router %s: %w", ra.name, err)
+		if errors.Is(err, ErrBudgetExceeded) {
+			return budgetFailure(err, ra.name, ra.agentType, tokens)
+		}
+		report := workflow.NewFailedWorkReport(err)
+		report.SetMetadata("agent_name", ra.name)
+		report.SetMetadata("agent_type", ra.agentType)
+		report.SetMetadata("total_tokens", tokens)
+		return report
+	}
+
+	action := ra.route(decision)
+	logger.Info("routed", "picked", decision.Picked, "confidence", decision.Confidence, "route", decision.Route)
+	return runRoute(wctx, ra.name, ra.agentType, action, decision, tokens, startTime)
+}
+
+// decide asks the model for a route and applies the confidence threshold.
+func (ra *RouterAgent) decide(wctx workflow.WorkContext, budget budgets, input string) (*RouteDecision, int, error) {
+	names := make([]string, 0, len(ra.routes)+1)
+	var prompt strings.Builder
+	prompt.WriteString(ra.prompt)
+	prompt.WriteString("\n\nHandlers:\n")
+	for _, route := range ra.routes {
+		names = append(names, route.Name)
+		fmt.Fprintf(&prompt, "- %s: %s\n", route.Name, route.Description)
+	}
+	names = append(names, NoRoute)
+
+	response, err := budget.complete(wctx.Context(), ra.client, ra.name, llm.CompletionRequest{
+		Model: ra.model,
+		Messages: []llm.Message{
+			{Role: constants.RoleSystem, Content: strings.TrimSpace(prompt.String())},
+			{Role: constants.RoleUser, Content: input},
+		},
+		JSONSchema:   routeSchema(names),
+		ResponseType: llm.ResponseTypeJSONSchema,
+		MaxTokens:    ra.maxTokens,
+		Temperature:  ra.temperature,
+		Metadata: map[string]interface{}{
+			"agent_name": ra.name,
+			"agent_type": ra.agentType,
+			"phase":      "route",
+		},
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("route selection failed: %w", err)
+	}
+
+	var pick struct {
+		Route      string  `json:"route"`
+		Confidence float64 `json:"confidence"`
+		Rationale  string  `json:"rationale"`
+	}
+	if err := decodeJSONObject(response.Content, &pick); err != nil {
+		return nil, response.Usage.TotalTokens, err
+	}
+	decision := &RouteDecision{
+		Picked:     strings.TrimSpace(pick.Route),
+		Confidence: pick.Confidence,
+		Rationale:  pick.Rationale,
+	}
+	for _, route := range ra.routes {
+		if strings.EqualFold(route.Name, decision.Picked) && decision.Confidence >= ra.minConfidence {
+			decision.Route = route.Name
+		}
+	}
+	return decision, response.Usage.TotalTokens, nil
+}
+
+// route returns the action for a decision and marks fallbacks.
+func (ra *RouterAgent) route(decision *RouteDecision) workflow.Action {
+	for _, route := range ra.routes {
+		if route.Name == decision.Route {
+			return route.Action
+		}
+	}
+	if ra.fallback != nil {
+		decision.Route = ra.fallback.Name()
+		decision.Fallback = true
+	}
+	return ra.fallback
+}
+
+// routeSchema is the structured output schema of a route pick.
+func routeSchema(names []string) *llm.JSONSchema {
+	return &llm.JSONSchema{
+		Name:        "route",
+		Description: "The handler selected for the request",
+		Strict:      true,
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"route":      map[string]interface{}{"type": "string", "enum": names},
+				"confidence": map[string]interface{}{"type": "number"},
+				"rationale":  map[string]interface{}{"type": "string"},
+			},
+			"required":             []string{"route", "confidence", "rationale"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// runRoute runs the routed action and records the decision. Without an
+// action the request fails.
+func runRoute(wctx workflow.WorkContext, name string, agentType AgentType, action workflow.Action, decision *RouteDecision, tokens int, startTime time.Time) workflow.WorkReport {
+	wctx.Set(constants.KeyRoute, decision)
+
+	var report workflow.WorkReport
+	if action == nil {
+		report = workflow.NewFailedWorkReport(fmt.Errorf("router %s: no route for the request (picked %q with confidence %.2f)", name, decision.Picked, decision.Confidence))
+	} else {
+		report = action.Run(wctx)
+		tokens += reportTokens(report)
+	}
+
+	report.SetMetadata("router_name", name)
+	report.SetMetadata("router_type", agentType)
+	report.SetMetadata("route", decision.Route)
+	report.SetMetadata("route_decision", decision)
+	report.SetMetadata("routing_elapsed", time.Since(startTime))
+	report.SetMetadata("total_tokens", tokens)
+	return report
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func routeTo(name string, ran *[]string) workflow.Action {
+	return workflow.NewActionFunc(name, func(wctx workflow.WorkContext) workflow.WorkReport {
+		*ran = append(*ran, name)
+		report := workflow.NewCompletedWorkReport()
+		report.Data = &llm.CompletionResponse{Content: "handled by " + name}
+		return report
+	})
+}
+
+func TestRouterAgent_RoutesByModelPick(t *testing.T) {
+	var ran []string
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"route": "billing", "confidence": 0.92, "rationale": "The user was charged twice."}`, Usage: llm.Usage{TotalTokens: 30}},
+	}}
+	router := NewRouterAgent("support", client).
+		WithRoute("billing", "Charges, refunds and invoices", routeTo("billing", &ran)).
+		WithRoute("shipping", "Delivery status and returns", routeTo("shipping", &ran)).
+		WithMinConfidence(0.6)
+
+	var _ Agent = router
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I was charged twice for my order")
+	report := router.Run(ctx)
+	if report.Status != workflow.StatusCompleted || len(ran) != 1 || ran[0] != "billing" {
+		t.Fatalf("Expected the billing route to run, got %v, %v", ran, report.Errors)
+	}
+
+	decision, _ := report.Metadata["route_decision"].(*RouteDecision)
+	if decision == nil || decision.Route != "billing" || decision.Confidence != 0.92 || decision.Rationale == "" || decision.Fallback {
+		t.Errorf("Unexpected decision %+v", decision)
+	}
+	if stored, _ := ctx.Get(constants.KeyRoute); stored != decision {
+		t.Error("Expected the decision in the context")
+	}
+	if report.Metadata["total_tokens"] != 30 {
+		t.Errorf("Expected the routing tokens, got %v", report.Metadata["total_tokens"])
+	}
+
+	req := client.requests[0]
+	if req.JSONSchema == nil || !strings.Contains(req.Messages[0].Content, "- shipping: Delivery status and returns") {
+		t.Errorf("Expected the routes in a structured request, got %+v", req.Messages[0])
+	}
+	enum := req.JSONSchema.Schema["properties"].(map[string]interface{})["route"].(map[string]interface{})["enum"].([]string)
+	if strings.Join(enum, ",") != "billing,shipping,none" {
+		t.Errorf("Unexpected route enum %v", enum)
+	}
+}
+
+func TestRouterAgent_FallsBackBelowThreshold(t *testing.T) {
+	var ran []string
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: `{"route": "billing", "confidence": 0.4, "rationale": "Unclear."}`},
+		{Content: `{"route": "none", "confidence": 0.9, "rationale": "Small talk."}`},
+	}}
+	router := NewRouterAgent("support", client).
+		WithRoute("billing", "Charges, refunds and invoices", routeTo("billing", &ran)).
+		WithDefaultRoute(routeTo("general", &ran)).
+		WithMinConfidence(0.6)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Hello there")
+	for i := 0; i < 2; i++ {
+		report := router.Run(ctx)
+		decision := report.Metadata["route_decision"].(*RouteDecision)
+		if report.Status != workflow.StatusCompleted || !decision.Fallback || decision.Route != "general" {
+			t.Errorf("Expected the default route, got %+v, %v", decision, report.Errors)
+		}
+	}
+	if len(ran) != 2 || ran[0] != "general" || ran[1] != "general" {
+		t.Errorf("Expected the default route twice, got %v", ran)
+	}
+
+	client.responses = []*llm.CompletionResponse{{Content: `{"route": "none", "confidence": 1, "rationale": ""}`}}
+	router.fallback = nil
+	if report := router.Run(ctx); report.Status != workflow.StatusFailure {
+		t.Error("Expected a failure without a default route")
+	}
+}
+
+// waitingLLMClient blocks every completion until its context is done
+type waitingLLMClient struct{}
+
+func (waitingLLMClient) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (waitingLLMClient) Close() error {
+	return nil
+}
+
+func TestRouterAgent_BudgetExceededWhileRouting(t *testing.T) {
+	var ran []string
+	router := NewRouterAgent("support", waitingLLMClient{}).
+		WithRoute("billing", "Charges, refunds and invoices", routeTo("billing", &ran)).
+		WithDefaultRoute(routeTo("general", &ran))
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I was charged twice for my order")
+	ctx.Set(constants.KeyBudget, NewBudget().WithTimeout(20*time.Millisecond))
+	report := router.Run(ctx)
+	if !errors.Is(firstError(report), ErrBudgetExceeded) || report.Metadata["budget_exceeded"] != BudgetDeadline {
+		t.Fatalf("Expected a budget failure, got %v, %v", report.Errors, report.Metadata)
+	}
+	if len(ran) != 0 {
+		t.Errorf("Expected no route to run, got %v", ran)
+	}
+}
@@ -0,0 +1,382 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// embeddingCacheVersion is the current embedding cache file format version.
+const embeddingCacheVersion = 1
+
+// semanticRoute is a route with its example utterances and their embeddings.
+type semanticRoute struct {
+	Route
+	examples   []string
+	threshold  float64
+	embeddings [][]float64
+}
+
+// SemanticRouter routes the user input to the route with the most similar
+// example utterance, without an LLM call. The examples are embedded once;
+// each request costs a single embedding. A route is taken only when the
+// similarity reaches its threshold; otherwise the next most similar route is
+// considered, and the default route runs when no route qualifies.
+//
+// Besides running as an action, the router provides predicates for the
+// cases of a SwitchFlow.
+type SemanticRouter struct {
+	name      string
+	agentType AgentType
+	embedder  llm.Embedder
+	routes    []*semanticRoute
+	fallback  workflow.Action
+	threshold float64
+	cachePath string
+	cacheKey  string
+
+	mu       sync.Mutex
+	prepared bool           // The route embeddings are computed
+	last     *RouteDecision // Decision for lastText, shared by the predicates
+	lastText string
+}
+
+// NewSemanticRouter creates a new SemanticRouter that embeds with the embedder.
+func NewSemanticRouter(name string, embedder llm.Embedder) *SemanticRouter {
+	return &SemanticRouter{
+		name:      name,
+		agentType: TypeRouter,
+		embedder:  embedder,
+	}
+}
+
+// Name returns the name of the SemanticRouter.
+func (sr *SemanticRouter) Name() string {
+	return sr.name
+}
+
+// Type returns the type of the agent.
+func (sr *SemanticRouter) Type() AgentType {
+	return sr.agentType
+}
+
+// Tools returns the tools of the routes that are agents.
+func (sr *SemanticRouter) Tools() []tools.Tool {
+	var all []tools.Tool
+	for _, action := range sr.Children() {
+		if agent, ok := action.(Agent); ok {
+			all = append(all, agent.Tools()...)
+		}
+	}
+	return all
+}
+
+// Children returns the actions of the routes and the default route.
+func (sr *SemanticRouter) Children() []workflow.Action {
+	children := make([]workflow.Action, 0, len(sr.routes)+1)
+	for _, route := range sr.routes {
+		if route.Action != nil {
+			children = append(children, route.Action)
+		}
+	}
+	if sr.fallback != nil {
+		children = append(children, sr.fallback)
+	}
+	return children
+}
+
+// Configure configures the SemanticRouter with the provided settings.
+func (sr *SemanticRouter) Configure(config map[string]interface{}) error {
+	if threshold, ok := config["threshold"].(float64); ok {
+		sr.threshold = threshold
+	}
+	return nil
+}
+
+// WithRoute adds a route with example utterances of the requests it handles.
+// The action may be nil when the router is only used for its predicates.
+func (sr *SemanticRouter) WithRoute(name string, action workflow.Action, examples ...string) *SemanticRouter {
+	sr.routes = append(sr.routes, &semanticRoute{
+		Route:     Route{Name: name, Action: action},
+		examples:  examples,
+		threshold: -1, // Use the router threshold
+	})
+	sr.prepared, sr.last = false, nil
+	return sr
+}
+
+// WithThreshold sets the similarity a route needs by default. Useful values
+// depend on the embedding model.
+func (sr *SemanticRouter) WithThreshold(threshold float64) *SemanticRouter {
+	sr.threshold, sr.last = threshold, nil
+	return sr
+}
+
+// WithRouteThreshold sets the similarity the named route needs.
+func (sr *SemanticRouter) WithRouteThreshold(name string, threshold float64) *SemanticRouter {
+	for _, route := range sr.routes {
+		if route.Name == name {
+			route.threshold = threshold
+		}
+	}
+	sr.last = nil
+	return sr
+}
+
+// WithDefaultRoute sets the action that runs when no route is similar
+// enough. Without a default route such requests fail.
+func (sr *SemanticRouter) WithDefaultRoute(action workflow.Action) *SemanticRouter {
+	sr.fallback = action
+	return sr
+}
+
+// WithEmbeddingCache caches the embeddings of the examples in a JSON file, so
+// that they are computed only once across restarts. The key identifies the
+// embedding model; a cache written with another key is ignored.
+func (sr *SemanticRouter) WithEmbeddingCache(path, key string) *SemanticRouter {
+	sr.cachePath = path
+	sr.cacheKey = key
+	return sr
+}
+
+// Prepare embeds the examples of all routes. It runs on the first request
+// when not called before.
+func (sr *SemanticRouter) Prepare(ctx context.Context) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.prepare(ctx)
+}
+
+func (sr *SemanticRouter) prepare(ctx context.Context) error {
+	if sr.prepared {
+		return nil
+	}
+	if sr.embedder == nil {
+		return fmt.Errorf("no embedder configured for router %s", sr.name)
+	}
+
+	cache := sr.loadCache()
+	var missing []string
+	for _, route := range sr.routes {
+		for _, example := range route.examples {
+			if _, ok := cache[embeddingKey(example)]; !ok {
+				missing = append(missing, example)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		vectors, err := sr.embedder.Embed(ctx, missing)
+		if err != nil {
+			return fmt.Errorf("failed to embed route examples: %w", err)
+		}
+		if len(vectors) != len(missing) {
+			return fmt.Errorf("embedder returned %d vectors for %d examples", len(vectors), len(missing))
+		}
+		for i, example := range missing {
+			cache[embeddingKey(example)] = vectors[i]
+		}
+		if err := sr.saveCache(cache); err != nil {
+			return err
+		}
+	}
+
+	for _, route := range sr.routes {
+		route.embeddings = make([][]float64, len(route.examples))
+		for i, example := range route.examples {
+			route.embeddings[i] = cache[embeddingKey(example)]
+		}
+	}
+	sr.prepared = true
+	return nil
+}
+
+// Route returns the routing decision for the text.
+func (sr *SemanticRouter) Route(ctx context.Context, text string) (*RouteDecision, error) {
+	sr.mu.Lock()
+	if sr.last != nil && sr.lastText == text {
+		decision := *sr.last
+		sr.mu.Unlock()
+		return &decision, nil
+	}
+	err := sr.prepare(ctx)
+	sr.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := sr.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed input: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 input", len(vectors))
+	}
+
+	type match struct {
+		route   *semanticRoute
+		score   float64
+		example string
+	}
+	var matches []match
+	for _, route := range sr.routes {
+		best := match{route: route, score: -1}
+		for i, embedding := range route.embeddings {
+			if score := llm.CosineSimilarity(vectors[0], embedding); score > best.score {
+				best.score, best.example = score, route.examples[i]
+			}
+		}
+		matches = append(matches, best)
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	decision := &RouteDecision{}
+	if len(matches) > 0 {
+		decision.Picked, decision.Confidence = matches[0].route.Name, matches[0].score
+		decision.Rationale = fmt.Sprintf("nearest example %q", matches[0].example)
+	}
+	for _, m := range matches {
+		threshold := m.route.threshold
+		if threshold < 0 {
+			threshold = sr.threshold
+		}
+		if m.score >= threshold {
+			decision.Route, decision.Picked, decision.Confidence = m.route.Name, m.route.Name, m.score
+			decision.Rationale = fmt.Sprintf("nearest example %q", m.example)
+			break
+		}
+	}
+
+	sr.mu.Lock()
+	sr.last, sr.lastText = decision, text
+	sr.mu.Unlock()
+	copied := *decision
+	return &copied, nil
+}
+
+// Predicate returns a predicate that holds when the user input routes to the
+// named route, for use in the cases of a SwitchFlow. The input is embedded
+// once for all predicates of the router.
+func (sr *SemanticRouter) Predicate(route string) workflow.Predicate {
+	return func(wctx workflow.WorkContext) (bool, error) {
+		input, _ := userInput(wctx)
+		decision, err := sr.Route(wctx.Context(), input)
+		if err != nil {
+			return false, err
+		}
+		return decision.Route == route, nil
+	}
+}
+
+// Run routes the user input and runs the action of the route.
+func (sr *SemanticRouter) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	startTime := time.Now()
+	logger := wctx.Logger().With("agent", "SemanticRouter", "name", sr.name)
+
+	if len(sr.routes) == 0 {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no routes configured for router %s", sr.name))
+	}
+	input, ok := userInput(wctx)
+	if !ok {
+		return workflow.NewFailedWorkReport(fmt.Errorf("router %s: no user input to route", sr.name))
+	}
+
+	decision, err := sr.Route(wctx.Context(), input)
+	if err != nil {
+		logger.Error("routing failed", "error", err)
+		return workflow.NewFailedWorkReport(fmt.Errorf("router %s: %w", sr.name, err))
+	}
+
+	var action workflow.Action
+	for _, route := range sr.routes {
+		if route.Name == decision.Route {
+			action = route.Action
+		}
+	}
+	if action == nil && sr.fallback != nil {
+		action = sr.fallback
+		decision.Route = sr.fallback.Name()
+		decision.Fallback = true
+	}
+	logger.Info("routed", "picked", decision.Picked, "similarity", decision.Confidence, "route", decision.Route)
+	return runRoute(wctx, sr.name, sr.agentType, action, decision, 0, startTime)
+}
+
+// userInput returns the non-empty user input of the WorkContext.
+func userInput(wctx workflow.WorkContext) (string, bool) {
+	value, _ := wctx.Get(constants.KeyUserInput)
+	input, _ := value.(string)
+	return input, strings.TrimSpace(input) != ""
+}
+
+// embeddingCache is the on-disk representation of cached example embeddings.
+type embeddingCache struct {
+	Version    int                  `json:"version"`
+	Key        string               `json:"key"`
+	Embeddings map[string][]float64 `json:"embeddings"`
+}
+
+// embeddingKey identifies the embedding of a text in the cache.
+func embeddingKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCache returns the cached embeddings. A missing, unreadable or foreign
+// cache yields an empty one.
+func (sr *SemanticRouter) loadCache() map[string][]float64 {
+	embeddings := make(map[string][]float64)
+	if sr.cachePath == "" {
+		return embeddings
+	}
+	data, err := os.ReadFile(sr.cachePath)
+	if err != nil {
+		return embeddings
+	}
+	var cache embeddingCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Version != embeddingCacheVersion || cache.Key != sr.cacheKey {
+		return embeddings
+	}
+	for key, vector := range cache.Embeddings {
+		embeddings[key] = vector
+	}
+	return embeddings
+}
+
+// saveCache writes the embeddings to the cache file atomically.
+func (sr *SemanticRouter) saveCache(embeddings map[string][]float64) error {
+	if sr.cachePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(embeddingCache{Version: embeddingCacheVersion, Key: sr.cacheKey, Embeddings: embeddings})
+	if err != nil {
+		return fmt.Errorf("failed to encode embedding cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(sr.cachePath), filepath.Base(sr.cachePath)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create embedding cache: %w", err)
+	}
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), sr.cachePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	return nil
+}
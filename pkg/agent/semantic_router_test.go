@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// wordEmbedder embeds texts as counts of a fixed vocabulary.
+type wordEmbedder struct {
+	mu    sync.Mutex
+	calls [][]string
+}
+
+var embedderVocabulary = []string{"refund", "charged", "invoice", "package", "delivery", "tracking", "hello"}
+
+func (e *wordEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	e.mu.Lock()
+	e.calls = append(e.calls, texts)
+	e.mu.Unlock()
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(embedderVocabulary))
+		for j, word := range embedderVocabulary {
+			vectors[i][j] = float64(strings.Count(strings.ToLower(text), word))
+		}
+	}
+	return vectors, nil
+}
+
+func TestSemanticRouter_RoutesByNearestExample(t *testing.T) {
+	var ran []string
+	embedder := &wordEmbedder{}
+	router := NewSemanticRouter("support", embedder).
+		WithRoute("billing", routeTo("billing", &ran), "I want a refund", "I was charged twice", "Where is my invoice").
+		WithRoute("shipping", routeTo("shipping", &ran), "Where is my package", "Delivery tracking").
+		WithDefaultRoute(routeTo("general", &ran)).
+		WithThreshold(0.5).
+		WithRouteThreshold("shipping", 0.9)
+
+	var _ Agent = router
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I got charged twice, refund please")
+	report := router.Run(ctx)
+	decision := report.Metadata["route_decision"].(*RouteDecision)
+	if report.Status != workflow.StatusCompleted || decision.Route != "billing" || decision.Confidence < 0.5 {
+		t.Fatalf("Expected the billing route, got %+v, %v", decision, report.Errors)
+	}
+
+	// Similar to shipping, but below its stricter threshold
+	ctx.Set(constants.KeyUserInput, "package hello")
+	report = router.Run(ctx)
+	decision = report.Metadata["route_decision"].(*RouteDecision)
+	if decision.Picked != "shipping" || decision.Route != "general" || !decision.Fallback {
+		t.Errorf("Expected the default route, got %+v", decision)
+	}
+	if strings.Join(ran, ",") != "billing,general" {
+		t.Errorf("Unexpected routes %v", ran)
+	}
+	if len(embedder.calls) != 3 || len(embedder.calls[0]) != 5 {
+		t.Errorf("Expected the examples embedded once and one call per request, got %d calls", len(embedder.calls))
+	}
+}
+
+func TestSemanticRouter_SwitchFlowPredicates(t *testing.T) {
+	var ran []string
+	embedder := &wordEmbedder{}
+	router := NewSemanticRouter("support", embedder).
+		WithRoute("billing", nil, "I want a refund").
+		WithRoute("shipping", nil, "Delivery tracking").
+		WithThreshold(0.5)
+
+	flow := workflow.NewSwitchFlowBuilder("dispatch").
+		Case(router.Predicate("billing"), routeTo("billing", &ran)).
+		Case(router.Predicate("shipping"), routeTo("shipping", &ran)).
+		Build()
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "tracking of my delivery")
+	if report := flow.Run(ctx); report.Status != workflow.StatusCompleted || len(ran) != 1 || ran[0] != "shipping" {
+		t.Fatalf("Expected the shipping case, got %v, %v", ran, report.Errors)
+	}
+	if len(embedder.calls) != 2 {
+		t.Errorf("Expected the input embedded once for all predicates, got %d calls", len(embedder.calls))
+	}
+}
+
+func TestSemanticRouter_EmbeddingCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+	newRouter := func(embedder *wordEmbedder, key string) *SemanticRouter {
+		return NewSemanticRouter("support", embedder).
+			WithRoute("billing", nil, "I want a refund", "Where is my invoice").
+			WithEmbeddingCache(path, key)
+	}
+
+	first := &wordEmbedder{}
+	if err := newRouter(first, "model-a").Prepare(context.Background()); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	cached := &wordEmbedder{}
+	if err := newRouter(cached, "model-a").Prepare(context.Background()); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if len(first.calls) != 1 || len(cached.calls) != 0 {
+		t.Errorf("Expected the second router to use the cache, got %d and %d calls", len(first.calls), len(cached.calls))
+	}
+
+	other := &wordEmbedder{}
+	if err := newRouter(other, "model-b").Prepare(context.Background()); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if len(other.calls) != 1 {
+		t.Error("Expected a cache of another model to be ignored")
+	}
+}
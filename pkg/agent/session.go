@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/session"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// openSession loads the session named by constants.KeySessionID and puts its
+// history, state and pending tool calls in the WorkContext. It returns nil
+// when the agent has no store or the run has no session ID.
+func openSession(wctx workflow.WorkContext, store session.Store) (*session.Session, error) {
+	if store == nil {
+		return nil, nil
+	}
+	value, _ := wctx.Get(constants.KeySessionID)
+	id, _ := value.(string)
+	if id == "" {
+		return nil, nil
+	}
+
+	sess, err := session.LoadOrNew(wctx.Context(), store, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+	if len(sess.Messages) > 0 {
+		wctx.Set(constants.KeyMessageHistory, sess.Messages)
+	}
+	wctx.Set(constants.KeySessionState, sess.State)
+	if len(sess.PendingToolCalls) > 0 {
+		wctx.Set(constants.KeyPendingToolCalls, sess.PendingToolCalls)
+	}
+	return sess, nil
+}
+
+// saveSession stores the conversation of a completed run with the state and
+// pending tool calls from the WorkContext. A failed save, such as a conflict
+// with a concurrent run, fails the report but keeps its data.
+func saveSession(wctx workflow.WorkContext, store session.Store, sess *session.Session, report *workflow.WorkReport) {
+	if report.Status != workflow.StatusCompleted {
+		return
+	}
+
+	sess.Messages = sessionHistory(wctx, *report)
+	if state, ok := wctx.Get(constants.KeySessionState); ok {
+		if m, ok := state.(map[string]interface{}); ok {
+			sess.State = m
+		}
+	}
+	if pending, ok := wctx.Get(constants.KeyPendingToolCalls); ok {
+		if calls, ok := pending.([]llm.ToolCall); ok {
+			sess.PendingToolCalls = calls
+		}
+	}
+
+	if err := store.Save(wctx.Context(), sess); err != nil {
+		report.Status = workflow.StatusFailure
+		report.Errors = append(report.Errors, fmt.Errorf("failed to save session %s: %w", sess.ID, err))
+		return
+	}
+	report.SetMetadata("session_id", sess.ID)
+	report.SetMetadata("session_version", sess.Version)
+}
+
+// sessionHistory returns the conversation to store for a run: the transcript
+// of a tool agent, or otherwise the history extended by the input and answer.
+func sessionHistory(wctx workflow.WorkContext, report workflow.WorkReport) []llm.Message {
+	if transcript, ok := TranscriptFromReport(report); ok {
+		return transcript.history()
+	}
+
+	var history []llm.Message
+	if value, ok := wctx.Get(constants.KeyMessageHistory); ok {
+		if messages, ok := value.([]llm.Message); ok {
+			history = append(history, messages...)
+		}
+	}
+	if value, ok := wctx.Get(constants.KeyUserInput); ok {
+		if input, ok := value.(string); ok && input != "" {
+			history = append(history, llm.Message{Role: constants.RoleUser, Content: input})
+		}
+	}
+	var answer string
+	switch data := report.Data.(type) {
+	case *llm.CompletionResponse:
+		if data != nil {
+			answer = data.Content
+		}
+	case *ParsedOutput:
+		answer = data.Content
+	}
+	if answer != "" {
+		history = append(history, llm.Message{Role: constants.RoleAssistant, Content: answer})
+	}
+	return history
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/session"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestChatAgent_SessionResume(t *testing.T) {
+	store := session.NewMemoryStore()
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Hi Ada!"},
+		{Content: "Your name is Ada."},
+	}}
+	ca := NewChatAgent("assistant").
+		WithClient(client).
+		WithPrompt("You are friendly.").
+		WithSessionStore(store)
+
+	// Each request starts from a fresh WorkContext, as in a stateless handler
+	for _, input := range []string{"I am Ada", "What is my name?"} {
+		ctx := workflow.NewWorkContext(context.Background())
+		ctx.Set(constants.KeySessionID, "user-1")
+		ctx.Set(constants.KeyUserInput, input)
+		if report := ca.Run(ctx); report.Status != workflow.StatusCompleted {
+			t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+		}
+	}
+
+	second := client.requests[1].Messages
+	if len(second) != 4 || second[0].Content != "I am Ada" || second[1].Content != "Hi Ada!" {
+		t.Errorf("Expected the second run to resume the conversation, got %+v", second)
+	}
+
+	sess, err := store.Load(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if sess.Version != 2 || len(sess.Messages) != 4 || sess.Messages[3].Content != "Your name is Ada." {
+		t.Errorf("Unexpected stored session: %+v", sess)
+	}
+}
+
+func TestToolAgent_SessionStateAndConflict(t *testing.T) {
+	store := session.NewMemoryStore()
+	concurrent := false
+	tool := &mockTool{name: "remember", execute: func(map[string]interface{}) (interface{}, error) {
+		if concurrent {
+			// Another request saves the same session while this run is in flight
+			other, _ := session.LoadOrNew(context.Background(), store, "user-1")
+			return "ok", store.Save(context.Background(), other)
+		}
+		return "ok", nil
+	}}
+	newAgent := func() *ToolAgent {
+		return NewToolAgent("assistant").
+			WithClient(&recordingLLMClient{responses: []*llm.CompletionResponse{
+				{ToolCalls: []llm.ToolCall{{ID: "1", Name: "remember", Args: map[string]interface{}{}}}},
+				{Content: "Noted."},
+			}}).
+			WithTools(tool).
+			WithSessionStore(store)
+	}
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeySessionID, "user-1")
+	ctx.Set(constants.KeyUserInput, "Remember my order")
+	ctx.Set(constants.KeyPendingToolCalls, []llm.ToolCall{{ID: "9", Name: "refund"}})
+	report := newAgent().Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+	if _, ok := ctx.Get(constants.KeySessionState); !ok {
+		t.Error("Expected the session state in the WorkContext")
+	}
+
+	sess, _ := store.Load(context.Background(), "user-1")
+	if len(sess.Messages) != 4 || sess.Messages[2].Role != constants.RoleTool {
+		t.Errorf("Expected the tool result in the stored history, got %+v", sess.Messages)
+	}
+	if len(sess.PendingToolCalls) != 1 || sess.PendingToolCalls[0].Name != "refund" {
+		t.Errorf("Expected the pending tool calls to be stored, got %+v", sess.PendingToolCalls)
+	}
+
+	concurrent = true
+	ctx = workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeySessionID, "user-1")
+	ctx.Set(constants.KeyUserInput, "And my address")
+	report = newAgent().Run(ctx)
+	if report.Status != workflow.StatusFailure || !errors.Is(firstError(report), session.ErrConflict) {
+		t.Fatalf("Expected a session conflict, got %v: %v", report.Status, report.Errors)
+	}
+	if report.Data == nil {
+		t.Error("Expected the response to be kept on a conflict")
+	}
+}
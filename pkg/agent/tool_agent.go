@@ -3,12 +3,18 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/guardrail"
 	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/memory"
+	"github.com/ratlabs-io/go-agent-kit/pkg/output"
+	"github.com/ratlabs-io/go-agent-kit/pkg/prompt"
+	"github.com/ratlabs-io/go-agent-kit/pkg/session"
 	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
 	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
 )
@@ -24,11 +30,28 @@ type ToolAgent struct {
 	client       llm.Client
 	toolFlow     workflow.Action // Internal workflow for complex tool execution
 	maxToolCalls int             // Maximum number of tool calls per execution
+	toolMode     ToolCallingMode // Native function calling or ReAct text protocol
+	handoffs     []*Handoff      // Agents the conversation may be transferred to
+	maxHandoffs  int             // Maximum nested handoffs per run
 	jsonSchema   *llm.JSONSchema
 	responseType llm.ResponseType
 	maxTokens    int
 	temperature  float64
 	topP         float64
+	memory       memory.Strategy
+	memoryTokens int
+	context      contextFitting
+	guardrails   guardrail.Pipeline
+	template     *prompt.Template
+	inputs       interface{}
+	budget       *Budget
+	history      bool // Write the transcript back to the message history
+	sessions     session.Store
+	output       outputParsing
+	resultLimit  *ResultPolicy            // Handling of oversized tool results
+	toolLimits   map[string]*ResultPolicy // Per-tool handling of oversized results
+	registry     tools.ToolRegistry       // Additional tools, e.g. from MCP servers
+	selector     *ToolSelector            // Selection of the tools offered per turn
 	log          *slog.Logger
 }
 
@@ -38,10 +61,12 @@ func NewToolAgent(name string) *ToolAgent {
 		name:         name,
 		agentType:    TypeTool,
 		tools:        []tools.Tool{},
-		maxToolCalls: 5,    // Default maximum tool calls
-		maxTokens:    4000, // Default max tokens
-		temperature:  0.7,  // Default temperature
-		topP:         0.95, // Default top-p
+		maxToolCalls: 5,                  // Default maximum tool calls
+		toolMode:     ToolCallingNative,  // Default to native function calling
+		maxHandoffs:  defaultMaxHandoffs, // Default maximum nested handoffs
+		maxTokens:    4000,               // Default max tokens
+		temperature:  0.7,                // Default temperature
+		topP:         0.95,               // Default top-p
 		log:          slog.With("agent", "ToolAgent", "name", name),
 	}
 }
@@ -56,9 +81,10 @@ func (ta *ToolAgent) Type() AgentType {
 	return ta.agentType
 }
 
-// Tools returns the list of tools available to this agent.
+// Tools returns the list of tools available to this agent, including the
+// tools of its registry.
 func (ta *ToolAgent) Tools() []tools.Tool {
-	return ta.tools
+	return ta.candidateTools()
 }
 
 // Configure configures the ToolAgent with the provided settings.
@@ -75,6 +101,9 @@ func (ta *ToolAgent) Configure(config map[string]interface{}) error {
 	if responseType, ok := config["response_type"].(string); ok {
 		ta.responseType = llm.ResponseType(responseType)
 	}
+	if mode, ok := config["tool_calling_mode"].(string); ok {
+		ta.toolMode = ToolCallingMode(mode)
+	}
 	if maxTokens, ok := config["max_tokens"].(int); ok {
 		ta.maxTokens = maxTokens
 	}
@@ -84,6 +113,9 @@ func (ta *ToolAgent) Configure(config map[string]interface{}) error {
 	if topP, ok := config["top_p"].(float64); ok {
 		ta.topP = topP
 	}
+	if window, ok := config["context_window"].(int); ok {
+		ta.context.window = window
+	}
 	// Note: LLM client must be provided via WithClient() - no default implementation
 	return nil
 }
@@ -124,6 +156,13 @@ func (ta *ToolAgent) WithMaxToolCalls(max int) *ToolAgent {
 	return ta
 }
 
+// WithToolCallingMode sets how tools are offered to and called by the model.
+// Use ToolCallingReAct for models without native function calling.
+func (ta *ToolAgent) WithToolCallingMode(mode ToolCallingMode) *ToolAgent {
+	ta.toolMode = mode
+	return ta
+}
+
 // WithJSONSchema sets the JSON schema for structured responses.
 func (ta *ToolAgent) WithJSONSchema(schema *llm.JSONSchema) *ToolAgent {
 	ta.jsonSchema = schema
@@ -162,6 +201,133 @@ func (ta *ToolAgent) WithTopP(topP float64) *ToolAgent {
 	return ta
 }
 
+// WithMemory sets the memory strategy used to keep the message history within
+// maxTokens, e.g. a memory.SummarizingMemory that compresses older turns.
+func (ta *ToolAgent) WithMemory(strategy memory.Strategy, maxTokens int) *ToolAgent {
+	ta.memory = strategy
+	ta.memoryTokens = maxTokens
+	return ta
+}
+
+// WithContextWindow sets the context window of the model in tokens. The
+// message history is fitted into the window, after room for the output, the
+// prompt, the user input and the tool definitions. By default the window is
+// looked up with llm.ContextWindow; a negative window disables fitting.
+func (ta *ToolAgent) WithContextWindow(tokens int) *ToolAgent {
+	ta.context.window = tokens
+	return ta
+}
+
+// WithContextStrategy sets how a history that does not fit the context window
+// is reduced, e.g. memory.NewTrimmingMemory().WithMaxTurns(10) or a
+// memory.SummarizingMemory. The turn limit of a TrimmingMemory applies even
+// when the history fits. By default the oldest turns are dropped. Turns are
+// kept or dropped whole, so tool calls stay with their results.
+func (ta *ToolAgent) WithContextStrategy(strategy memory.Strategy) *ToolAgent {
+	ta.context.strategy = strategy
+	return ta
+}
+
+// WithPromptTemplate sets a system prompt template that is rendered on every
+// run. Variables are resolved from the prompt inputs, then from WorkContext
+// values stored under the variable name, then from the template defaults.
+// The template takes precedence over WithPrompt.
+func (ta *ToolAgent) WithPromptTemplate(tmpl *prompt.Template) *ToolAgent {
+	ta.template = tmpl
+	return ta
+}
+
+// WithPromptInputs sets the inputs of the prompt template, as a map or a struct.
+func (ta *ToolAgent) WithPromptInputs(inputs interface{}) *ToolAgent {
+	ta.inputs = inputs
+	return ta
+}
+
+// WithGuardrails adds guardrails that check the user messages, every tool
+// result and the final output of the agent. The system prompt and the
+// assistant and tool messages of the message history are sent unchecked.
+func (ta *ToolAgent) WithGuardrails(guardrails ...guardrail.Guardrail) *ToolAgent {
+	ta.guardrails = append(ta.guardrails, guardrails...)
+	return ta
+}
+
+// WithBudget sets a token, cost and time budget that every LLM call of a run
+// is checked against. A budget stored in the WorkContext under
+// constants.KeyBudget applies as well.
+func (ta *ToolAgent) WithBudget(budget *Budget) *ToolAgent {
+	ta.budget = budget
+	return ta
+}
+
+// WithTranscriptHistory writes the conversation of every completed run,
+// including tool calls and results, back to the message history in the
+// WorkContext so that the next run continues from it.
+func (ta *ToolAgent) WithTranscriptHistory(enabled bool) *ToolAgent {
+	ta.history = enabled
+	return ta
+}
+
+// WithOutputParser parses the final response with the parser. The report
+// data becomes a *ParsedOutput and the parsed value is stored in the
+// WorkContext under constants.KeyParsedOutput. A response that does not parse
+// fails the run unless a format-fix retry succeeds.
+func (ta *ToolAgent) WithOutputParser(parser output.Parser) *ToolAgent {
+	ta.output.parser = parser
+	return ta
+}
+
+// WithParseRetries sets how often the model is asked to fix a response that
+// does not parse. The default is no retries.
+func (ta *ToolAgent) WithParseRetries(retries int) *ToolAgent {
+	ta.output.retries = retries
+	return ta
+}
+
+// WithResultPolicy sets how tool results above the size limit of the policy
+// are handled before they enter the conversation: truncated, summarized or
+// split into pages the model reads with the read_tool_result tool.
+func (ta *ToolAgent) WithResultPolicy(policy *ResultPolicy) *ToolAgent {
+	ta.resultLimit = policy
+	return ta
+}
+
+// WithToolResultPolicy sets the result policy of one tool, overriding the
+// policy of the agent. A nil policy passes the results of the tool unchanged.
+func (ta *ToolAgent) WithToolResultPolicy(toolName string, policy *ResultPolicy) *ToolAgent {
+	if ta.toolLimits == nil {
+		ta.toolLimits = make(map[string]*ResultPolicy)
+	}
+	ta.toolLimits[toolName] = policy
+	return ta
+}
+
+// WithToolRegistry makes the tools of the registry available to the agent in
+// addition to the tools given with WithTools. The registry is read on every
+// run, so tools registered later are picked up.
+func (ta *ToolAgent) WithToolRegistry(registry tools.ToolRegistry) *ToolAgent {
+	ta.registry = registry
+	return ta
+}
+
+// WithToolSelector sets how the tools offered to the model are picked on
+// each turn, e.g. SelectToolsByEmbedding(embedder, 8). Without a selector
+// every tool is sent with every request. Tool calls are not restricted to
+// the offered tools. A selector indexes the tools of one agent and should
+// not be shared.
+func (ta *ToolAgent) WithToolSelector(selector *ToolSelector) *ToolAgent {
+	ta.selector = selector
+	return ta
+}
+
+// WithSessionStore sets the store of the sessions the agent resumes. When
+// the WorkContext holds a session ID under constants.KeySessionID, the
+// session is loaded at the start of the run and saved with the conversation
+// at the end.
+func (ta *ToolAgent) WithSessionStore(store session.Store) *ToolAgent {
+	ta.sessions = store
+	return ta
+}
+
 // Run executes the ToolAgent, potentially using tools and internal workflows.
 func (ta *ToolAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 	startTime := time.Now()
@@ -171,14 +337,46 @@ func (ta *ToolAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
 		return workflow.NewFailedWorkReport(fmt.Errorf("no LLM client configured for agent %s", ta.name))
 	}
 
+	// Resume the session of the conversation, if any
+	sess, err := openSession(wctx, ta.sessions)
+	if err != nil {
+		ta.log.Error("session loading failed", "error", err)
+		return workflow.NewFailedWorkReport(err)
+	}
+
+	report := ta.execute(wctx, startTime)
+	if sess != nil {
+		saveSession(wctx, ta.sessions, sess, &report)
+	}
+	return report
+}
+
+// execute runs the agent in its configured mode.
+func (ta *ToolAgent) execute(wctx workflow.WorkContext, startTime time.Time) workflow.WorkReport {
 	// If we have an internal tool flow, use it for complex execution
 	if ta.toolFlow != nil {
 		ta.log.Info("executing internal tool flow", "flow", ta.toolFlow.Name())
 		return ta.executeWithToolFlow(wctx, startTime)
 	}
 
-	// Otherwise, perform simple tool-calling execution
-	return ta.executeSimpleToolCalling(wctx, startTime)
+	// Pick the tools offered on this turn
+	if err := ta.selectTools(wctx); err != nil {
+		ta.log.Error("tool selection failed", "error", err)
+		return workflow.NewFailedWorkReport(err)
+	}
+
+	// Models without native function calling use the ReAct text protocol
+	var report workflow.WorkReport
+	if ta.toolMode == ToolCallingReAct {
+		report = ta.executeReActToolCalling(wctx, startTime)
+	} else {
+		// Otherwise, perform simple tool-calling execution
+		report = ta.executeSimpleToolCalling(wctx, startTime)
+	}
+	if ta.selector != nil {
+		report.SetMetadata("selected_tools", selections(wctx).get(ta.name))
+	}
+	return report
 }
 
 // executeWithToolFlow runs the internal workflow for complex tool execution.
@@ -187,7 +385,11 @@ func (ta *ToolAgent) executeWithToolFlow(wctx workflow.WorkContext, startTime ti
 	wctx.Set("agent_name", ta.name)
 	wctx.Set("available_tools", ta.tools)
 	wctx.Set("llm_client", ta.client)
-	wctx.Set("prompt", ta.prompt)
+	systemPrompt, err := ta.systemPrompt(wctx)
+	if err != nil {
+		return workflow.NewFailedWorkReport(err)
+	}
+	wctx.Set("prompt", systemPrompt)
 
 	// Execute the internal workflow
 	flowReport := ta.toolFlow.Run(wctx)
@@ -210,77 +412,43 @@ func (ta *ToolAgent) executeWithToolFlow(wctx workflow.WorkContext, startTime ti
 
 // executeSimpleToolCalling performs proper tool calling with conversation loop.
 func (ta *ToolAgent) executeSimpleToolCalling(wctx workflow.WorkContext, startTime time.Time) workflow.WorkReport {
-	// Convert tools to LLM tool definitions
-	var toolDefs []llm.ToolDefinition
-	for _, tool := range ta.tools {
-		toolDefs = append(toolDefs, llm.ToolDefinition{
-			Name:        tool.Name(),
-			Description: tool.Description(),
-			Parameters:  convertSchemaToMap(tool.Parameters()),
-		})
-	}
-
 	// Build initial messages for the conversation
-	var messages []llm.Message
-
-	// Check for runtime message history in context
-	var messageHistory []llm.Message
-	if runtimeHistory, ok := wctx.Get(constants.KeyMessageHistory); ok {
-		if historySlice, ok := runtimeHistory.([]llm.Message); ok {
-			messageHistory = historySlice
-		}
-	}
-
-	// Add message history first
-	if len(messageHistory) > 0 {
-		messages = append(messages, messageHistory...)
-	}
-
-	// Add system prompt if provided (only if not already in history)
-	if ta.prompt != "" {
-		// Check if system prompt already exists in history
-		systemPromptExists := false
-		for _, msg := range messageHistory {
-			if msg.Role == constants.RoleSystem && msg.Content == ta.prompt {
-				systemPromptExists = true
-				break
-			}
-		}
-		if !systemPromptExists {
-			messages = append(messages, llm.Message{
-				Role:    constants.RoleSystem,
-				Content: ta.prompt,
-			})
+	transcript := newTranscript(ta.name, ToolCallingNative, startTime)
+	messages, prompt, err := ta.buildMessages(wctx, transcript)
+	if err != nil {
+		if errors.Is(err, ErrBudgetExceeded) {
+			return budgetFailure(err, ta.name, ta.agentType, 0)
 		}
+		return workflow.NewFailedWorkReport(err)
 	}
 
-	// Check for user input in context
-	userInput := ""
-	if input, ok := wctx.Get(constants.KeyUserInput); ok {
-		if inputStr, ok := input.(string); ok && inputStr != "" {
-			userInput = inputStr
-		}
-	}
-
-	if userInput != "" {
-		messages = append(messages, llm.Message{
-			Role:    constants.RoleUser,
-			Content: userInput,
-		})
-	}
-
-	// If no messages were built, fall back to prompt-only mode
-	var prompt string
-	if len(messages) == 0 && ta.prompt != "" {
-		prompt = ta.prompt
+	// Check the user messages before they reach the provider
+	messages, guarded, err := guardMessages(wctx.Context(), ta.guardrails, ta.name, messages)
+	if err != nil {
+		ta.log.Warn("input blocked by guardrail", "error", err)
+		return guardrailFailure(err, ta.name, ta.agentType)
 	}
 
 	// Start the tool calling loop
 	var finalResponse *llm.CompletionResponse
+	var finalReq llm.CompletionRequest
 	var totalTokens int
 	toolCallCount := 0
+	wrappedUp := false
+	budget := runBudgets(wctx, ta.budget)
 
 	for i := 0; i < ta.maxToolCalls; i++ {
+		if err := budget.check(ta.name); err != nil {
+			ta.log.Warn("budget exceeded", "iteration", i+1, "error", err)
+			report := budgetFailure(err, ta.name, ta.agentType, totalTokens)
+			ta.recordTranscript(wctx, &report, transcript, messages, nil)
+			return report
+		}
+
+		// Convert the offered tools to LLM tool definitions; tools found with
+		// search_tools join on the next iteration
+		toolDefs := ta.toolDefinitions(ta.offeredTools(wctx))
+
 		// Prepare the completion request
 		req := llm.CompletionRequest{
 			Model:        ta.model,
@@ -299,16 +467,45 @@ func (ta *ToolAgent) executeSimpleToolCalling(wctx workflow.WorkContext, startTi
 			},
 		}
 
+		// Close to the budget the model answers once more without tools
+		wrapUp := budget.nearlyExhausted()
+		if wrapUp {
+			req.Tools = nil
+			req.Messages = append(append([]llm.Message{}, messages...), llm.Message{
+				Role:    constants.RoleSystem,
+				Content: wrapUpNote,
+			})
+		}
+
 		// Make LLM completion call
-		response, err := ta.client.Complete(wctx.Context(), req)
+		callStart := time.Now()
+		response, err := budget.complete(wctx.Context(), ta.client, ta.name, req)
 		if err != nil {
 			elapsed := time.Since(startTime)
 			ta.log.Error("LLM completion failed", "iteration", i+1, "elapsed", elapsed, "error", err)
-			return workflow.NewFailedWorkReport(fmt.Errorf("LLM completion failed on iteration %d: %w", i+1, err))
+			report := workflow.NewFailedWorkReport(fmt.Errorf("LLM completion failed on iteration %d: %w", i+1, err))
+			if errors.Is(err, ErrBudgetExceeded) {
+				report = budgetFailure(err, ta.name, ta.agentType, totalTokens)
+			}
+			ta.recordTranscript(wctx, &report, transcript, messages, nil)
+			return report
 		}
 
 		totalTokens += response.Usage.TotalTokens
 		finalResponse = response
+		finalReq = req
+		iteration := transcript.addIteration(i+1, callStart, response.Content, response.Usage)
+
+		if wrapUp {
+			ta.log.Info("tool calling loop wrapped up - budget nearly exhausted", "iterations", i+1, "total_tokens", totalTokens)
+			finalResponse = &llm.CompletionResponse{
+				Content:  response.Content,
+				Usage:    response.Usage,
+				Metadata: response.Metadata,
+			}
+			wrappedUp = true
+			break
+		}
 
 		// If no tool calls, we're done
 		if len(response.ToolCalls) == 0 {
@@ -326,29 +523,39 @@ func (ta *ToolAgent) executeSimpleToolCalling(wctx workflow.WorkContext, startTi
 		for _, toolCall := range response.ToolCalls {
 			toolCallCount++
 
-			result, err := ta.executeTool(wctx.Context(), toolCall)
-			if err != nil {
-				ta.log.Error("tool execution failed", "tool", toolCall.Name, "id", toolCall.ID, "error", err)
-				// Add error message to conversation
+			// A handoff ends this agent's turn and continues in the target
+			if handoff, ok := ta.handoffFor(toolCall); ok {
+				if ta.handoffAllowed(wctx) {
+					iteration.addToolCall(toolCall, toolOutcome{content: fmt.Sprintf("Transferred to %s", handoff.Agent.Name())})
+					return ta.runHandoff(wctx, handoff, toolCall, transcript, messages, totalTokens, startTime)
+				}
+				refusal := fmt.Sprintf("Handoff to %s refused: maximum number of handoffs reached", handoff.Agent.Name())
+				iteration.addToolCall(toolCall, toolOutcome{content: refusal})
 				messages = append(messages, llm.Message{
 					Role:    constants.RoleTool,
-					Content: fmt.Sprintf("Error executing tool %s: %v", toolCall.Name, err),
+					Content: refusal,
 					Name:    toolCall.Name,
 				})
 				continue
 			}
 
-			// Convert tool result to JSON string for the conversation
-			resultJSON := ta.formatToolResult(result)
+			outcome := ta.runToolCall(wctx, toolCall, i+1)
+			iteration.addToolCall(toolCall, outcome)
+			totalTokens += outcome.tokens
+			guarded = append(guarded, outcome.guarded...)
+			if guardrail.IsBlocked(outcome.err) {
+				report := guardrailFailure(outcome.err, ta.name, ta.agentType)
+				report.SetMetadata("total_tokens", totalTokens)
+				ta.recordTranscript(wctx, &report, transcript, messages, nil)
+				return report
+			}
 
 			// Add tool result message to conversation
 			messages = append(messages, llm.Message{
 				Role:    constants.RoleTool,
-				Content: resultJSON,
+				Content: outcome.content,
 				Name:    toolCall.Name,
 			})
-
-			ta.log.Info("tool executed successfully", "tool", toolCall.Name, "id", toolCall.ID, "iteration", i+1)
 		}
 
 		// Clear prompt for subsequent iterations (we have messages now)
@@ -360,9 +567,40 @@ func (ta *ToolAgent) executeSimpleToolCalling(wctx workflow.WorkContext, startTi
 		ta.log.Warn("reached maximum tool calls limit", "max_calls", ta.maxToolCalls, "total_calls", toolCallCount)
 	}
 
+	// Check the output before it is returned
+	finalResponse, outputGuarded, err := guardOutput(wctx.Context(), ta.guardrails, ta.name, finalResponse)
+	if err != nil {
+		ta.log.Warn("output blocked by guardrail", "error", err)
+		report := guardrailFailure(err, ta.name, ta.agentType)
+		report.SetMetadata("total_tokens", totalTokens)
+		ta.recordTranscript(wctx, &report, transcript, messages, nil)
+		return report
+	}
+	guarded = append(guarded, outputGuarded...)
+
+	// Parse the response, asking the model to fix its format if needed
+	var parsed *ParsedOutput
+	if ta.output.parser != nil && finalResponse != nil {
+		var parseGuarded []string
+		parsed, parseGuarded, err = ta.output.parse(wctx, budget, ta.client, ta.guardrails, ta.name, finalReq, finalResponse, nil)
+		totalTokens += parsed.tokens
+		if err != nil {
+			ta.log.Warn("output parsing failed", "retries", parsed.Retries, "error", err)
+			report := parseFailure(err, ta.name, ta.agentType, parsed, totalTokens)
+			ta.recordTranscript(wctx, &report, transcript, messages, nil)
+			return report
+		}
+		finalResponse = parsed.Response
+		guarded = append(guarded, parseGuarded...)
+	}
+
 	// Create final report
 	report := workflow.NewCompletedWorkReport()
 	report.Data = finalResponse
+	if parsed != nil {
+		report.Data = parsed
+		report.SetMetadata("parse_retries", parsed.Retries)
+	}
 
 	elapsed := time.Since(startTime)
 	ta.log.Info("tool calling loop completed", "elapsed", elapsed, "total_tokens", totalTokens, "tool_calls", toolCallCount)
@@ -374,6 +612,13 @@ func (ta *ToolAgent) executeSimpleToolCalling(wctx workflow.WorkContext, startTi
 	report.SetMetadata("total_tokens", totalTokens)
 	report.SetMetadata("tool_calls_count", toolCallCount)
 	report.SetMetadata("execution_type", "tool_calling_loop")
+	if wrappedUp {
+		report.SetMetadata("budget_wrap_up", true)
+	}
+	if len(guarded) > 0 {
+		report.SetMetadata("guardrails_modified", guarded)
+	}
+	ta.recordTranscript(wctx, &report, transcript, messages, finalResponse)
 
 	// Wait for callbacks to complete if WorkContext supports waiting
 	if ctxValue := wctx.Context().Value(constants.KeyWorkContext); ctxValue != nil {
@@ -385,6 +630,119 @@ func (ta *ToolAgent) executeSimpleToolCalling(wctx workflow.WorkContext, startTi
 	return report
 }
 
+// systemPrompt returns the system prompt for a run, rendering the prompt
+// template when one is set.
+func (ta *ToolAgent) systemPrompt(wctx workflow.WorkContext) (string, error) {
+	return renderPrompt(wctx, ta.prompt, ta.template, ta.inputs)
+}
+
+// toolDefinitions converts tools to LLM tool definitions.
+func (ta *ToolAgent) toolDefinitions(offered []tools.Tool) []llm.ToolDefinition {
+	var toolDefs []llm.ToolDefinition
+	for _, tool := range offered {
+		toolDefs = append(toolDefs, llm.ToolDefinition{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  convertSchemaToMap(tool.Parameters()),
+		})
+	}
+	return toolDefs
+}
+
+// buildMessages builds the initial conversation from the message history,
+// the system prompt and the user input. When no messages can be built, the
+// prompt is returned for prompt-only completion instead. The transcript notes
+// which part of the stored history was sent.
+func (ta *ToolAgent) buildMessages(wctx workflow.WorkContext, transcript *Transcript) ([]llm.Message, string, error) {
+	var messages []llm.Message
+
+	// Check for runtime message history in context
+	var messageHistory []llm.Message
+	if runtimeHistory, ok := wctx.Get(constants.KeyMessageHistory); ok {
+		if historySlice, ok := runtimeHistory.([]llm.Message); ok {
+			messageHistory = historySlice
+		}
+	}
+
+	// Keep the history within the memory budget if a strategy is configured
+	messageHistory, err := compactHistory(wctx, runBudgets(wctx, ta.budget), ta.name, ta.memory, ta.memoryTokens, messageHistory)
+	if err != nil {
+		ta.log.Error("memory compaction failed", "error", err)
+		return nil, "", err
+	}
+
+	// Render the system prompt for this run
+	systemPrompt, err := ta.systemPrompt(wctx)
+	if err != nil {
+		ta.log.Error("prompt rendering failed", "error", err)
+		return nil, "", err
+	}
+
+	// Add system prompt if provided (only if not already in history)
+	if systemPrompt != "" {
+		// Check if system prompt already exists in history
+		systemPromptExists := false
+		for _, msg := range messageHistory {
+			if msg.Role == constants.RoleSystem && msg.Content == systemPrompt {
+				systemPromptExists = true
+				break
+			}
+		}
+		if !systemPromptExists {
+			messages = append(messages, llm.Message{
+				Role:    constants.RoleSystem,
+				Content: systemPrompt,
+			})
+		}
+	}
+
+	// Check for user input in context
+	userInput := ""
+	if input, ok := wctx.Get(constants.KeyUserInput); ok {
+		if inputStr, ok := input.(string); ok && inputStr != "" {
+			userInput = inputStr
+		}
+	}
+
+	if userInput != "" {
+		messages = append(messages, llm.Message{
+			Role:    constants.RoleUser,
+			Content: userInput,
+		})
+	}
+
+	// Fit the history into what the context window leaves for it
+	var reserved int
+	if ta.toolMode == ToolCallingReAct {
+		protocol := llm.Message{Role: constants.RoleSystem, Content: ta.reactSystemPrompt(ta.offeredTools(wctx))}
+		reserved = ta.context.reserved(ta.maxTokens, append(messages, protocol), nil, ta.jsonSchema)
+	} else {
+		reserved = ta.context.reserved(ta.maxTokens, messages, ta.toolDefinitions(ta.offeredTools(wctx)), ta.jsonSchema)
+	}
+	messageHistory, err = ta.context.fit(wctx, runBudgets(wctx, ta.budget), ta.name, ta.model, messageHistory, reserved)
+	if err != nil {
+		ta.log.Error("context fitting failed", "error", err)
+		return nil, "", err
+	}
+
+	// Turns left out of the request stay in the stored history
+	if stored, ok := wctx.Get(constants.KeyMessageHistory); ok {
+		transcript.stored, _ = stored.([]llm.Message)
+	}
+	transcript.sent = len(messageHistory)
+
+	// Message history goes first
+	messages = append(append([]llm.Message{}, messageHistory...), messages...)
+
+	// If no messages were built, fall back to prompt-only mode
+	var prompt string
+	if len(messages) == 0 && systemPrompt != "" {
+		prompt = systemPrompt
+	}
+
+	return messages, prompt, nil
+}
+
 // formatToolResult converts a tool execution result to JSON string for LLM conversation.
 func (ta *ToolAgent) formatToolResult(result interface{}) string {
 	// Handle nil results
@@ -406,17 +764,111 @@ func (ta *ToolAgent) formatToolResult(result interface{}) string {
 	return fmt.Sprintf("%v", result)
 }
 
-// executeTool executes a single tool call.
-func (ta *ToolAgent) executeTool(ctx context.Context, toolCall llm.ToolCall) (interface{}, error) {
-	// Find the tool in our registry
-	var targetTool tools.Tool
-	for _, tool := range ta.tools {
-		if tool.Name() == toolCall.Name {
-			targetTool = tool
-			break
+// toolOutcome is the result of a tool call as seen by the model.
+type toolOutcome struct {
+	content string        // Content returned to the model
+	tokens  int           // Tokens used by nested agents
+	guarded []string      // Guardrails that modified the content
+	elapsed time.Duration // Execution time of the tool
+	err     error
+}
+
+// runToolCall executes a tool call, emits the tool events and returns the
+// content to send back to the model. Tool errors are returned to the model as
+// text so that it can recover; the error is kept for bookkeeping. A result
+// blocked by a guardrail is returned as a guardrail.BlockedError.
+func (ta *ToolAgent) runToolCall(wctx workflow.WorkContext, toolCall llm.ToolCall, iteration int) toolOutcome {
+	ta.emitToolEvent(wctx, workflow.EventToolCalled, toolCall, toolCall.Args, 0)
+
+	// Make the WorkContext available to tools that run nested actions
+	ctx := context.WithValue(wctx.Context(), constants.KeyWorkContext, wctx)
+
+	start := time.Now()
+	result, err := ta.executeTool(ctx, toolCall)
+	elapsed := time.Since(start)
+
+	// Tools wrapping agents report their token usage and failures in a
+	// ToolResult; the model only sees its data. Results of other tools are
+	// returned as they are.
+	outcome := toolOutcome{elapsed: elapsed}
+	data := result
+	if _, isAction := ta.findTool(toolCall.Name).(*ActionTool); isAction {
+		if toolResult, ok := result.(*tools.ToolResult); ok {
+			outcome.tokens, _ = toolResult.Metadata["total_tokens"].(int)
+			if err == nil && toolResult.Error != nil {
+				err = toolResult.Error
+			}
+			data = toolResult.Data
+		}
+	}
+
+	if err != nil {
+		ta.log.Error("tool execution failed", "tool", toolCall.Name, "id", toolCall.ID, "iteration", iteration, "error", err)
+		ta.emitToolEvent(wctx, workflow.EventToolFailed, toolCall, err.Error(), elapsed)
+		outcome.content = fmt.Sprintf("Error executing tool %s: %v", toolCall.Name, err)
+		outcome.err = err
+		return outcome
+	}
+
+	ta.log.Info("tool executed successfully", "tool", toolCall.Name, "id", toolCall.ID, "iteration", iteration)
+	ta.emitToolEvent(wctx, workflow.EventToolCompleted, toolCall, result, elapsed)
+
+	// Convert tool result to JSON string for the conversation
+	outcome.content = ta.formatToolResult(data)
+
+	// Check the tool result before it reaches the model
+	if len(ta.guardrails) > 0 {
+		checked, err := ta.guardrails.Run(ctx, guardrail.Check{
+			Stage:    guardrail.StageToolResult,
+			Content:  outcome.content,
+			Agent:    ta.name,
+			ToolName: toolCall.Name,
+		})
+		if err != nil {
+			ta.log.Warn("tool result blocked by guardrail", "tool", toolCall.Name, "error", err)
+			outcome.err = err
+			return outcome
+		}
+		outcome.content = checked.Content
+		outcome.guarded = checked.Modified
+	}
+
+	// Keep oversized results from filling the context window
+	content, tokens := ta.limitResult(wctx, toolCall, outcome.content)
+	outcome.content = content
+	outcome.tokens += tokens
+
+	return outcome
+}
+
+// emitToolEvent emits a tool lifecycle event through the WorkContext.
+func (ta *ToolAgent) emitToolEvent(wctx workflow.WorkContext, eventType workflow.EventType, toolCall llm.ToolCall, payload interface{}, elapsed time.Duration) {
+	wctx.EmitEvent(workflow.Event{
+		Type:      eventType,
+		Source:    ta.name,
+		Timestamp: time.Now(),
+		Payload:   payload,
+		Metadata: map[string]interface{}{
+			"tool_name":    toolCall.Name,
+			"tool_call_id": toolCall.ID,
+			"elapsed":      elapsed,
+		},
+	})
+}
+
+// findTool returns the available tool with the given name, or nil.
+func (ta *ToolAgent) findTool(name string) tools.Tool {
+	for _, tool := range ta.availableTools() {
+		if tool.Name() == name {
+			return tool
 		}
 	}
+	return nil
+}
 
+// executeTool executes a single tool call.
+func (ta *ToolAgent) executeTool(ctx context.Context, toolCall llm.ToolCall) (interface{}, error) {
+	targetTool := ta.findTool(toolCall.Name)
 	if targetTool == nil {
 		return nil, fmt.Errorf("tool %s not found in agent registry", toolCall.Name)
 	}
@@ -434,6 +886,7 @@ func (ta *ToolAgent) addCompletionMetadata(report *workflow.WorkReport, response
 	report.SetMetadata("elapsed", elapsed)
 	report.SetMetadata("token_usage", response.Usage)
 	report.SetMetadata("tool_calls_count", len(response.ToolCalls))
+	addPromptMetadata(report, ta.template)
 	report.SetMetadata("execution_type", "simple_tool_calling")
 
 	// Add agent completion event
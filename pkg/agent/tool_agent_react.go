@@ -0,0 +1,379 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/guardrail"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// ToolCallingMode defines how a ToolAgent offers tools to the model.
+type ToolCallingMode string
+
+const (
+	// ToolCallingNative passes tool definitions through the provider's function calling API.
+	ToolCallingNative ToolCallingMode = "native"
+
+	// ToolCallingReAct describes the tools in the prompt and parses
+	// Thought/Action/Action Input blocks (or a JSON action) from the text output.
+	// Use it with models that have no native function calling.
+	ToolCallingReAct ToolCallingMode = "react"
+)
+
+// reactFinalAction is the action name a model may use in the JSON format to finish.
+const reactFinalAction = "Final Answer"
+
+// reactInstructions explains the ReAct protocol to the model. The tool list is appended.
+const reactInstructions = `You can use tools to answer. To use a tool, respond with exactly:
+
+Thought: <your reasoning about what to do next>
+Action: <tool name>
+Action Input: <JSON object with the tool arguments>
+
+You will then receive the tool result as:
+
+Observation: <tool result>
+
+Repeat as needed. When you can answer, respond with:
+
+Thought: <your reasoning>
+Final Answer: <your answer to the user>
+
+Alternatively you may respond with a single JSON object: {"action": "<tool name or Final Answer>", "action_input": <arguments object or answer>}.
+Never write an Observation yourself.
+
+Available tools:
+`
+
+var (
+	reactFinalRe  = regexp.MustCompile(`(?s)Final Answer\s*:\s*(.*)$`)
+	reactActionRe = regexp.MustCompile(`(?m)^\s*Action\s*:\s*(.+?)\s*$`)
+	reactInputRe  = regexp.MustCompile(`(?s)Action Input\s*:\s*(.*)$`)
+	reactObsRe    = regexp.MustCompile(`(?m)^\s*Observation\s*:`)
+)
+
+// reactStep is a parsed model turn in the ReAct protocol.
+type reactStep struct {
+	final    bool
+	answer   string
+	toolCall llm.ToolCall
+}
+
+// executeReActToolCalling runs the tool loop using the ReAct text protocol.
+// It shares the tool registry, the maxToolCalls limit and the tool events with
+// the native mode.
+func (ta *ToolAgent) executeReActToolCalling(wctx workflow.WorkContext, startTime time.Time) workflow.WorkReport {
+	transcript := newTranscript(ta.name, ToolCallingReAct, startTime)
+	messages, prompt, err := ta.buildMessages(wctx, transcript)
+	if err != nil {
+		if errors.Is(err, ErrBudgetExceeded) {
+			return budgetFailure(err, ta.name, ta.agentType, 0)
+		}
+		return workflow.NewFailedWorkReport(err)
+	}
+
+	// Check the user messages before they reach the provider
+	messages, guarded, err := guardMessages(wctx.Context(), ta.guardrails, ta.name, messages)
+	if err != nil {
+		ta.log.Warn("input blocked by guardrail", "error", err)
+		return guardrailFailure(err, ta.name, ta.agentType)
+	}
+
+	// The tool protocol goes in front of the conversation as a system message
+	messages = append([]llm.Message{{Role: constants.RoleSystem, Content: ta.reactSystemPrompt(ta.offeredTools(wctx))}}, messages...)
+	if prompt != "" {
+		messages = append(messages, llm.Message{Role: constants.RoleUser, Content: prompt})
+	}
+
+	var finalResponse *llm.CompletionResponse
+	var finalReq llm.CompletionRequest
+	var totalTokens int
+	toolCallCount := 0
+	answered := false
+	wrappedUp := false
+	budget := runBudgets(wctx, ta.budget)
+
+	for i := 0; i < ta.maxToolCalls; i++ {
+		if err := budget.check(ta.name); err != nil {
+			ta.log.Warn("budget exceeded", "iteration", i+1, "error", err)
+			report := budgetFailure(err, ta.name, ta.agentType, totalTokens)
+			ta.recordTranscript(wctx, &report, transcript, messages, nil)
+			return report
+		}
+
+		req := llm.CompletionRequest{
+			Model:        ta.model,
+			Messages:     messages,
+			JSONSchema:   ta.jsonSchema,
+			ResponseType: ta.responseType,
+			MaxTokens:    ta.maxTokens,
+			Temperature:  ta.temperature,
+			TopP:         ta.topP,
+			Metadata: map[string]interface{}{
+				"agent_name":        ta.name,
+				"agent_type":        ta.agentType,
+				"loop_iteration":    i + 1,
+				"tool_calling_mode": ToolCallingReAct,
+			},
+		}
+
+		// Close to the budget the model must answer without another action
+		wrapUp := budget.nearlyExhausted()
+		if wrapUp {
+			req.Messages = append(append([]llm.Message{}, messages...), llm.Message{
+				Role:    constants.RoleUser,
+				Content: wrapUpNote + " Reply with \"Final Answer:\" followed by your answer.",
+			})
+		}
+
+		callStart := time.Now()
+		response, err := budget.complete(wctx.Context(), ta.client, ta.name, req)
+		if err != nil {
+			elapsed := time.Since(startTime)
+			ta.log.Error("LLM completion failed", "iteration", i+1, "elapsed", elapsed, "error", err)
+			report := workflow.NewFailedWorkReport(fmt.Errorf("LLM completion failed on iteration %d: %w", i+1, err))
+			if errors.Is(err, ErrBudgetExceeded) {
+				report = budgetFailure(err, ta.name, ta.agentType, totalTokens)
+			}
+			ta.recordTranscript(wctx, &report, transcript, messages, nil)
+			return report
+		}
+
+		totalTokens += response.Usage.TotalTokens
+		finalResponse = response
+		finalReq = req
+
+		// Drop anything the model invented after its action, such as its own observation
+		content := response.Content
+		if loc := reactObsRe.FindStringIndex(content); loc != nil {
+			content = strings.TrimSpace(content[:loc[0]])
+		}
+
+		iteration := transcript.addIteration(i+1, callStart, content, response.Usage)
+		step := parseReActStep(content, i+1)
+		if wrapUp && !step.final {
+			// The model ignored the note; its text is the best answer available
+			step.final, step.answer = true, content
+		}
+		if step.final {
+			wrappedUp = wrapUp
+			finalResponse = &llm.CompletionResponse{
+				Content:  step.answer,
+				Usage:    response.Usage,
+				Metadata: response.Metadata,
+			}
+			answered = true
+			ta.log.Info("tool calling loop completed - final answer given", "iterations", i+1, "total_tokens", totalTokens)
+			break
+		}
+
+		toolCallCount++
+
+		// A handoff ends this agent's turn and continues in the target
+		if handoff, ok := ta.handoffFor(step.toolCall); ok {
+			if ta.handoffAllowed(wctx) {
+				iteration.addToolCall(step.toolCall, toolOutcome{content: fmt.Sprintf("Transferred to %s", handoff.Agent.Name())})
+				return ta.runHandoff(wctx, handoff, step.toolCall, transcript, messages, totalTokens, startTime)
+			}
+			refusal := fmt.Sprintf("Handoff to %s refused: maximum number of handoffs reached", handoff.Agent.Name())
+			iteration.addToolCall(step.toolCall, toolOutcome{content: refusal})
+			messages = append(messages, llm.Message{
+				Role:    constants.RoleAssistant,
+				Content: content,
+			}, llm.Message{
+				Role:    constants.RoleUser,
+				Content: "Observation: " + refusal,
+			})
+			continue
+		}
+
+		messages = append(messages, llm.Message{
+			Role:    constants.RoleAssistant,
+			Content: content,
+		})
+
+		outcome := ta.runToolCall(wctx, step.toolCall, i+1)
+		iteration.addToolCall(step.toolCall, outcome)
+		totalTokens += outcome.tokens
+		guarded = append(guarded, outcome.guarded...)
+		if guardrail.IsBlocked(outcome.err) {
+			report := guardrailFailure(outcome.err, ta.name, ta.agentType)
+			report.SetMetadata("total_tokens", totalTokens)
+			ta.recordTranscript(wctx, &report, transcript, messages, nil)
+			return report
+		}
+		messages = append(messages, llm.Message{
+			Role:    constants.RoleUser,
+			Content: "Observation: " + outcome.content,
+		})
+	}
+
+	if !answered {
+		ta.log.Warn("reached maximum tool calls limit without a final answer", "max_calls", ta.maxToolCalls, "total_calls", toolCallCount)
+	}
+
+	// Check the output before it is returned
+	finalResponse, outputGuarded, err := guardOutput(wctx.Context(), ta.guardrails, ta.name, finalResponse)
+	if err != nil {
+		ta.log.Warn("output blocked by guardrail", "error", err)
+		report := guardrailFailure(err, ta.name, ta.agentType)
+		report.SetMetadata("total_tokens", totalTokens)
+		ta.recordTranscript(wctx, &report, transcript, messages, nil)
+		return report
+	}
+	guarded = append(guarded, outputGuarded...)
+
+	// Parse the response, asking the model to fix its format if needed
+	var parsed *ParsedOutput
+	if ta.output.parser != nil && finalResponse != nil {
+		var parseGuarded []string
+		parsed, parseGuarded, err = ta.output.parse(wctx, budget, ta.client, ta.guardrails, ta.name, finalReq, finalResponse, reactAnswer)
+		totalTokens += parsed.tokens
+		if err != nil {
+			ta.log.Warn("output parsing failed", "retries", parsed.Retries, "error", err)
+			report := parseFailure(err, ta.name, ta.agentType, parsed, totalTokens)
+			ta.recordTranscript(wctx, &report, transcript, messages, nil)
+			return report
+		}
+		finalResponse = parsed.Response
+		guarded = append(guarded, parseGuarded...)
+	}
+
+	report := workflow.NewCompletedWorkReport()
+	report.Data = finalResponse
+	if parsed != nil {
+		report.Data = parsed
+		report.SetMetadata("parse_retries", parsed.Retries)
+	}
+
+	elapsed := time.Since(startTime)
+	ta.log.Info("tool calling loop completed", "elapsed", elapsed, "total_tokens", totalTokens, "tool_calls", toolCallCount)
+
+	ta.addCompletionMetadata(&report, finalResponse, startTime)
+	report.SetMetadata("total_tokens", totalTokens)
+	report.SetMetadata("tool_calls_count", toolCallCount)
+	report.SetMetadata("execution_type", "react_tool_calling")
+	report.SetMetadata("tool_calling_mode", ToolCallingReAct)
+	if wrappedUp {
+		report.SetMetadata("budget_wrap_up", true)
+	}
+	if len(guarded) > 0 {
+		report.SetMetadata("guardrails_modified", guarded)
+	}
+	ta.recordTranscript(wctx, &report, transcript, messages, finalResponse)
+
+	// Wait for callbacks to complete if WorkContext supports waiting
+	if ctxValue := wctx.Context().Value(constants.KeyWorkContext); ctxValue != nil {
+		if workCtx, ok := ctxValue.(workflow.WorkContext); ok {
+			workCtx.Wait()
+		}
+	}
+
+	return report
+}
+
+// reactSystemPrompt describes the protocol and the offered tools.
+func (ta *ToolAgent) reactSystemPrompt(offered []tools.Tool) string {
+	var sb strings.Builder
+	sb.WriteString(reactInstructions)
+	for _, tool := range offered {
+		params, _ := json.Marshal(convertSchemaToMap(tool.Parameters()))
+		fmt.Fprintf(&sb, "- %s: %s\n  Arguments schema: %s\n", tool.Name(), tool.Description(), params)
+	}
+	return sb.String()
+}
+
+// parseReActStep parses a model turn. Text without an action is treated as the final answer.
+func parseReActStep(content string, iteration int) reactStep {
+	// Thought/Action/Action Input format; an action takes precedence over a final answer
+	// the model may have appended prematurely after it
+	if match := reactActionRe.FindStringSubmatchIndex(content); match != nil {
+		name := strings.TrimSpace(content[match[2]:match[3]])
+		if !strings.EqualFold(name, reactFinalAction) {
+			args := map[string]interface{}{}
+			if input := reactInputRe.FindStringSubmatch(content[match[1]:]); input != nil {
+				args = parseReActInput(input[1])
+			}
+			return reactStep{toolCall: reactToolCall(name, args, iteration)}
+		}
+		// "Action: Final Answer" carries the answer as its input
+		if input := reactInputRe.FindStringSubmatch(content[match[1]:]); input != nil {
+			answer := strings.TrimSpace(input[1])
+			if err := json.Unmarshal([]byte(answer), &answer); err != nil {
+				answer = strings.TrimSpace(input[1])
+			}
+			return reactStep{final: true, answer: answer}
+		}
+	}
+
+	if match := reactFinalRe.FindStringSubmatch(content); match != nil {
+		return reactStep{final: true, answer: strings.TrimSpace(match[1])}
+	}
+
+	// JSON action format
+	var action struct {
+		Action      string          `json:"action"`
+		ActionInput json.RawMessage `json:"action_input"`
+	}
+	if err := decodeJSONObject(content, &action); err == nil && action.Action != "" {
+		if strings.EqualFold(action.Action, reactFinalAction) {
+			var answer string
+			if err := json.Unmarshal(action.ActionInput, &answer); err != nil {
+				answer = string(action.ActionInput)
+			}
+			return reactStep{final: true, answer: answer}
+		}
+		return reactStep{toolCall: reactToolCall(action.Action, parseReActInput(string(action.ActionInput)), iteration)}
+	}
+
+	return reactStep{final: true, answer: strings.TrimSpace(content)}
+}
+
+// parseReActInput decodes tool arguments. Non-object input is passed as {"input": ...}.
+func parseReActInput(raw string) map[string]interface{} {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+	raw = strings.TrimSpace(raw)
+
+	args := map[string]interface{}{}
+	if err := decodeJSONObject(raw, &args); err == nil {
+		return args
+	}
+	if raw == "" {
+		return args
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err == nil {
+		return map[string]interface{}{"input": value}
+	}
+	return map[string]interface{}{"input": raw}
+}
+
+// reactToolCall creates a tool call with a synthetic ID for a parsed action.
+func reactToolCall(name string, args map[string]interface{}, iteration int) llm.ToolCall {
+	return llm.ToolCall{
+		ID:   fmt.Sprintf("react_%d", iteration),
+		Name: strings.Trim(name, "`\"' "),
+		Args: args,
+	}
+}
+
+// reactAnswer returns the answer of a response that may follow the ReAct
+// protocol, such as a format fix after the final answer.
+func reactAnswer(content string) string {
+	if match := reactFinalRe.FindStringSubmatch(content); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// mockTool is a tool backed by a function for agent tests
+type mockTool struct {
+	name    string
+	execute func(params map[string]interface{}) (interface{}, error)
+	calls   []map[string]interface{}
+}
+
+func (m *mockTool) Name() string        { return m.name }
+func (m *mockTool) Description() string { return "Mock tool " + m.name }
+
+func (m *mockTool) Parameters() tools.Schema {
+	return tools.Schema{
+		Type:       "object",
+		Properties: map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+	}
+}
+
+func (m *mockTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	m.calls = append(m.calls, params)
+	return m.execute(params)
+}
+
+func weatherTool() *mockTool {
+	return &mockTool{name: "get_weather", execute: func(params map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{"city": params["city"], "forecast": "sunny"}, nil
+	}}
+}
+
+func TestToolAgent_ReActMode(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Thought: I need the weather.\nAction: get_weather\nAction Input: {\"city\": \"Paris\"}\nObservation: rainy (made up)"},
+		{Content: "Thought: I know the answer.\nFinal Answer: It is sunny in Paris."},
+	}}
+	tool := weatherTool()
+
+	ta := NewToolAgent("react").
+		WithModel("local-model").
+		WithClient(client).
+		WithTools(tool).
+		WithToolCallingMode(ToolCallingReAct)
+
+	var mu sync.Mutex
+	var events []workflow.EventType
+	callbacks := workflow.NewCallbackRegistry()
+	callbacks.Add(func(ctx context.Context, event workflow.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event.Type)
+	})
+
+	ctx := workflow.NewWorkContextWithCallbacks(context.Background(), callbacks)
+	ctx.Set(constants.KeyUserInput, "What's the weather in Paris?")
+	report := ta.Run(ctx)
+	ctx.Wait()
+
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	response, ok := report.Data.(*llm.CompletionResponse)
+	if !ok || response.Content != "It is sunny in Paris." {
+		t.Errorf("Expected final answer content, got %+v", report.Data)
+	}
+	if len(tool.calls) != 1 || tool.calls[0]["city"] != "Paris" {
+		t.Errorf("Expected one tool call for Paris, got %v", tool.calls)
+	}
+
+	first := client.requests[0]
+	if len(first.Tools) != 0 {
+		t.Error("ReAct requests should not use native tool definitions")
+	}
+	if first.Messages[0].Role != constants.RoleSystem || !strings.Contains(first.Messages[0].Content, "get_weather") {
+		t.Errorf("Expected tool descriptions in the system prompt, got %+v", first.Messages[0])
+	}
+
+	second := client.requests[1].Messages
+	assistant := second[len(second)-2]
+	observation := second[len(second)-1]
+	if strings.Contains(assistant.Content, "rainy") {
+		t.Error("Hallucinated observation should be removed from the assistant turn")
+	}
+	if !strings.HasPrefix(observation.Content, "Observation: ") || !strings.Contains(observation.Content, "sunny") {
+		t.Errorf("Expected tool observation, got %q", observation.Content)
+	}
+
+	if report.Metadata["execution_type"] != "react_tool_calling" || report.Metadata["tool_calls_count"] != 1 {
+		t.Errorf("Unexpected metadata: %v", report.Metadata)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Errorf("Expected tool called and completed events, got %v", events)
+	}
+}
+
+func TestToolAgent_ReActJSONAction(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "```json\n{\"action\": \"get_weather\", \"action_input\": {\"city\": \"Oslo\"}}\n```"},
+		{Content: `{"action": "Final Answer", "action_input": "Sunny in Oslo."}`},
+	}}
+	tool := weatherTool()
+
+	ta := NewToolAgent("react").WithClient(client).WithTools(tool)
+	if err := ta.Configure(map[string]interface{}{"tool_calling_mode": "react"}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Weather in Oslo?")
+	report := ta.Run(ctx)
+
+	if len(tool.calls) != 1 || tool.calls[0]["city"] != "Oslo" {
+		t.Errorf("Expected one tool call for Oslo, got %v", tool.calls)
+	}
+	if response := report.Data.(*llm.CompletionResponse); response.Content != "Sunny in Oslo." {
+		t.Errorf("Unexpected final answer: %q", response.Content)
+	}
+}
+
+func TestToolAgent_KeepsToolResultOfOrdinaryTools(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "get_weather", Args: map[string]interface{}{"city": "Oslo"}}}},
+		{Content: "The weather service is down."},
+	}}
+	tool := &mockTool{name: "get_weather", execute: func(params map[string]interface{}) (interface{}, error) {
+		return &tools.ToolResult{
+			Error:    errors.New("service unavailable"),
+			Metadata: map[string]interface{}{"status": 503},
+		}, nil
+	}}
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Weather in Oslo?")
+	report := NewToolAgent("weather").WithClient(client).WithTools(tool).Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the run to complete, got %v", report.Errors)
+	}
+
+	// Only ActionTool results are unwrapped to their data
+	transcript, _ := TranscriptFromReport(report)
+	result := transcript.Iterations[0].ToolCalls[0].Result
+	if !strings.Contains(result, "service unavailable") || !strings.Contains(result, "503") {
+		t.Errorf("Expected the error and metadata of the tool result, got %q", result)
+	}
+}
+
+func TestParseReActStep(t *testing.T) {
+	tests := []struct {
+		content string
+		final   bool
+		answer  string
+		tool    string
+		args    map[string]interface{}
+	}{
+		{content: "Just a plain reply.", final: true, answer: "Just a plain reply."},
+		{content: "Thought: done\nFinal Answer: 42", final: true, answer: "42"},
+		{content: "Thought: done\nAction: Final Answer\nAction Input: 42", final: true, answer: "42"},
+		{content: "Thought: done\nAction: Final Answer\nAction Input: \"It is sunny.\"", final: true, answer: "It is sunny."},
+		{content: "Action: search\nAction Input: golang", tool: "search", args: map[string]interface{}{"input": "golang"}},
+		{content: "Action: `lookup`\nAction Input: {\"id\": 7}", tool: "lookup", args: map[string]interface{}{"id": float64(7)}},
+	}
+
+	for _, tt := range tests {
+		step := parseReActStep(tt.content, 1)
+		if step.final != tt.final {
+			t.Errorf("%q: expected final=%v, got %v", tt.content, tt.final, step.final)
+			continue
+		}
+		if tt.final && step.answer != tt.answer {
+			t.Errorf("%q: expected answer %q, got %q", tt.content, tt.answer, step.answer)
+		}
+		if !tt.final {
+			if step.toolCall.Name != tt.tool {
+				t.Errorf("%q: expected tool %q, got %q", tt.content, tt.tool, step.toolCall.Name)
+			}
+			for k, v := range tt.args {
+				if step.toolCall.Args[k] != v {
+					t.Errorf("%q: expected arg %s=%v, got %v", tt.content, k, v, step.toolCall.Args[k])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// ResultHandling selects what happens to a tool result that exceeds the size
+// limit of its policy.
+type ResultHandling string
+
+const (
+	// ResultTruncate cuts the result and appends a truncation marker.
+	ResultTruncate ResultHandling = "truncate"
+
+	// ResultSummarize replaces the result with a summary written by a model.
+	ResultSummarize ResultHandling = "summarize"
+
+	// ResultPage stores the result and gives the model its first page, a
+	// handle and the read_tool_result tool to read further pages.
+	ResultPage ResultHandling = "page"
+)
+
+// ReadResultToolName is the name of the built-in tool that pages through
+// stored tool results.
+const ReadResultToolName = "read_tool_result"
+
+// charsPerToken converts token limits to characters, matching the
+// approximate tokenizer.
+const charsPerToken = 4
+
+// summarizeResultPrompt instructs the model that summarizes large results.
+const summarizeResultPrompt = `You condense the result of the tool %q for an assistant that called it with the arguments %s.
+Keep every fact, number, name and identifier the assistant may need; drop boilerplate and repetition.
+Respond with the condensed result only, in at most %d tokens.`
+
+// ResultPolicy limits the size of tool results before they enter the
+// conversation.
+type ResultPolicy struct {
+	Handling   ResultHandling
+	MaxTokens  int        // Results above this size are handled
+	Client     llm.Client // Client that writes summaries
+	Model      string     // Model that writes summaries
+	PageTokens int        // Size of a page; defaults to MaxTokens
+}
+
+// TruncateResults creates a policy that truncates results above maxTokens.
+func TruncateResults(maxTokens int) *ResultPolicy {
+	return &ResultPolicy{Handling: ResultTruncate, MaxTokens: maxTokens}
+}
+
+// SummarizeResults creates a policy that summarizes results above maxTokens
+// with the given client and model, typically a small and cheap one. When the
+// summary fails the result is truncated instead.
+func SummarizeResults(client llm.Client, model string, maxTokens int) *ResultPolicy {
+	return &ResultPolicy{Handling: ResultSummarize, MaxTokens: maxTokens, Client: client, Model: model}
+}
+
+// PageResults creates a policy that stores results above maxTokens and lets
+// the model read them page by page.
+func PageResults(maxTokens int) *ResultPolicy {
+	return &ResultPolicy{Handling: ResultPage, MaxTokens: maxTokens}
+}
+
+// WithPageTokens sets the size of a page.
+func (p *ResultPolicy) WithPageTokens(tokens int) *ResultPolicy {
+	p.PageTokens = tokens
+	return p
+}
+
+// exceeds reports whether the content is larger than the policy allows.
+func (p *ResultPolicy) exceeds(content string) bool {
+	return p != nil && p.MaxTokens > 0 && llm.NewApproxTokenizer().CountTokens(content) > p.MaxTokens
+}
+
+// resultPolicy returns the policy for the results of a tool.
+func (ta *ToolAgent) resultPolicy(toolName string) *ResultPolicy {
+	if toolName == ReadResultToolName {
+		return nil // Pages already fit
+	}
+	if policy, ok := ta.toolLimits[toolName]; ok {
+		return policy
+	}
+	return ta.resultLimit
+}
+
+// pagesResults reports whether any policy of the agent pages results.
+func (ta *ToolAgent) pagesResults() bool {
+	if ta.resultLimit != nil && ta.resultLimit.Handling == ResultPage {
+		return true
+	}
+	for _, policy := range ta.toolLimits {
+		if policy != nil && policy.Handling == ResultPage {
+			return true
+		}
+	}
+	return false
+}
+
+// limitResult applies the result policy of the tool to an oversized result.
+// It returns the content for the model and the tokens used to summarize it.
+func (ta *ToolAgent) limitResult(wctx workflow.WorkContext, toolCall llm.ToolCall, content string) (string, int) {
+	policy := ta.resultPolicy(toolCall.Name)
+	if !policy.exceeds(content) {
+		return content, 0
+	}
+	ta.log.Info("tool result exceeds limit", "tool", toolCall.Name, "handling", policy.Handling, "chars", len(content))
+
+	switch policy.Handling {
+	case ResultSummarize:
+		summary, tokens, err := ta.summarizeResult(wctx, policy, toolCall, content)
+		if err != nil {
+			ta.log.Warn("tool result summary failed, truncating", "tool", toolCall.Name, "error", err)
+			return truncateResult(content, policy.MaxTokens), tokens
+		}
+		return summary, tokens
+	case ResultPage:
+		return pageResult(wctx, policy, content), 0
+	default:
+		return truncateResult(content, policy.MaxTokens), 0
+	}
+}
+
+// truncateResult cuts the content to maxTokens and marks the cut.
+func truncateResult(content string, maxTokens int) string {
+	runes := []rune(content)
+	limit := maxTokens * charsPerToken
+	if len(runes) <= limit {
+		return content
+	}
+	return fmt.Sprintf("%s\n[... truncated %d of %d characters ...]", string(runes[:limit]), len(runes)-limit, len(runes))
+}
+
+// summarizeResult asks the summary model to condense a result. The summary
+// counts against the budgets of the run.
+func (ta *ToolAgent) summarizeResult(wctx workflow.WorkContext, policy *ResultPolicy, toolCall llm.ToolCall, content string) (string, int, error) {
+	if policy.Client == nil {
+		return "", 0, fmt.Errorf("no client configured to summarize tool results")
+	}
+	args := ta.formatToolResult(toolCall.Args)
+	req := llm.CompletionRequest{
+		Model: policy.Model,
+		Messages: []llm.Message{
+			{Role: constants.RoleSystem, Content: fmt.Sprintf(summarizeResultPrompt, toolCall.Name, args, policy.MaxTokens)},
+			{Role: constants.RoleUser, Content: content},
+		},
+		MaxTokens:   policy.MaxTokens,
+		Temperature: 0,
+		Metadata: map[string]interface{}{
+			"agent_name": ta.name,
+			"agent_type": ta.agentType,
+			"tool_name":  toolCall.Name,
+			"purpose":    "tool_result_summary",
+		},
+	}
+	response, err := runBudgets(wctx, ta.budget).complete(wctx.Context(), policy.Client, ta.name, req)
+	if err != nil {
+		return "", 0, err
+	}
+	summary := strings.TrimSpace(response.Content)
+	if summary == "" {
+		return "", response.Usage.TotalTokens, fmt.Errorf("empty summary")
+	}
+	return "[Summary of a large result]\n" + truncateResult(summary, policy.MaxTokens), response.Usage.TotalTokens, nil
+}
+
+// resultStore holds paged tool results for the runs sharing a WorkContext.
+type resultStore struct {
+	mu      sync.Mutex
+	results map[string][]string
+	next    int
+}
+
+// sharedStoresMu serializes the creation of the stores that runs keep in a
+// WorkContext.
+var sharedStoresMu sync.Mutex
+
+// sharedStore returns the store kept in the WorkContext under key when valid
+// accepts it, and otherwise stores a new one from create. Parallel runs
+// sharing a WorkContext therefore end up with the same store.
+func sharedStore(wctx workflow.WorkContext, key interface{}, valid func(interface{}) bool, create func() interface{}) interface{} {
+	sharedStoresMu.Lock()
+	defer sharedStoresMu.Unlock()
+	if value, ok := wctx.Get(key); ok && valid(value) {
+		return value
+	}
+	store := create()
+	wctx.Set(key, store)
+	return store
+}
+
+// results returns the result store of the WorkContext, creating it if needed.
+func results(wctx workflow.WorkContext) *resultStore {
+	store := sharedStore(wctx, constants.KeyToolResults, func(value interface{}) bool {
+		_, ok := value.(*resultStore)
+		return ok
+	}, func() interface{} {
+		return &resultStore{results: make(map[string][]string)}
+	})
+	return store.(*resultStore)
+}
+
+// add stores the pages of a result and returns its handle.
+func (s *resultStore) add(pages []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	handle := fmt.Sprintf("result-%d", s.next)
+	s.results[handle] = pages
+	return handle
+}
+
+// page returns a page of a stored result and the number of pages.
+func (s *resultStore) page(handle string, page int) (string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pages, ok := s.results[handle]
+	if !ok {
+		return "", 0, fmt.Errorf("unknown result handle %q", handle)
+	}
+	if page < 1 || page > len(pages) {
+		return "", len(pages), fmt.Errorf("page %d out of range, result %s has %d pages", page, handle, len(pages))
+	}
+	return pages[page-1], len(pages), nil
+}
+
+// pageResult stores the content and returns its first page with the handle.
+func pageResult(wctx workflow.WorkContext, policy *ResultPolicy, content string) string {
+	size := policy.PageTokens
+	if size <= 0 {
+		size = policy.MaxTokens
+	}
+	pages := splitPages(content, size*charsPerToken)
+	handle := results(wctx).add(pages)
+	return formatPage(handle, pages[0], 1, len(pages))
+}
+
+// splitPages splits content into pages of at most size characters, breaking
+// at a newline when one is close to the limit.
+func splitPages(content string, size int) []string {
+	runes := []rune(content)
+	var pages []string
+	for len(runes) > size {
+		cut := size
+		for i := size; i > size*3/4; i-- {
+			if runes[i-1] == '\n' {
+				cut = i
+				break
+			}
+		}
+		pages = append(pages, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return append(pages, string(runes))
+}
+
+// formatPage adds the paging note to a page.
+func formatPage(handle, page string, number, total int) string {
+	if number == total {
+		return fmt.Sprintf("%s\n[Page %d of %d of result %s, end of result]", page, number, total, handle)
+	}
+	return fmt.Sprintf("%s\n[Page %d of %d of result %s. Call %s with {\"handle\": %q, \"page\": %d} to read on.]",
+		page, number, total, handle, ReadResultToolName, handle, number+1)
+}
+
+// readResultTool pages through the results stored in the WorkContext.
+type readResultTool struct{}
+
+func (readResultTool) Name() string {
+	return ReadResultToolName
+}
+
+func (readResultTool) Description() string {
+	return "Read a page of a large tool result that was split into pages."
+}
+
+func (readResultTool) Parameters() tools.Schema {
+	return tools.Schema{
+		Type: constants.SchemaTypeObject,
+		Properties: map[string]interface{}{
+			"handle": map[string]interface{}{
+				"type":        constants.SchemaTypeString,
+				"description": "Handle of the result, e.g. result-1",
+			},
+			"page": map[string]interface{}{
+				"type":        constants.SchemaTypeInteger,
+				"description": "Page number, starting at 1",
+			},
+		},
+		Required: []string{"handle", "page"},
+	}
+}
+
+func (readResultTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	wctx, ok := ctx.Value(constants.KeyWorkContext).(workflow.WorkContext)
+	if !ok {
+		return nil, fmt.Errorf("no stored results available")
+	}
+	handle, _ := params["handle"].(string)
+	page := 1
+	switch n := params["page"].(type) {
+	case float64:
+		page = int(n)
+	case int:
+		page = n
+	case string:
+		fmt.Sscanf(n, "%d", &page)
+	}
+
+	content, total, err := results(wctx).page(handle, page)
+	if err != nil {
+		return nil, err
+	}
+	return formatPage(handle, content, page, total), nil
+}
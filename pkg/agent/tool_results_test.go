@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func largeTool(name string, size int) *mockTool {
+	return &mockTool{name: name, execute: func(map[string]interface{}) (interface{}, error) {
+		return strings.Repeat("line of log output\n", size/19+1)[:size], nil
+	}}
+}
+
+// toolMessages returns the tool result messages of a request.
+func toolMessages(req llm.CompletionRequest) []llm.Message {
+	var results []llm.Message
+	for _, msg := range req.Messages {
+		if msg.Role == constants.RoleTool {
+			results = append(results, msg)
+		}
+	}
+	return results
+}
+
+func runWithToolCalls(t *testing.T, agent *ToolAgent, client *recordingLLMClient) {
+	t.Helper()
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Check the logs")
+	if report := agent.WithClient(client).Run(ctx); report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+}
+
+func TestToolAgent_TruncatesLargeResults(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "1", Name: "read_logs"}, {ID: "2", Name: "read_config"}}},
+		{Content: "Done."},
+	}}
+	agent := NewToolAgent("ops").WithTools(largeTool("read_logs", 5000), largeTool("read_config", 5000)).
+		WithResultPolicy(TruncateResults(100)).
+		WithToolResultPolicy("read_config", nil)
+	runWithToolCalls(t, agent, client)
+
+	results := toolMessages(client.requests[1])
+	if !strings.Contains(results[0].Content, "[... truncated 4600 of 5000 characters ...]") || len(results[0].Content) > 500 {
+		t.Errorf("Expected the log result truncated, got %d characters", len(results[0].Content))
+	}
+	if len(results[1].Content) != 5000 {
+		t.Errorf("Expected the per-tool policy to keep the config result, got %d characters", len(results[1].Content))
+	}
+}
+
+func TestToolAgent_SummarizesLargeResults(t *testing.T) {
+	summarizer := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "3 errors, all timeouts.", Usage: llm.Usage{TotalTokens: 40}},
+	}}
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "1", Name: "read_logs", Args: map[string]interface{}{"service": "api"}}}},
+		{Content: "Done."},
+	}}
+	agent := NewToolAgent("ops").WithTools(largeTool("read_logs", 5000)).
+		WithResultPolicy(SummarizeResults(summarizer, "gpt-4o-mini", 100))
+	runWithToolCalls(t, agent, client)
+
+	if got := toolMessages(client.requests[1])[0].Content; got != "[Summary of a large result]\n3 errors, all timeouts." {
+		t.Errorf("Expected the summary as tool result, got %q", got)
+	}
+	req := summarizer.requests[0]
+	if req.Model != "gpt-4o-mini" || !strings.Contains(req.Messages[0].Content, `"read_logs"`) || len(req.Messages[1].Content) != 5000 {
+		t.Errorf("Unexpected summary request %+v", req.Messages[0])
+	}
+}
+
+func TestToolAgent_PagesLargeResults(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "1", Name: "read_logs"}}},
+		{ToolCalls: []llm.ToolCall{{ID: "2", Name: ReadResultToolName, Args: map[string]interface{}{"handle": "result-1", "page": float64(2)}}}},
+		{Content: "Done."},
+	}}
+	agent := NewToolAgent("ops").WithTools(largeTool("read_logs", 500)).
+		WithToolResultPolicy("read_logs", PageResults(50))
+	runWithToolCalls(t, agent, client)
+
+	var offered bool
+	for _, def := range client.requests[0].Tools {
+		offered = offered || def.Name == ReadResultToolName
+	}
+	if !offered {
+		t.Error("Expected the read_tool_result tool to be offered")
+	}
+
+	results := toolMessages(client.requests[2])
+	if !strings.Contains(results[0].Content, `[Page 1 of 3 of result result-1. Call read_tool_result with {"handle": "result-1", "page": 2} to read on.]`) {
+		t.Errorf("Expected the first page with a handle, got %q", results[0].Content)
+	}
+	if !strings.Contains(results[1].Content, "[Page 2 of 3 of result result-1.") || !strings.HasPrefix(results[1].Content, "line of log output") {
+		t.Errorf("Expected the second page, got %q", results[1].Content)
+	}
+}
+
+func TestResults_SharedAcrossParallelRuns(t *testing.T) {
+	wctx := workflow.NewWorkContext(context.Background())
+
+	var wg sync.WaitGroup
+	handles := make([]string, 20)
+	for i := range handles {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			handles[i] = results(wctx).add([]string{"page"})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, handle := range handles {
+		if _, _, err := results(wctx).page(handle, 1); err != nil {
+			t.Errorf("Expected every run to use the same store: %v", err)
+		}
+	}
+}
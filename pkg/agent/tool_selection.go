@@ -0,0 +1,431 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/vectorstore"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// ToolSelection selects how a ToolSelector picks the tools of a turn.
+type ToolSelection string
+
+const (
+	// SelectByKeyword ranks tools by BM25 keyword match of their name and
+	// description with the conversation.
+	SelectByKeyword ToolSelection = "keyword"
+
+	// SelectByEmbedding ranks tools by embedding similarity with the
+	// conversation.
+	SelectByEmbedding ToolSelection = "embedding"
+
+	// SelectBySearch offers only the always-included tools and the
+	// search_tools tool, with which the model finds the tools it needs.
+	SelectBySearch ToolSelection = "search"
+)
+
+// SearchToolsToolName is the name of the built-in tool that searches the
+// tools of an agent.
+const SearchToolsToolName = "search_tools"
+
+// selectionQueryMessages is the number of recent user messages the tools
+// are matched against.
+const selectionQueryMessages = 3
+
+// ToolSelector picks the tools a ToolAgent offers the model on each turn, so
+// that agents with large toolsets do not send every definition with every
+// request. Selectors index the tool descriptions once and are safe for
+// concurrent use. Agents with different tools may share a selector, but every
+// switch between them re-indexes the tools that differ.
+type ToolSelector struct {
+	Selection ToolSelection
+	TopK      int          // Number of tools picked per turn or search
+	Embedder  llm.Embedder // Embeds tools and queries; keyword search is used without one
+	Always    []string     // Tools offered on every turn
+
+	mu      sync.Mutex
+	index   *vectorstore.Store
+	indexed map[string]string // Tool name -> indexed content
+}
+
+// SelectToolsByKeyword creates a selector that offers the k tools whose
+// names and descriptions best match the conversation.
+func SelectToolsByKeyword(k int) *ToolSelector {
+	return &ToolSelector{Selection: SelectByKeyword, TopK: k}
+}
+
+// SelectToolsByEmbedding creates a selector that offers the k tools most
+// similar to the conversation.
+func SelectToolsByEmbedding(embedder llm.Embedder, k int) *ToolSelector {
+	return &ToolSelector{Selection: SelectByEmbedding, TopK: k, Embedder: embedder}
+}
+
+// SearchTools creates a selector that lets the model search the tools with
+// the search_tools tool, which returns up to k tools per search and makes
+// them available for the rest of the run.
+func SearchTools(k int) *ToolSelector {
+	return &ToolSelector{Selection: SelectBySearch, TopK: k}
+}
+
+// WithAlways adds tools that are offered on every turn.
+func (s *ToolSelector) WithAlways(names ...string) *ToolSelector {
+	s.Always = append(s.Always, names...)
+	return s
+}
+
+// WithEmbedder sets the embedder, e.g. to search tools by similarity.
+func (s *ToolSelector) WithEmbedder(embedder llm.Embedder) *ToolSelector {
+	s.Embedder = embedder
+	return s
+}
+
+// always reports whether a tool is offered on every turn.
+func (s *ToolSelector) always(name string) bool {
+	for _, always := range s.Always {
+		if always == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rank returns the names of up to TopK of the candidates that best match the
+// query, best first. Always-included tools are not ranked.
+func (s *ToolSelector) rank(ctx context.Context, query string, candidates []tools.Tool) ([]string, error) {
+	if strings.TrimSpace(query) == "" || s.TopK <= 0 {
+		return nil, nil
+	}
+
+	req := vectorstore.SearchRequest{
+		K: s.TopK,
+		Filter: func(metadata map[string]interface{}) bool {
+			return metadata["always"] != true
+		},
+	}
+	if s.Embedder != nil {
+		embeddings, err := s.Embedder.Embed(ctx, []string{query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed tool query: %w", err)
+		}
+		if len(embeddings) != 1 {
+			return nil, fmt.Errorf("embedder returned %d vectors for 1 query", len(embeddings))
+		}
+		req.Embedding = embeddings[0]
+	} else {
+		req.Query = query
+	}
+
+	// Agents sharing the selector may offer other tools, so the index must
+	// not change between syncing it with the candidates and searching it
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index, err := s.syncLocked(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	results, err := index.Search(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("tool search failed: %w", err)
+	}
+	names := make([]string, len(results))
+	for i, result := range results {
+		names[i] = result.ID
+	}
+	return names, nil
+}
+
+// syncLocked indexes new and changed tools and drops removed ones. The caller
+// must hold the lock.
+func (s *ToolSelector) syncLocked(ctx context.Context, candidates []tools.Tool) (*vectorstore.Store, error) {
+	if s.index == nil {
+		s.index = vectorstore.NewStore()
+		s.indexed = make(map[string]string)
+	}
+
+	var docs []vectorstore.Document
+	var texts []string
+	current := make(map[string]bool, len(candidates))
+	for _, tool := range candidates {
+		current[tool.Name()] = true
+		content := toolSearchText(tool)
+		if s.indexed[tool.Name()] == content {
+			continue
+		}
+		docs = append(docs, vectorstore.Document{
+			ID:       tool.Name(),
+			Content:  content,
+			Metadata: map[string]interface{}{"always": s.always(tool.Name())},
+		})
+		texts = append(texts, content)
+	}
+	for name := range s.indexed {
+		if !current[name] {
+			s.index.Delete(name)
+			delete(s.indexed, name)
+		}
+	}
+	if len(docs) == 0 {
+		return s.index, nil
+	}
+
+	if s.Embedder != nil {
+		embeddings, err := s.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed tools: %w", err)
+		}
+		if len(embeddings) != len(docs) {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d tools", len(embeddings), len(docs))
+		}
+		for i := range docs {
+			docs[i].Embedding = embeddings[i]
+		}
+	}
+	if err := s.index.Upsert(docs...); err != nil {
+		return nil, fmt.Errorf("failed to index tools: %w", err)
+	}
+	for i, doc := range docs {
+		s.indexed[doc.ID] = texts[i]
+	}
+	return s.index, nil
+}
+
+// toolSearchText is the text a tool is matched by: its name, description and
+// parameter descriptions.
+func toolSearchText(tool tools.Tool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s", tool.Name(), tool.Description())
+	params := tool.Parameters().Properties
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sb.WriteString("\n")
+		sb.WriteString(name)
+		if param, ok := params[name].(map[string]interface{}); ok {
+			if description, ok := param["description"].(string); ok {
+				sb.WriteString(": " + description)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// selectionQuery is the text the tools of a turn are matched against: the
+// user input and the most recent user messages of the history.
+func selectionQuery(wctx workflow.WorkContext) string {
+	var parts []string
+	if input, ok := userInput(wctx); ok {
+		parts = append(parts, input)
+	}
+	if value, ok := wctx.Get(constants.KeyMessageHistory); ok {
+		history, _ := value.([]llm.Message)
+		for i := len(history) - 1; i >= 0 && len(parts) < selectionQueryMessages; i-- {
+			if history[i].Role == constants.RoleUser {
+				parts = append(parts, history[i].Content)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toolSelections holds the tools selected for the runs of the agents that
+// share a WorkContext, by agent name.
+type toolSelections struct {
+	mu       sync.Mutex
+	selected map[string][]string
+}
+
+// selections returns the tool selections of the WorkContext, creating them
+// if needed.
+func selections(wctx workflow.WorkContext) *toolSelections {
+	store := sharedStore(wctx, constants.KeySelectedTools, func(value interface{}) bool {
+		_, ok := value.(*toolSelections)
+		return ok
+	}, func() interface{} {
+		return &toolSelections{selected: make(map[string][]string)}
+	})
+	return store.(*toolSelections)
+}
+
+// set replaces the selection of an agent.
+func (s *toolSelections) set(agent string, names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.selected[agent] = names
+}
+
+// add adds tools to the selection of an agent and returns the new ones.
+func (s *toolSelections) add(agent string, names []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var added []string
+	for _, name := range names {
+		if !containsString(s.selected[agent], name) {
+			s.selected[agent] = append(s.selected[agent], name)
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+// get returns the selection of an agent.
+func (s *toolSelections) get(agent string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.selected[agent]...)
+}
+
+// containsString reports whether the list contains the value.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// candidateTools returns the tools of the agent and of its registry, by name.
+// Tools given with WithTools take precedence over registry tools of the same
+// name.
+func (ta *ToolAgent) candidateTools() []tools.Tool {
+	if ta.registry == nil {
+		return ta.tools
+	}
+	candidates := append([]tools.Tool(nil), ta.tools...)
+	registered := ta.registry.List()
+	sort.Slice(registered, func(i, j int) bool { return registered[i].Name() < registered[j].Name() })
+	for _, tool := range registered {
+		if !hasTool(candidates, tool.Name()) {
+			candidates = append(candidates, tool)
+		}
+	}
+	return candidates
+}
+
+// hasTool reports whether the list holds a tool with the name.
+func hasTool(list []tools.Tool, name string) bool {
+	for _, tool := range list {
+		if tool.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectTools picks the tools offered on this turn and records them in the
+// WorkContext. Without a selector every tool is offered.
+func (ta *ToolAgent) selectTools(wctx workflow.WorkContext) error {
+	if ta.selector == nil {
+		return nil
+	}
+	candidates := ta.candidateTools()
+	selected := make([]string, 0, len(ta.selector.Always)+ta.selector.TopK)
+	for _, tool := range candidates {
+		if ta.selector.always(tool.Name()) {
+			selected = append(selected, tool.Name())
+		}
+	}
+	if ta.selector.Selection != SelectBySearch {
+		ranked, err := ta.selector.rank(wctx.Context(), selectionQuery(wctx), candidates)
+		if err != nil {
+			return fmt.Errorf("tool selection failed: %w", err)
+		}
+		selected = append(selected, ranked...)
+	}
+	selections(wctx).set(ta.name, selected)
+	ta.log.Debug("selected tools", "tools", selected, "candidates", len(candidates))
+	return nil
+}
+
+// offeredTools returns the tools offered to the model: the selected tools, or
+// every tool without a selector, followed by the built-in tools.
+func (ta *ToolAgent) offeredTools(wctx workflow.WorkContext) []tools.Tool {
+	if ta.selector == nil {
+		return ta.availableTools()
+	}
+	candidates := ta.candidateTools()
+	var offered []tools.Tool
+	for _, name := range selections(wctx).get(ta.name) {
+		for _, tool := range candidates {
+			if tool.Name() == name {
+				offered = append(offered, tool)
+				break
+			}
+		}
+	}
+	return append(offered, ta.builtinTools()...)
+}
+
+// searchToolsTool lets the model find tools of its agent by description.
+type searchToolsTool struct {
+	agent *ToolAgent
+}
+
+func (t searchToolsTool) Name() string {
+	return SearchToolsToolName
+}
+
+func (t searchToolsTool) Description() string {
+	return "Search the available tools by what they do. Found tools can be called right away."
+}
+
+func (t searchToolsTool) Parameters() tools.Schema {
+	return tools.Schema{
+		Type: constants.SchemaTypeObject,
+		Properties: map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        constants.SchemaTypeString,
+				"description": "What the tool should do, e.g. create a calendar event",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+func (t searchToolsTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	wctx, ok := ctx.Value(constants.KeyWorkContext).(workflow.WorkContext)
+	if !ok {
+		return nil, fmt.Errorf("no tool selection available")
+	}
+	query, _ := params["query"].(string)
+	candidates := t.agent.candidateTools()
+	names, err := t.agent.selector.rank(ctx, query, candidates)
+	if err != nil {
+		return nil, err
+	}
+	selections(wctx).add(t.agent.name, names)
+
+	type foundTool struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	}
+	found := make([]foundTool, 0, len(names))
+	for _, name := range names {
+		for _, tool := range candidates {
+			if tool.Name() == name {
+				found = append(found, foundTool{Name: name, Description: tool.Description(), Parameters: convertSchemaToMap(tool.Parameters())})
+				break
+			}
+		}
+	}
+	if len(found) == 0 {
+		return "No tools found. Try other words.", nil
+	}
+	data, err := json.Marshal(found)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// describedTool is a tool with a real description for selection tests
+type describedTool struct {
+	name        string
+	description string
+	calls       int
+}
+
+func (d *describedTool) Name() string        { return d.name }
+func (d *describedTool) Description() string { return d.description }
+
+func (d *describedTool) Parameters() tools.Schema {
+	return tools.Schema{Type: constants.SchemaTypeObject, Properties: map[string]interface{}{}}
+}
+
+func (d *describedTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	d.calls++
+	return "done by " + d.name, nil
+}
+
+func toolRegistry(t *testing.T) *tools.DefaultToolRegistry {
+	t.Helper()
+	registry := tools.NewDefaultToolRegistry()
+	for _, tool := range []*describedTool{
+		{name: "get_weather", description: "Get the weather forecast for a city"},
+		{name: "create_event", description: "Create a calendar event with a title and a time"},
+		{name: "send_email", description: "Send an email message to a recipient"},
+		{name: "stock_price", description: "Look up the current price of a stock ticker"},
+		{name: "translate", description: "Translate text into another language"},
+		{name: "calculator", description: "Evaluate an arithmetic expression"},
+	} {
+		if err := registry.Register(tool); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return registry
+}
+
+// toolNames returns the names of the tool definitions of a request.
+func toolNames(req llm.CompletionRequest) []string {
+	var names []string
+	for _, tool := range req.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestToolAgent_SelectsToolsByKeyword(t *testing.T) {
+	client := &recordingLLMClient{}
+	ta := NewToolAgent("assistant").
+		WithClient(client).
+		WithToolRegistry(toolRegistry(t)).
+		WithToolSelector(SelectToolsByKeyword(2).WithAlways("calculator"))
+
+	if len(ta.Tools()) != 6 {
+		t.Errorf("Expected the registry tools, got %d", len(ta.Tools()))
+	}
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyMessageHistory, []llm.Message{{Role: constants.RoleUser, Content: "I need to plan a calendar event"}})
+	ctx.Set(constants.KeyUserInput, "And what is the weather forecast in Paris?")
+	report := ta.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the run to complete, got %v", report.Errors)
+	}
+
+	// The input and the earlier user message both count
+	names := toolNames(client.requests[0])
+	if len(names) != 3 || names[0] != "calculator" || !containsString(names, "get_weather") || !containsString(names, "create_event") {
+		t.Errorf("Expected the calculator, weather and calendar tools, got %v", names)
+	}
+	if selected := report.Metadata["selected_tools"].([]string); len(selected) != 3 {
+		t.Errorf("Expected the selection in the metadata, got %v", selected)
+	}
+}
+
+// toolEmbedder embeds texts as counts of a fixed vocabulary.
+type toolEmbedder struct {
+	mu    sync.Mutex
+	texts int
+}
+
+var toolVocabulary = []string{"weather", "forecast", "calendar", "event", "email", "stock", "translate", "arithmetic"}
+
+func (e *toolEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	e.mu.Lock()
+	e.texts += len(texts)
+	e.mu.Unlock()
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(toolVocabulary))
+		for j, word := range toolVocabulary {
+			vectors[i][j] = float64(strings.Count(strings.ToLower(text), word))
+		}
+	}
+	return vectors, nil
+}
+
+func TestToolAgent_SelectsToolsByEmbedding(t *testing.T) {
+	client := &recordingLLMClient{}
+	embedder := &toolEmbedder{}
+	ta := NewToolAgent("assistant").
+		WithClient(client).
+		WithTools(weatherTool()). // Same name as a registry tool, takes precedence
+		WithToolRegistry(toolRegistry(t)).
+		WithToolSelector(SelectToolsByEmbedding(embedder, 1))
+
+	for _, input := range []string{"Will the weather be nice?", "Email my boss"} {
+		ctx := workflow.NewWorkContext(context.Background())
+		ctx.Set(constants.KeyUserInput, input)
+		ta.Run(ctx)
+	}
+
+	if names := toolNames(client.requests[0]); len(names) != 1 || names[0] != "get_weather" || client.requests[0].Tools[0].Description != "Mock tool get_weather" {
+		t.Errorf("Expected the agent's weather tool, got %+v", client.requests[0].Tools)
+	}
+	if names := toolNames(client.requests[1]); len(names) != 1 || names[0] != "send_email" {
+		t.Errorf("Expected the email tool, got %v", names)
+	}
+	// Six tools indexed once, plus one query per run
+	if embedder.texts != 8 {
+		t.Errorf("Expected the tools to be embedded once, got %d embedded texts", embedder.texts)
+	}
+}
+
+func TestToolAgent_SearchTools(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{ToolCalls: []llm.ToolCall{{ID: "1", Name: SearchToolsToolName, Args: map[string]interface{}{"query": "send an email"}}}},
+		{ToolCalls: []llm.ToolCall{{ID: "2", Name: "send_email", Args: map[string]interface{}{}}}},
+		{Content: "Email sent."},
+	}}
+	registry := toolRegistry(t)
+	ta := NewToolAgent("assistant").
+		WithClient(client).
+		WithToolRegistry(registry).
+		WithToolSelector(SearchTools(2).WithAlways("calculator"))
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Tell Bob I am late")
+	report := ta.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected the run to complete, got %v", report.Errors)
+	}
+
+	if names := toolNames(client.requests[0]); strings.Join(names, ",") != "calculator,"+SearchToolsToolName {
+		t.Errorf("Expected only the always-included and the search tool at first, got %v", names)
+	}
+	if result := client.requests[1].Messages[len(client.requests[1].Messages)-1]; !strings.Contains(result.Content, `"name":"send_email"`) {
+		t.Errorf("Expected the search result to describe send_email, got %q", result.Content)
+	}
+	if names := toolNames(client.requests[1]); !containsString(names, "send_email") {
+		t.Errorf("Expected the found tool to be offered, got %v", names)
+	}
+	tool, _ := registry.Get("send_email")
+	if tool.(*describedTool).calls != 1 {
+		t.Error("Expected the found tool to be called")
+	}
+}
+
+func TestToolSelector_SharedByAgentsWithDifferentTools(t *testing.T) {
+	selector := SelectToolsByKeyword(3)
+	groups := [][]tools.Tool{
+		{
+			&describedTool{name: "get_weather", description: "Get the weather forecast for a city"},
+			&describedTool{name: "create_event", description: "Create a calendar event with a title and a time"},
+		},
+		{
+			&describedTool{name: "stock_price", description: "Look up the current price of a stock ticker"},
+			&describedTool{name: "translate", description: "Translate text into another language"},
+		},
+	}
+	query := "weather forecast, calendar event, stock price and translate text"
+
+	var wg sync.WaitGroup
+	for _, candidates := range groups {
+		wg.Add(1)
+		go func(candidates []tools.Tool) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				names, err := selector.rank(context.Background(), query, candidates)
+				if err != nil {
+					t.Errorf("Rank failed: %v", err)
+					return
+				}
+				for _, name := range names {
+					if name != candidates[0].Name() && name != candidates[1].Name() {
+						t.Errorf("Expected only the agent's own tools, got %v", names)
+						return
+					}
+				}
+			}
+		}(candidates)
+	}
+	wg.Wait()
+
+	// Parallel agents keep their selections in one store
+	wctx := workflow.NewWorkContext(context.Background())
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			selections(wctx).set(fmt.Sprintf("agent-%d", i), []string{"get_weather"})
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 20; i++ {
+		if len(selections(wctx).get(fmt.Sprintf("agent-%d", i))) != 1 {
+			t.Errorf("Expected the selection of agent-%d to be kept", i)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// Transcript is the structured record of a ToolAgent run: the full
+// conversation and, per loop iteration, the model turn, the tool calls with
+// their results, the timing and the token usage. It is attached to the work
+// report under the "transcript" metadata key.
+type Transcript struct {
+	Agent      string
+	Mode       ToolCallingMode
+	Messages   []llm.Message // Conversation sent to the model, ending with the final answer
+	Iterations []TranscriptIteration
+	Usage      llm.Usage // Usage of all completions of the run
+	Started    time.Time
+	Elapsed    time.Duration
+
+	systemPrompt string
+	stored       []llm.Message // Stored message history the run continues from
+	sent         int           // Number of history messages sent to the model
+}
+
+// TranscriptIteration records one model turn of the tool calling loop.
+type TranscriptIteration struct {
+	Iteration int
+	Content   string // Text of the assistant turn
+	ToolCalls []TranscriptToolCall
+	Usage     llm.Usage
+	Started   time.Time
+	Elapsed   time.Duration // Latency of the completion
+}
+
+// TranscriptToolCall records a tool call and the result returned to the model.
+type TranscriptToolCall struct {
+	ID      string
+	Name    string
+	Args    map[string]interface{}
+	Result  string // Content returned to the model
+	Error   string
+	Elapsed time.Duration
+}
+
+// TranscriptFromReport returns the transcript attached to a report.
+func TranscriptFromReport(report workflow.WorkReport) (*Transcript, bool) {
+	transcript, ok := report.Metadata["transcript"].(*Transcript)
+	return transcript, ok && transcript != nil
+}
+
+// HandoffTranscripts returns the transcripts of the agents that handed the
+// conversation off before the agent that produced the report, in handoff order.
+// They are attached under the "handoff_transcripts" metadata key.
+func HandoffTranscripts(report workflow.WorkReport) []*Transcript {
+	transcripts, _ := report.Metadata["handoff_transcripts"].([]*Transcript)
+	return transcripts
+}
+
+// newTranscript starts the transcript of a run.
+func newTranscript(agentName string, mode ToolCallingMode, started time.Time) *Transcript {
+	return &Transcript{Agent: agentName, Mode: mode, Started: started}
+}
+
+// addIteration records a completion and returns its iteration so that tool
+// calls can be added to it.
+func (t *Transcript) addIteration(iteration int, started time.Time, content string, usage llm.Usage) *TranscriptIteration {
+	t.Iterations = append(t.Iterations, TranscriptIteration{
+		Iteration: iteration,
+		Content:   content,
+		Usage:     usage,
+		Started:   started,
+		Elapsed:   time.Since(started),
+	})
+	t.Usage.PromptTokens += usage.PromptTokens
+	t.Usage.CompletionTokens += usage.CompletionTokens
+	t.Usage.TotalTokens += usage.TotalTokens
+	return &t.Iterations[len(t.Iterations)-1]
+}
+
+// addToolCall records a tool call of the iteration.
+func (it *TranscriptIteration) addToolCall(toolCall llm.ToolCall, outcome toolOutcome) {
+	call := TranscriptToolCall{
+		ID:      toolCall.ID,
+		Name:    toolCall.Name,
+		Args:    toolCall.Args,
+		Result:  outcome.content,
+		Elapsed: outcome.elapsed,
+	}
+	if outcome.err != nil {
+		call.Error = outcome.err.Error()
+	}
+	it.ToolCalls = append(it.ToolCalls, call)
+}
+
+// finish completes the transcript with the conversation and the final answer.
+func (t *Transcript) finish(messages []llm.Message, final *llm.CompletionResponse) {
+	t.Messages = append([]llm.Message{}, messages...)
+	if final != nil && final.Content != "" {
+		t.Messages = append(t.Messages, llm.Message{Role: constants.RoleAssistant, Content: final.Content})
+	}
+	t.Elapsed = time.Since(t.Started)
+}
+
+// history returns the conversation of the transcript without the system
+// prompt and the ReAct protocol, ready to be stored as message history.
+// Turns that were left out of the requests to fit the context window are
+// restored from the stored history.
+func (t *Transcript) history() []llm.Message {
+	start := 0
+	if len(t.Messages) > 0 && strings.HasPrefix(t.Messages[0].Content, reactInstructions) {
+		start = 1
+	}
+	messages := t.Messages
+	if start+t.sent <= len(t.Messages) {
+		messages = append(append(append([]llm.Message{}, t.Messages[:start]...), t.stored...), t.Messages[start+t.sent:]...)
+	}
+
+	var history []llm.Message
+	for _, msg := range messages {
+		if msg.Role == constants.RoleSystem && (msg.Content == t.systemPrompt || strings.HasPrefix(msg.Content, reactInstructions)) {
+			continue
+		}
+		history = append(history, msg)
+	}
+	return history
+}
+
+// recordTranscript completes the transcript, attaches it to the report and,
+// when enabled, writes the conversation back to the message history.
+func (ta *ToolAgent) recordTranscript(wctx workflow.WorkContext, report *workflow.WorkReport, transcript *Transcript, messages []llm.Message, final *llm.CompletionResponse) {
+	transcript.systemPrompt, _ = ta.systemPrompt(wctx)
+	transcript.finish(messages, final)
+	report.SetMetadata("transcript", transcript)
+
+	if ta.history && report.Status == workflow.StatusCompleted {
+		wctx.Set(constants.KeyMessageHistory, transcript.history())
+	}
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestToolAgent_Transcript(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{
+			Content: "Checking both cities.",
+			ToolCalls: []llm.ToolCall{
+				{ID: "1", Name: "get_weather", Args: map[string]interface{}{"city": "Paris"}},
+				{ID: "2", Name: "get_time", Args: map[string]interface{}{"city": "Paris"}},
+			},
+			Usage: llm.Usage{PromptTokens: 30, CompletionTokens: 10, TotalTokens: 40},
+		},
+		{Content: "It is sunny in Paris.", Usage: llm.Usage{PromptTokens: 50, CompletionTokens: 5, TotalTokens: 55}},
+	}}
+	failing := &mockTool{name: "get_time", execute: func(map[string]interface{}) (interface{}, error) {
+		return nil, errors.New("clock unavailable")
+	}}
+
+	ta := NewToolAgent("weather").
+		WithClient(client).
+		WithPrompt("You report the weather.").
+		WithTools(weatherTool(), failing).
+		WithTranscriptHistory(true)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Weather in Paris?")
+	report := ta.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v: %v", report.Status, report.Errors)
+	}
+
+	transcript, ok := TranscriptFromReport(report)
+	if !ok {
+		t.Fatal("Expected a transcript in the report")
+	}
+	if len(transcript.Iterations) != 2 {
+		t.Fatalf("Expected 2 iterations, got %d", len(transcript.Iterations))
+	}
+	first := transcript.Iterations[0]
+	if first.Content != "Checking both cities." || first.Usage.TotalTokens != 40 || len(first.ToolCalls) != 2 {
+		t.Errorf("Unexpected first iteration: %+v", first)
+	}
+	if call := first.ToolCalls[0]; call.Name != "get_weather" || call.Args["city"] != "Paris" || call.Result == "" || call.Error != "" {
+		t.Errorf("Unexpected weather call: %+v", call)
+	}
+	if call := first.ToolCalls[1]; call.Error != "clock unavailable" {
+		t.Errorf("Expected the tool error in the transcript, got %+v", call)
+	}
+	if transcript.Usage.TotalTokens != 95 || transcript.Usage.PromptTokens != 80 {
+		t.Errorf("Expected the summed usage, got %+v", transcript.Usage)
+	}
+
+	// system, user, assistant, two tool results and the final answer
+	if len(transcript.Messages) != 6 {
+		t.Fatalf("Expected 6 messages, got %+v", transcript.Messages)
+	}
+	if last := transcript.Messages[5]; last.Role != constants.RoleAssistant || last.Content != "It is sunny in Paris." {
+		t.Errorf("Expected the final answer last, got %+v", last)
+	}
+
+	value, _ := ctx.Get(constants.KeyMessageHistory)
+	history := value.([]llm.Message)
+	if len(history) != 5 || history[0].Role != constants.RoleUser || history[2].Role != constants.RoleTool {
+		t.Errorf("Expected the conversation without the system prompt in the history, got %+v", history)
+	}
+}
+
+func TestToolAgent_TranscriptReAct(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{
+		{Content: "Thought: I need the weather.\nAction: get_weather\nAction Input: {\"city\": \"Paris\"}"},
+		{Content: "Final Answer: It is sunny in Paris."},
+	}}
+	ta := NewToolAgent("react").
+		WithClient(client).
+		WithTools(weatherTool()).
+		WithToolCallingMode(ToolCallingReAct)
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Weather in Paris?")
+	report := ta.Run(ctx)
+
+	transcript, ok := TranscriptFromReport(report)
+	if !ok || transcript.Mode != ToolCallingReAct || len(transcript.Iterations) != 2 {
+		t.Fatalf("Expected a ReAct transcript with 2 iterations, got %+v", transcript)
+	}
+	if calls := transcript.Iterations[0].ToolCalls; len(calls) != 1 || calls[0].Name != "get_weather" {
+		t.Errorf("Expected the parsed tool call, got %+v", calls)
+	}
+	if _, ok := ctx.Get(constants.KeyMessageHistory); ok {
+		t.Error("Expected the history to be left alone without WithTranscriptHistory")
+	}
+}
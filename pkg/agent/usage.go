@@ -0,0 +1,18 @@
+package agent
+
+import (
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// reportTokens returns the total number of tokens recorded in a report's metadata.
+// Agents record either "total_tokens" (loops) or "token_usage" (single completions).
+func reportTokens(report workflow.WorkReport) int {
+	if total, ok := report.Metadata["total_tokens"].(int); ok {
+		return total
+	}
+	if usage, ok := report.Metadata["token_usage"].(llm.Usage); ok {
+		return usage.TotalTokens
+	}
+	return 0
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// keyMapping maps a WorkContext key of one context to a key of another.
+type keyMapping struct {
+	from     interface{}
+	to       interface{}
+	required bool
+}
+
+// WorkflowAgent wraps a workflow as an Agent, so that a complex pipeline can
+// be used and reused wherever a single agent fits: in other workflows, as a
+// handoff target or as a tool. The workflow runs in an isolated child
+// WorkContext; declared inputs are copied in from the parent context and
+// declared outputs are copied back when the workflow completes.
+type WorkflowAgent struct {
+	name      string
+	agentType AgentType
+	flow      workflow.Action
+	inputs    []keyMapping
+	outputs   []keyMapping
+	declared  bool // Inputs were declared, replacing the defaults
+}
+
+// NewWorkflowAgent creates a new WorkflowAgent for the given workflow. Until
+// inputs are declared, the user input and the message history are passed to
+// the workflow.
+func NewWorkflowAgent(name string, flow workflow.Action) *WorkflowAgent {
+	return &WorkflowAgent{
+		name:      name,
+		agentType: TypeWorkflow,
+		flow:      flow,
+		inputs: []keyMapping{
+			{from: constants.KeyUserInput, to: constants.KeyUserInput},
+			{from: constants.KeyMessageHistory, to: constants.KeyMessageHistory},
+		},
+	}
+}
+
+// Name returns the name of the WorkflowAgent.
+func (wa *WorkflowAgent) Name() string {
+	return wa.name
+}
+
+// Type returns the type of the agent.
+func (wa *WorkflowAgent) Type() AgentType {
+	return wa.agentType
+}
+
+// Workflow returns the wrapped workflow.
+func (wa *WorkflowAgent) Workflow() workflow.Action {
+	return wa.flow
+}
+
+// Tools returns the tools of all agents in the workflow, each name once.
+func (wa *WorkflowAgent) Tools() []tools.Tool {
+	var all []tools.Tool
+	seen := make(map[string]bool)
+	for _, agent := range wa.agents() {
+		for _, tool := range agent.Tools() {
+			if !seen[tool.Name()] {
+				seen[tool.Name()] = true
+				all = append(all, tool)
+			}
+		}
+	}
+	return all
+}
+
+// Configure passes the settings through to the agents in the workflow.
+// Top-level settings apply to every agent; settings under "agents", keyed by
+// agent name, apply to that agent only and take precedence.
+func (wa *WorkflowAgent) Configure(config map[string]interface{}) error {
+	shared := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if key != "agents" {
+			shared[key] = value
+		}
+	}
+	perAgent, _ := config["agents"].(map[string]interface{})
+
+	for _, agent := range wa.agents() {
+		settings := make(map[string]interface{}, len(shared))
+		for key, value := range shared {
+			settings[key] = value
+		}
+		if own, ok := perAgent[agent.Name()].(map[string]interface{}); ok {
+			for key, value := range own {
+				settings[key] = value
+			}
+		}
+		if len(settings) == 0 {
+			continue
+		}
+		if err := agent.Configure(settings); err != nil {
+			return fmt.Errorf("failed to configure agent %s: %w", agent.Name(), err)
+		}
+	}
+	return nil
+}
+
+// WithInput declares a required input: the value of parentKey is copied to
+// childKey of the workflow context. The first declared input replaces the
+// default user input and message history inputs.
+func (wa *WorkflowAgent) WithInput(parentKey, childKey interface{}) *WorkflowAgent {
+	return wa.addInput(keyMapping{from: parentKey, to: childKey, required: true})
+}
+
+// WithOptionalInput declares an input that is copied only when present.
+func (wa *WorkflowAgent) WithOptionalInput(parentKey, childKey interface{}) *WorkflowAgent {
+	return wa.addInput(keyMapping{from: parentKey, to: childKey})
+}
+
+func (wa *WorkflowAgent) addInput(mapping keyMapping) *WorkflowAgent {
+	if !wa.declared {
+		wa.inputs = nil
+		wa.declared = true
+	}
+	wa.inputs = append(wa.inputs, mapping)
+	return wa
+}
+
+// WithOutput declares an output: when the workflow completes, the value of
+// childKey is copied to parentKey of the parent context.
+func (wa *WorkflowAgent) WithOutput(childKey, parentKey interface{}) *WorkflowAgent {
+	wa.outputs = append(wa.outputs, keyMapping{from: childKey, to: parentKey})
+	return wa
+}
+
+// Run runs the workflow in a child context and maps its outputs back.
+func (wa *WorkflowAgent) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	startTime := time.Now()
+	logger := wctx.Logger().With("agent", "WorkflowAgent", "name", wa.name)
+
+	if wa.flow == nil {
+		return workflow.NewFailedWorkReport(fmt.Errorf("no workflow configured for agent %s", wa.name))
+	}
+
+	child := workflow.NewChildWorkContext(wctx)
+	for _, input := range wa.inputs {
+		value, ok := wctx.Get(input.from)
+		if !ok {
+			if input.required {
+				logger.Error("missing input", "key", input.from)
+				return workflow.NewFailedWorkReport(fmt.Errorf("workflow agent %s: missing input %v", wa.name, input.from))
+			}
+			continue
+		}
+		child.Set(input.to, value)
+	}
+
+	report := wa.flow.Run(child)
+
+	if report.Status == workflow.StatusCompleted {
+		for _, output := range wa.outputs {
+			if value, ok := child.Get(output.from); ok {
+				wctx.Set(output.to, value)
+			}
+		}
+	}
+
+	elapsed := time.Since(startTime)
+	logger.Info("workflow completed", "workflow", wa.flow.Name(), "status", report.Status, "elapsed", elapsed)
+
+	report.SetMetadata("agent_name", wa.name)
+	report.SetMetadata("agent_type", wa.agentType)
+	report.SetMetadata("workflow_name", wa.flow.Name())
+	report.SetMetadata("elapsed", elapsed)
+	report.SetMetadata("execution_type", "workflow")
+	return report
+}
+
+// agents returns the agents in the workflow. Nested agents report the tools
+// of their own sub-agents, so the walk does not descend into agents.
+func (wa *WorkflowAgent) agents() []Agent {
+	var agents []Agent
+	workflow.Walk(wa.flow, func(action workflow.Action) bool {
+		if agent, ok := action.(Agent); ok {
+			agents = append(agents, agent)
+			return false
+		}
+		return true
+	})
+	return agents
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestWorkflowAgent_MapsInputsAndOutputs(t *testing.T) {
+	client := &recordingLLMClient{responses: []*llm.CompletionResponse{{Content: "Summary of the ticket."}}}
+	summarizer := NewChatAgent("summarizer").WithClient(client)
+	store := workflow.NewActionFunc("store", func(wctx workflow.WorkContext) workflow.WorkReport {
+		ticket, _ := wctx.Get("ticket")
+		wctx.Set("summary", "stored:"+ticket.(string))
+		wctx.Set("scratch", "internal")
+		return workflow.NewCompletedWorkReport()
+	})
+
+	pipeline := NewWorkflowAgent("triage", workflow.NewSequentialFlow("triage_flow", summarizer, store)).
+		WithInput("ticket_text", constants.KeyUserInput).
+		WithInput("ticket_text", "ticket").
+		WithOutput("summary", "ticket_summary")
+
+	var _ Agent = pipeline
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set("ticket_text", "My order arrived broken")
+	ctx.Set(constants.KeyMessageHistory, []llm.Message{{Role: constants.RoleUser, Content: "unrelated"}})
+	report := pipeline.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected StatusCompleted, got %v", report.Errors)
+	}
+
+	messages := client.requests[0].Messages
+	if len(messages) != 1 || messages[0].Content != "My order arrived broken" {
+		t.Errorf("Expected only the declared inputs in the child context, got %+v", messages)
+	}
+	if summary, _ := ctx.Get("ticket_summary"); summary != "stored:My order arrived broken" {
+		t.Errorf("Expected the mapped output, got %v", summary)
+	}
+	if _, ok := ctx.Get("scratch"); ok {
+		t.Error("Expected undeclared outputs to stay in the child context")
+	}
+	if report.Metadata["agent_type"] != TypeWorkflow || report.Metadata["workflow_name"] != "triage_flow" {
+		t.Errorf("Unexpected metadata %v", report.Metadata)
+	}
+
+	report = pipeline.Run(workflow.NewWorkContext(context.Background()))
+	if report.Status != workflow.StatusFailure {
+		t.Error("Expected a missing required input to fail the run")
+	}
+}
+
+func TestWorkflowAgent_ToolsAndConfigure(t *testing.T) {
+	weather := weatherTool()
+	search := &mockTool{name: "search"}
+	planner := NewToolAgent("planner").WithTools(weather, search)
+	writer := NewToolAgent("writer").WithTools(weather)
+	reviewer := NewChatAgent("reviewer")
+
+	flow := workflow.NewSequentialFlow("flow",
+		planner,
+		workflow.NewRetry("retry", 2).WithAction(
+			workflow.NewConditionalFlow("check", func(workflow.WorkContext) (bool, error) { return true, nil }, writer, reviewer),
+		),
+	)
+	wa := NewWorkflowAgent("team", flow)
+
+	names := map[string]bool{}
+	for _, tool := range wa.Tools() {
+		if names[tool.Name()] {
+			t.Errorf("Expected tool %s once", tool.Name())
+		}
+		names[tool.Name()] = true
+	}
+	if len(names) != 2 || !names["get_weather"] || !names["search"] {
+		t.Errorf("Expected the tools of all nested agents, got %v", names)
+	}
+
+	err := wa.Configure(map[string]interface{}{
+		"model":  "gpt-4o-mini",
+		"agents": map[string]interface{}{"writer": map[string]interface{}{"model": "gpt-4o"}},
+	})
+	if err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	if planner.model != "gpt-4o-mini" || reviewer.model != "gpt-4o-mini" || writer.model != "gpt-4o" {
+		t.Errorf("Expected settings passed through, got %s, %s, %s", planner.model, reviewer.model, writer.model)
+	}
+}
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"github.com/ratlabs-io/go-agent-kit/pkg/agent"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/prompt"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+)
+
+// AgentDefinition is the declarative form of a ChatAgent or ToolAgent:
+//
+//	agents:
+//	  - name: support
+//	    type: tool                 # chat (default) or tool
+//	    client: local              # named client registered with Loader.WithClient
+//	    model: gpt-4o
+//	    prompt_template: support@2 # or an inline prompt
+//	    prompt_inputs:
+//	      company: Acme
+//	    tools: [lookup_order, refund]
+//	    handoffs:
+//	      - billing
+//	      - agent: escalation
+//	        description: Hand off angry customers
+//	    tool_calling_mode: native  # native or react
+//	    response_type: json_schema # text, json_object or json_schema
+//	    response_schema:
+//	      name: answer
+//	      strict: true
+//	      schema: {type: object, properties: {answer: {type: string}}}
+//	    temperature: 0.2
+//	    top_p: 0.9
+//	    max_tokens: 800
+//	    max_tool_calls: 4
+//	    max_handoffs: 2
+type AgentDefinition struct {
+	Name            string
+	Type            agent.AgentType
+	Client          string
+	Model           string
+	Prompt          string
+	PromptTemplate  string
+	PromptInputs    map[string]interface{}
+	Tools           []string
+	Handoffs        []HandoffDefinition
+	ToolCallingMode agent.ToolCallingMode
+	ResponseType    llm.ResponseType
+	ResponseSchema  *llm.JSONSchema
+	Temperature     *float64
+	TopP            *float64
+	MaxTokens       int
+	MaxToolCalls    int
+	MaxHandoffs     int
+
+	// Source and node locate the definition for errors found while building.
+	Source string
+	node   *Node
+}
+
+// HandoffDefinition names an agent that a tool agent may hand off to.
+type HandoffDefinition struct {
+	Agent       string
+	Description string
+}
+
+// ParseAgents validates the agent definitions in a parsed document. All
+// problems are reported together as an ErrorList with line numbers.
+func ParseAgents(root *Node, source string) ([]*AgentDefinition, error) {
+	doc, err := ParseDocument(root, source)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Agents, nil
+}
+
+// parseAgentList parses the "agents" field of a document.
+func parseAgentList(doc *object, source string, errs *ErrorList) []*AgentDefinition {
+	var defs []*AgentDefinition
+	seen := make(map[string]bool)
+	for _, item := range doc.sequence("agents") {
+		def := parseAgent(item, source, errs)
+		if def.Name != "" {
+			if seen[def.Name] {
+				errs.add(source, item, "duplicate agent name %q", def.Name)
+			}
+			seen[def.Name] = true
+		}
+		defs = append(defs, def)
+	}
+
+	for _, def := range defs {
+		for i, handoff := range def.Handoffs {
+			if handoff.Agent != "" && !seen[handoff.Agent] {
+				errs.add(source, def.field("handoffs", i), "agent %s: handoff to unknown agent %q", def.Name, handoff.Agent)
+			}
+		}
+	}
+	return defs
+}
+
+// parseAgent parses and validates a single agent definition.
+func parseAgent(n *Node, source string, errs *ErrorList) *AgentDefinition {
+	o := newObject(n, "agent", source, errs)
+	def := &AgentDefinition{Source: source, node: n}
+
+	def.Name = o.required("name")
+	if def.Name != "" {
+		o.what = "agent " + def.Name
+	}
+
+	def.Type = agent.TypeChat
+	if t := o.oneOf("type", string(agent.TypeChat), string(agent.TypeTool)); t != "" {
+		def.Type = agent.AgentType(t)
+	}
+	def.Client = o.str("client")
+	def.Model = o.str("model")
+	def.Prompt = o.str("prompt")
+	def.PromptTemplate = o.str("prompt_template")
+	def.PromptInputs = o.mapping("prompt_inputs")
+	if def.Prompt != "" && def.PromptTemplate != "" {
+		o.errorf(o.get("prompt_template"), "%s: prompt and prompt_template are mutually exclusive", o.what)
+	}
+	if def.PromptInputs != nil && def.PromptTemplate == "" {
+		o.errorf(o.get("prompt_inputs"), "%s: prompt_inputs requires prompt_template", o.what)
+	}
+
+	for _, item := range o.sequence("tools") {
+		if item.Kind != ScalarNode || item.IsNull() {
+			o.errorf(item, "%s: tools must be a list of tool names", o.what)
+			continue
+		}
+		def.Tools = append(def.Tools, item.Value)
+	}
+	for _, item := range o.sequence("handoffs") {
+		def.Handoffs = append(def.Handoffs, parseHandoff(item, o.what, source, errs))
+	}
+
+	if mode := o.oneOf("tool_calling_mode", string(agent.ToolCallingNative), string(agent.ToolCallingReAct)); mode != "" {
+		def.ToolCallingMode = agent.ToolCallingMode(mode)
+	}
+	if rt := o.oneOf("response_type", string(llm.ResponseTypeText), string(llm.ResponseTypeJSONObject), string(llm.ResponseTypeJSONSchema)); rt != "" {
+		def.ResponseType = llm.ResponseType(rt)
+	}
+	if schema := o.get("response_schema"); schema != nil {
+		def.ResponseSchema = parseResponseSchema(schema, o.what, source, errs)
+		if def.ResponseType == "" {
+			def.ResponseType = llm.ResponseTypeJSONSchema
+		}
+	}
+	if def.ResponseType == llm.ResponseTypeJSONSchema && def.ResponseSchema == nil {
+		o.errorf(o.get("response_type"), "%s: response_type json_schema requires response_schema", o.what)
+	}
+
+	if v, ok := o.float("temperature"); ok {
+		if v < 0 || v > 2 {
+			o.errorf(o.get("temperature"), "%s: temperature must be between 0 and 2", o.what)
+		}
+		def.Temperature = &v
+	}
+	if v, ok := o.float("top_p"); ok {
+		if v <= 0 || v > 1 {
+			o.errorf(o.get("top_p"), "%s: top_p must be greater than 0 and at most 1", o.what)
+		}
+		def.TopP = &v
+	}
+	def.MaxTokens = positive(o, "max_tokens")
+	def.MaxToolCalls = positive(o, "max_tool_calls")
+	def.MaxHandoffs = positive(o, "max_handoffs")
+
+	if def.Type != agent.TypeTool {
+		for _, key := range []string{"tools", "handoffs", "tool_calling_mode", "max_tool_calls", "max_handoffs"} {
+			if field := o.get(key); field != nil {
+				o.errorf(field, "%s: %s requires type tool", o.what, key)
+			}
+		}
+	}
+
+	o.finish()
+	return def
+}
+
+// parseHandoff parses a handoff given as an agent name or as a mapping with
+// agent and description fields.
+func parseHandoff(n *Node, what, source string, errs *ErrorList) HandoffDefinition {
+	if n.Kind == ScalarNode {
+		if n.IsNull() {
+			errs.add(source, n, "%s: handoff must name an agent", what)
+		}
+		return HandoffDefinition{Agent: n.Value}
+	}
+	o := newObject(n, what+": handoff", source, errs)
+	handoff := HandoffDefinition{Agent: o.required("agent"), Description: o.str("description")}
+	o.finish()
+	return handoff
+}
+
+// parseResponseSchema parses a structured output schema.
+func parseResponseSchema(n *Node, what, source string, errs *ErrorList) *llm.JSONSchema {
+	o := newObject(n, what+": response_schema", source, errs)
+	schema := &llm.JSONSchema{
+		Name:        o.required("name"),
+		Description: o.str("description"),
+		Schema:      o.mapping("schema"),
+	}
+	if strict, ok := o.boolean("strict"); ok {
+		schema.Strict = strict
+	}
+	if schema.Schema == nil {
+		o.errorf(nil, "%s: schema is required", o.what)
+	}
+	o.finish()
+	return schema
+}
+
+// positive returns an integer field that must be greater than zero.
+func positive(o *object, key string) int {
+	v, ok := o.integer(key)
+	if ok && v <= 0 {
+		o.errorf(o.get(key), "%s: %s must be greater than 0", o.what, key)
+	}
+	return v
+}
+
+// BuildAgents builds agents from validated definitions, resolving clients,
+// tools, prompt templates and handoffs. Agents are returned by name.
+func (l *Loader) BuildAgents(defs []*AgentDefinition) (map[string]agent.Agent, error) {
+	var errs ErrorList
+	agents := l.buildAgents(defs, &errs)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+func (l *Loader) buildAgents(defs []*AgentDefinition, errs *ErrorList) map[string]agent.Agent {
+	agents := make(map[string]agent.Agent, len(defs))
+	toolAgents := make(map[string]*agent.ToolAgent)
+
+	for _, def := range defs {
+		built := l.buildAgent(def, errs)
+		if built == nil {
+			continue
+		}
+		agents[def.Name] = built
+		if ta, ok := built.(*agent.ToolAgent); ok {
+			toolAgents[def.Name] = ta
+		}
+	}
+
+	// Handoffs are wired once every agent exists, so agents may hand off to
+	// each other regardless of their order in the file.
+	for _, def := range defs {
+		ta, ok := toolAgents[def.Name]
+		if !ok {
+			continue
+		}
+		for i, handoff := range def.Handoffs {
+			target, ok := agents[handoff.Agent]
+			if !ok {
+				errs.add(def.Source, def.field("handoffs", i), "agent %s: handoff to unknown agent %q", def.Name, handoff.Agent)
+				continue
+			}
+			ta.WithHandoff(target, handoff.Description)
+		}
+	}
+	return agents
+}
+
+// buildAgent builds a single agent, or returns nil after recording errors.
+func (l *Loader) buildAgent(def *AgentDefinition, errs *ErrorList) agent.Agent {
+	failed := len(*errs)
+
+	client := l.client
+	if def.Client != "" {
+		named, ok := l.clients[def.Client]
+		if !ok {
+			errs.add(def.Source, def.field("client", -1), "agent %s: unknown client %q", def.Name, def.Client)
+		}
+		client = named
+	} else if client == nil {
+		errs.add(def.Source, def.node, "agent %s: no LLM client configured", def.Name)
+	}
+
+	var tmpl *prompt.Template
+	if def.PromptTemplate != "" {
+		if l.prompts == nil {
+			errs.add(def.Source, def.field("prompt_template", -1), "agent %s: prompt_template requires a prompt registry", def.Name)
+		} else if t, err := l.prompts.Get(def.PromptTemplate); err != nil {
+			errs.add(def.Source, def.field("prompt_template", -1), "agent %s: %v", def.Name, err)
+		} else {
+			tmpl = t
+		}
+	}
+
+	var agentTools []tools.Tool
+	for i, name := range def.Tools {
+		var tool tools.Tool
+		var ok bool
+		if l.tools != nil {
+			tool, ok = l.tools.Get(name)
+		}
+		if !ok {
+			errs.add(def.Source, def.field("tools", i), "agent %s: unknown tool %q", def.Name, name)
+			continue
+		}
+		agentTools = append(agentTools, tool)
+	}
+
+	if len(*errs) > failed {
+		return nil
+	}
+
+	switch def.Type {
+	case agent.TypeTool:
+		ta := agent.NewToolAgent(def.Name).
+			WithClient(client).
+			WithModel(def.Model).
+			WithPrompt(def.Prompt).
+			WithTools(agentTools...)
+		if tmpl != nil {
+			ta.WithPromptTemplate(tmpl).WithPromptInputs(def.PromptInputs)
+		}
+		if def.ToolCallingMode != "" {
+			ta.WithToolCallingMode(def.ToolCallingMode)
+		}
+		if def.ResponseSchema != nil {
+			ta.WithJSONSchema(def.ResponseSchema)
+		}
+		if def.ResponseType != "" {
+			ta.WithResponseType(def.ResponseType)
+		}
+		if def.Temperature != nil {
+			ta.WithTemperature(*def.Temperature)
+		}
+		if def.TopP != nil {
+			ta.WithTopP(*def.TopP)
+		}
+		if def.MaxTokens > 0 {
+			ta.WithMaxTokens(def.MaxTokens)
+		}
+		if def.MaxToolCalls > 0 {
+			ta.WithMaxToolCalls(def.MaxToolCalls)
+		}
+		if def.MaxHandoffs > 0 {
+			ta.WithMaxHandoffs(def.MaxHandoffs)
+		}
+		return ta
+	default:
+		ca := agent.NewChatAgent(def.Name).
+			WithClient(client).
+			WithModel(def.Model).
+			WithPrompt(def.Prompt)
+		if tmpl != nil {
+			ca.WithPromptTemplate(tmpl).WithPromptInputs(def.PromptInputs)
+		}
+		if def.ResponseSchema != nil {
+			ca.WithJSONSchema(def.ResponseSchema)
+		}
+		if def.ResponseType != "" {
+			ca.WithResponseType(def.ResponseType)
+		}
+		if def.Temperature != nil {
+			ca.WithTemperature(*def.Temperature)
+		}
+		if def.TopP != nil {
+			ca.WithTopP(*def.TopP)
+		}
+		if def.MaxTokens > 0 {
+			ca.WithMaxTokens(def.MaxTokens)
+		}
+		return ca
+	}
+}
+
+// field returns the node of a field, or of a list item when index >= 0, so
+// that build errors point at the right line.
+func (def *AgentDefinition) field(key string, index int) *Node {
+	n := def.node.Get(key)
+	if n == nil {
+		return def.node
+	}
+	if index >= 0 && index < len(n.Items) {
+		return n.Items[index]
+	}
+	return n
+}
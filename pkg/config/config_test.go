@@ -0,0 +1,281 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/agent"
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/prompt"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// recordingClient records requests and replies with a fixed answer.
+type recordingClient struct {
+	requests []llm.CompletionRequest
+}
+
+func (c *recordingClient) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	c.requests = append(c.requests, req)
+	return &llm.CompletionResponse{Content: `{"answer": "ok"}`}, nil
+}
+
+func (c *recordingClient) Close() error { return nil }
+
+// echoTool is a simple tool for registry lookups.
+type echoTool struct{ name string }
+
+func (t echoTool) Name() string        { return t.name }
+func (t echoTool) Description() string { return "Echoes its input" }
+func (t echoTool) Parameters() tools.Schema {
+	return tools.Schema{Type: "object"}
+}
+func (t echoTool) Execute(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return params, nil
+}
+
+func TestParseYAML(t *testing.T) {
+	src := `# Agents
+name: demo   # trailing comment
+count: 3
+ratio: 0.5
+enabled: true
+empty:
+quoted: "a: \"b\" # not a comment"
+single: 'it''s'
+list:
+  - one
+  - two: 2
+    three: [x, "y, z"]
+flow: {a: 1, b: [true, null]}
+same_indent:
+- a
+- b
+literal: |
+  line one
+    indented
+
+  line three
+folded: >-
+  folded
+  text
+plain: hello
+  world
+base: &base {model: small, temperature: 0.2}
+merged:
+  <<: *base
+  model: large
+alias: *base
+tail: end
+`
+	root, err := ParseYAML([]byte(src), "demo.yaml")
+	if err != nil {
+		t.Fatalf("ParseYAML failed: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"name":    "demo",
+		"count":   3,
+		"ratio":   0.5,
+		"enabled": true,
+		"empty":   nil,
+		"quoted":  `a: "b" # not a comment`,
+		"single":  "it's",
+		"list": []interface{}{
+			"one",
+			map[string]interface{}{"two": 2, "three": []interface{}{"x", "y, z"}},
+		},
+		"flow":        map[string]interface{}{"a": 1, "b": []interface{}{true, nil}},
+		"same_indent": []interface{}{"a", "b"},
+		"literal":     "line one\n  indented\n\nline three\n",
+		"folded":      "folded text",
+		"plain":       "hello world",
+		"base":        map[string]interface{}{"model": "small", "temperature": 0.2},
+		"merged":      map[string]interface{}{"model": "large", "temperature": 0.2},
+		"alias":       map[string]interface{}{"model": "small", "temperature": 0.2},
+		"tail":        "end",
+	}
+	if got := root.Interface(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected result:\n got %#v\nwant %#v", got, want)
+	}
+
+	if n := root.Get("list").Items[1].Get("three"); n.Line != 12 {
+		t.Errorf("Expected line 12 for nested key, got %d", n.Line)
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+	}{
+		{"bad indentation", "a: 1\n   b: 2\n", 2},
+		{"duplicate key", "a: 1\nb: 2\na: 3\n", 3},
+		{"unterminated quote", "a: 1\nb: \"open\n", 2},
+		{"unterminated flow", "a: [1, 2\n", 1},
+		{"nested mapping value", "a: 1\nkey: value: more\n", 2},
+		{"tab indentation", "a:\n\tb: 1\n", 2},
+		{"multiple documents", "a: 1\n---\nb: 2\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML([]byte(tt.src), "bad.yaml")
+			var cfgErr *Error
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("Expected *Error, got %v", err)
+			}
+			if cfgErr.Line != tt.line {
+				t.Errorf("Expected error on line %d, got %v", tt.line, err)
+			}
+		})
+	}
+
+	for _, src := range []string{"key: value: more\n", "a: 1\nb: *ref\n"} {
+		if _, err := ParseYAML([]byte(src), "bad.yaml"); err == nil {
+			t.Errorf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestParseJSON_Positions(t *testing.T) {
+	src := "{\n  \"agents\": [\n    {\"name\": \"a\", \"temperature\": 0.2}\n  ]\n}"
+	root, err := ParseJSON([]byte(src), "agents.json")
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+	item := root.Get("agents").Items[0]
+	if item.Line != 3 || item.Get("temperature").Interface() != 0.2 || item.Get("name").Interface() != "a" {
+		t.Errorf("Unexpected node: line %d, %v", item.Line, item.Interface())
+	}
+
+	_, err = ParseJSON([]byte("{\n  \"a\": 1,\n  \"a\": 2\n}"), "dup.json")
+	if err == nil || !strings.Contains(err.Error(), "dup.json:3:") {
+		t.Errorf("Expected duplicate key error on line 3, got %v", err)
+	}
+}
+
+func TestLoader_LoadAgentsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agents.yaml")
+	src := `agents:
+  - name: support
+    type: tool
+    model: gpt-4o
+    prompt_template: support@2
+    prompt_inputs:
+      company: Acme
+    tools: [lookup_order]
+    handoffs:
+      - agent: billing
+        description: Billing questions
+    temperature: 0.1
+    max_tool_calls: 3
+
+  - name: billing
+    client: cheap
+    model: small
+    prompt: You handle billing.
+    response_schema:
+      name: answer
+      strict: true
+      schema:
+        type: object
+        properties:
+          answer: {type: string}
+    max_tokens: 200
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := tools.NewDefaultToolRegistry()
+	_ = registry.Register(echoTool{name: "lookup_order"})
+	prompts := prompt.NewRegistry()
+	_ = prompts.Register(prompt.MustNew("support", "You support {{.company}} customers.").WithVersion("2"))
+
+	main, cheap := &recordingClient{}, &recordingClient{}
+	agents, err := NewLoader(main, registry).
+		WithClient("cheap", cheap).
+		WithPrompts(prompts).
+		LoadAgentsFile(path)
+	if err != nil {
+		t.Fatalf("LoadAgentsFile failed: %v", err)
+	}
+
+	support, ok := agents["support"].(*agent.ToolAgent)
+	if !ok {
+		t.Fatalf("Expected support to be a ToolAgent, got %T", agents["support"])
+	}
+	if len(support.Tools()) != 1 || len(support.Handoffs()) != 1 || support.Handoffs()[0].Description != "Billing questions" {
+		t.Errorf("Unexpected tools or handoffs: %v, %v", support.Tools(), support.Handoffs())
+	}
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Where is my order?")
+	if report := support.Run(ctx); report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected completed run, got %v", report.Errors)
+	}
+	req := main.requests[0]
+	if req.Model != "gpt-4o" || req.Temperature != 0.1 || req.Messages[0].Content != "You support Acme customers." {
+		t.Errorf("Unexpected request: model %s, temperature %v, prompt %q", req.Model, req.Temperature, req.Messages[0].Content)
+	}
+
+	ctx = workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "Refund?")
+	agents["billing"].Run(ctx)
+	if len(cheap.requests) != 1 {
+		t.Fatalf("Expected billing to use the named client")
+	}
+	req = cheap.requests[0]
+	if req.ResponseType != llm.ResponseTypeJSONSchema || req.JSONSchema == nil || !req.JSONSchema.Strict || req.MaxTokens != 200 {
+		t.Errorf("Unexpected structured output settings: %+v", req)
+	}
+}
+
+func TestLoader_ValidationErrors(t *testing.T) {
+	src := `agents:
+  - name: support
+    type: tool
+    model: gpt-4o
+    tools: [lookup_order, missing_tool]
+    temperature: 3
+    handoffs: [nobody]
+    colour: blue
+  - name: support
+    type: chat
+    max_tool_calls: 2
+`
+	loader := NewLoader(&recordingClient{}, tools.NewDefaultToolRegistry())
+	_, err := loader.LoadAgents([]byte(src), FormatYAML, "agents.yaml")
+
+	var errs ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ErrorList, got %v", err)
+	}
+	for _, want := range []string{
+		"agents.yaml:6: agent support: temperature must be between 0 and 2",
+		"agents.yaml:8: agent support: unknown field \"colour\"",
+		"agents.yaml:9: duplicate agent name \"support\"",
+		"agents.yaml:11: agent support: max_tool_calls requires type tool",
+		"agents.yaml:7: agent support: handoff to unknown agent \"nobody\"",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q in:\n%v", want, err)
+		}
+	}
+
+	// Tool references are resolved when building.
+	src = "agents:\n  - name: support\n    type: tool\n    tools:\n      - lookup_order\n"
+	_, err = loader.LoadAgents([]byte(src), FormatYAML, "agents.yaml")
+	if err == nil || !strings.Contains(err.Error(), `agents.yaml:5: agent support: unknown tool "lookup_order"`) {
+		t.Errorf("Expected unknown tool error on line 5, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// object reads the fields of a mapping node, recording type errors, and
+// reports fields that were never read as unknown.
+type object struct {
+	node   *Node
+	what   string
+	source string
+	errs   *ErrorList
+	read   map[string]bool
+}
+
+// newObject starts decoding a mapping node. A node of another kind is
+// reported once and decodes as an empty object.
+func newObject(n *Node, what, source string, errs *ErrorList) *object {
+	o := &object{what: what, source: source, errs: errs, read: make(map[string]bool)}
+	if n != nil && n.Kind != MappingNode {
+		errs.add(source, n, "%s must be a mapping, got %s", what, n.Kind)
+		return o
+	}
+	o.node = n
+	return o
+}
+
+// get returns the node for a field, or nil when it is missing or null.
+func (o *object) get(key string) *Node {
+	o.read[key] = true
+	n := o.node.Get(key)
+	if n.IsNull() {
+		return nil
+	}
+	return n
+}
+
+// errorf records an error at a node, or at the object when the node is nil.
+func (o *object) errorf(n *Node, format string, args ...interface{}) {
+	if n == nil {
+		n = o.node
+	}
+	o.errs.add(o.source, n, format, args...)
+}
+
+// scalar returns the scalar node for a field.
+func (o *object) scalar(key string) *Node {
+	n := o.get(key)
+	if n != nil && n.Kind != ScalarNode {
+		o.errorf(n, "%s: %s must be a scalar, got %s", o.what, key, n.Kind)
+		return nil
+	}
+	return n
+}
+
+// str returns a string field.
+func (o *object) str(key string) string {
+	if n := o.scalar(key); n != nil {
+		return n.Value
+	}
+	return ""
+}
+
+// required returns a string field that must be present.
+func (o *object) required(key string) string {
+	value := o.str(key)
+	if value == "" {
+		o.errorf(o.node.Get(key), "%s: %s is required", o.what, key)
+	}
+	return value
+}
+
+// float returns a numeric field.
+func (o *object) float(key string) (float64, bool) {
+	n := o.scalar(key)
+	if n == nil {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(n.Value, 64)
+	if err != nil || n.Quoted {
+		o.errorf(n, "%s: %s must be a number, got %q", o.what, key, n.Value)
+		return 0, false
+	}
+	return f, true
+}
+
+// integer returns an integer field.
+func (o *object) integer(key string) (int, bool) {
+	n := o.scalar(key)
+	if n == nil {
+		return 0, false
+	}
+	i, err := strconv.Atoi(n.Value)
+	if err != nil || n.Quoted {
+		o.errorf(n, "%s: %s must be an integer, got %q", o.what, key, n.Value)
+		return 0, false
+	}
+	return i, true
+}
+
+// boolean returns a boolean field.
+func (o *object) boolean(key string) (bool, bool) {
+	n := o.scalar(key)
+	if n == nil {
+		return false, false
+	}
+	if b, ok := n.Interface().(bool); ok {
+		return b, true
+	}
+	o.errorf(n, "%s: %s must be true or false, got %q", o.what, key, n.Value)
+	return false, false
+}
+
+// oneOf returns a string field that must be one of the allowed values.
+func (o *object) oneOf(key string, allowed ...string) string {
+	n := o.scalar(key)
+	if n == nil {
+		return ""
+	}
+	for _, a := range allowed {
+		if n.Value == a {
+			return a
+		}
+	}
+	o.errorf(n, "%s: %s must be one of %s, got %q", o.what, key, strings.Join(allowed, ", "), n.Value)
+	return ""
+}
+
+// sequence returns the items of a sequence field.
+func (o *object) sequence(key string) []*Node {
+	n := o.get(key)
+	if n == nil {
+		return nil
+	}
+	if n.Kind != SequenceNode {
+		o.errorf(n, "%s: %s must be a list, got %s", o.what, key, n.Kind)
+		return nil
+	}
+	return n.Items
+}
+
+// mapping returns a mapping field as plain values.
+func (o *object) mapping(key string) map[string]interface{} {
+	n := o.get(key)
+	if n == nil {
+		return nil
+	}
+	if n.Kind != MappingNode {
+		o.errorf(n, "%s: %s must be a mapping, got %s", o.what, key, n.Kind)
+		return nil
+	}
+	return n.Interface().(map[string]interface{})
+}
+
+// finish reports fields that were not read.
+func (o *object) finish() {
+	if o.node == nil {
+		return
+	}
+	for _, pair := range o.node.Pairs {
+		if !o.read[pair.Key.Value] {
+			o.errs.add(o.source, pair.Key, "%s: unknown field %q (known fields: %s)", o.what, pair.Key.Value, strings.Join(o.fields(), ", "))
+		}
+	}
+}
+
+// fields returns the sorted names of the fields that were read.
+func (o *object) fields() []string {
+	fields := make([]string, 0, len(o.read))
+	for field := range o.read {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
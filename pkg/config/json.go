@@ -0,0 +1,33 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ParseJSON parses JSON data into a Node tree. The source name is used in errors.
+func ParseJSON(data []byte, source string) (*Node, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			line, column := position(data, int(syntax.Offset))
+			return nil, errorAt(source, line, column, "invalid JSON: %v", err)
+		}
+		return nil, errorAt(source, 0, 0, "invalid JSON: %v", err)
+	}
+	// JSON is a subset of YAML, and the YAML nodes keep the positions of the values
+	return ParseYAML(data, source)
+}
+
+// position converts a byte offset in data to a line and column.
+func position(data []byte, offset int) (int, int) {
+	line, start := 1, 0
+	for i := 0; i < offset && i < len(data); i++ {
+		if data[i] == '\n' {
+			line++
+			start = i + 1
+		}
+	}
+	return line, offset - start + 1
+}
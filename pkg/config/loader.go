@@ -0,0 +1,172 @@
+package config
+
+import (
+	"github.com/ratlabs-io/go-agent-kit/pkg/agent"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/prompt"
+	"github.com/ratlabs-io/go-agent-kit/pkg/tools"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// Document is a validated configuration document with agent and workflow
+// definitions.
+type Document struct {
+	Source    string
+	Agents    []*AgentDefinition
+	Workflows []*StepDefinition
+}
+
+// ParseDocument validates a parsed configuration document, a mapping with
+// optional "agents" and "workflows" lists. All problems are reported
+// together as an ErrorList with line numbers.
+func ParseDocument(root *Node, source string) (*Document, error) {
+	var errs ErrorList
+	o := newObject(root, "document", source, &errs)
+	doc := &Document{
+		Source:    source,
+		Agents:    parseAgentList(o, source, &errs),
+		Workflows: parseWorkflowList(o, source, &errs),
+	}
+	o.finish()
+
+	agents := make(map[string]bool, len(doc.Agents))
+	for _, def := range doc.Agents {
+		agents[def.Name] = true
+	}
+	for _, def := range doc.Workflows {
+		if agents[def.Name] {
+			errs.add(source, def.node, "workflow %s: an agent with the same name is already defined", def.Name)
+		}
+	}
+
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Bundle holds the agents and workflows built from a configuration document.
+type Bundle struct {
+	Agents    map[string]agent.Agent
+	Workflows map[string]workflow.Action
+}
+
+// Loader builds ready-to-run agents and workflows from declarative definitions.
+type Loader struct {
+	client  llm.Client
+	clients map[string]llm.Client
+	tools   tools.ToolRegistry
+	prompts *prompt.Registry
+	actions *ActionRegistry
+}
+
+// NewLoader creates a loader that builds agents with the given LLM client and
+// resolves tool names against the registry.
+func NewLoader(client llm.Client, registry tools.ToolRegistry) *Loader {
+	return &Loader{
+		client:  client,
+		clients: make(map[string]llm.Client),
+		tools:   registry,
+	}
+}
+
+// WithClient registers an additional client that definitions select with
+// the client field.
+func (l *Loader) WithClient(name string, client llm.Client) *Loader {
+	l.clients[name] = client
+	return l
+}
+
+// WithPrompts sets the registry used to resolve prompt_template references.
+func (l *Loader) WithPrompts(registry *prompt.Registry) *Loader {
+	l.prompts = registry
+	return l
+}
+
+// WithActions sets the registry used to resolve action and predicate names
+// in workflow definitions.
+func (l *Loader) WithActions(registry *ActionRegistry) *Loader {
+	l.actions = registry
+	return l
+}
+
+// LoadFile loads the agents and workflows defined in a YAML or JSON file.
+func (l *Loader) LoadFile(path string) (*Bundle, error) {
+	root, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return l.load(root, path)
+}
+
+// Load loads the agents and workflows defined in YAML or JSON data. The
+// source name is used in errors.
+func (l *Loader) Load(data []byte, format Format, source string) (*Bundle, error) {
+	root, err := Parse(data, format, source)
+	if err != nil {
+		return nil, err
+	}
+	return l.load(root, source)
+}
+
+func (l *Loader) load(root *Node, source string) (*Bundle, error) {
+	doc, err := ParseDocument(root, source)
+	if err != nil {
+		return nil, err
+	}
+	return l.Build(doc)
+}
+
+// LoadAgentsFile loads the agents defined in a YAML or JSON file.
+func (l *Loader) LoadAgentsFile(path string) (map[string]agent.Agent, error) {
+	bundle, err := l.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Agents, nil
+}
+
+// LoadAgents loads the agents defined in YAML or JSON data.
+func (l *Loader) LoadAgents(data []byte, format Format, source string) (map[string]agent.Agent, error) {
+	bundle, err := l.Load(data, format, source)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Agents, nil
+}
+
+// LoadWorkflowsFile loads the workflows defined in a YAML or JSON file.
+// Agents defined in the same file are built so workflows can reference them.
+func (l *Loader) LoadWorkflowsFile(path string) (map[string]workflow.Action, error) {
+	bundle, err := l.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Workflows, nil
+}
+
+// Build builds the agents and workflows of a validated document.
+func (l *Loader) Build(doc *Document) (*Bundle, error) {
+	var errs ErrorList
+	agents := l.buildAgents(doc.Agents, &errs)
+
+	b := &workflowBuilder{
+		registry: l.actions,
+		agents:   agents,
+		defs:     make(map[string]*StepDefinition, len(doc.Workflows)),
+		built:    make(map[string]workflow.Action, len(doc.Workflows)),
+		state:    make(map[string]buildState),
+		errs:     &errs,
+	}
+	for _, def := range doc.Workflows {
+		b.defs[def.Name] = def
+	}
+	for _, def := range doc.Workflows {
+		b.build(def)
+	}
+
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return &Bundle{Agents: agents, Workflows: b.built}, nil
+}
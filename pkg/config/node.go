@@ -0,0 +1,224 @@
+// Package config loads declarative agent and workflow definitions from YAML
+// or JSON files. Both formats are decoded with gopkg.in/yaml.v3 into a Node
+// tree that keeps line numbers, so validation errors point at the offending
+// line. Aliases and merge keys are expanded; multiple documents in one file
+// are not supported.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a Node.
+type Kind int
+
+const (
+	// ScalarNode is a single value.
+	ScalarNode Kind = iota + 1
+
+	// MappingNode is an ordered set of key/value pairs.
+	MappingNode
+
+	// SequenceNode is a list of items.
+	SequenceNode
+)
+
+// String returns the name of the kind, for error messages.
+func (k Kind) String() string {
+	switch k {
+	case ScalarNode:
+		return "scalar"
+	case MappingNode:
+		return "mapping"
+	case SequenceNode:
+		return "sequence"
+	}
+	return "unknown"
+}
+
+// Pair is a key/value pair of a mapping node.
+type Pair struct {
+	Key   *Node
+	Value *Node
+}
+
+// Node is a parsed YAML or JSON value with its position in the source.
+type Node struct {
+	Kind   Kind
+	Line   int
+	Column int
+	Value  string  // Scalar value
+	Quoted bool    // Quoted scalars are always strings
+	Pairs  []Pair  // Mapping entries in source order
+	Items  []*Node // Sequence items
+}
+
+// Get returns the value for a key of a mapping node, or nil.
+func (n *Node) Get(key string) *Node {
+	if n == nil || n.Kind != MappingNode {
+		return nil
+	}
+	for _, pair := range n.Pairs {
+		if pair.Key.Value == key {
+			return pair.Value
+		}
+	}
+	return nil
+}
+
+// IsNull reports whether the node is missing or a null scalar.
+func (n *Node) IsNull() bool {
+	if n == nil {
+		return true
+	}
+	if n.Kind != ScalarNode || n.Quoted {
+		return false
+	}
+	switch n.Value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// Interface converts the node to plain Go values: map[string]interface{},
+// []interface{}, string, bool, int, float64 or nil.
+func (n *Node) Interface() interface{} {
+	if n == nil {
+		return nil
+	}
+	switch n.Kind {
+	case MappingNode:
+		m := make(map[string]interface{}, len(n.Pairs))
+		for _, pair := range n.Pairs {
+			m[pair.Key.Value] = pair.Value.Interface()
+		}
+		return m
+	case SequenceNode:
+		items := make([]interface{}, 0, len(n.Items))
+		for _, item := range n.Items {
+			items = append(items, item.Interface())
+		}
+		return items
+	}
+
+	if n.Quoted {
+		return n.Value
+	}
+	if n.IsNull() {
+		return nil
+	}
+	switch n.Value {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.Atoi(n.Value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(n.Value, 64); err == nil {
+		return f
+	}
+	return n.Value
+}
+
+// Format is a configuration file format.
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// FormatForFile returns the format for a file name based on its extension.
+func FormatForFile(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unsupported configuration file type: %s", path)
+}
+
+// Parse parses YAML or JSON data. The source name is used in errors.
+func Parse(data []byte, format Format, source string) (*Node, error) {
+	switch format {
+	case FormatYAML:
+		return ParseYAML(data, source)
+	case FormatJSON:
+		return ParseJSON(data, source)
+	}
+	return nil, fmt.Errorf("unsupported configuration format: %s", format)
+}
+
+// ParseFile reads and parses a YAML or JSON file.
+func ParseFile(path string) (*Node, error) {
+	format, err := FormatForFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	return Parse(data, format, path)
+}
+
+// Error is a configuration error at a position in a source file.
+type Error struct {
+	Source  string
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	var prefix string
+	switch {
+	case e.Source != "" && e.Line > 0:
+		prefix = fmt.Sprintf("%s:%d: ", e.Source, e.Line)
+	case e.Line > 0:
+		prefix = fmt.Sprintf("line %d: ", e.Line)
+	case e.Source != "":
+		prefix = e.Source + ": "
+	}
+	return prefix + e.Message
+}
+
+// ErrorList collects configuration errors so that all problems in a file are
+// reported at once.
+type ErrorList []*Error
+
+// Error implements the error interface.
+func (l ErrorList) Error() string {
+	messages := make([]string, 0, len(l))
+	for _, e := range l {
+		messages = append(messages, e.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// add records an error at the position of a node.
+func (l *ErrorList) add(source string, n *Node, format string, args ...interface{}) {
+	e := &Error{Source: source, Message: fmt.Sprintf(format, args...)}
+	if n != nil {
+		e.Line = n.Line
+		e.Column = n.Column
+	}
+	*l = append(*l, e)
+}
+
+// err returns the list as an error, or nil when it is empty.
+func (l ErrorList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
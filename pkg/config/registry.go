@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// ActionRegistry resolves the names used in workflow definitions to actions
+// and predicates implemented in Go. Agents and workflows defined in the same
+// document are resolved automatically and need not be registered.
+type ActionRegistry struct {
+	mu         sync.RWMutex
+	actions    map[string]workflow.Action
+	predicates map[string]workflow.Predicate
+}
+
+// NewActionRegistry creates an empty action registry.
+func NewActionRegistry() *ActionRegistry {
+	return &ActionRegistry{
+		actions:    make(map[string]workflow.Action),
+		predicates: make(map[string]workflow.Predicate),
+	}
+}
+
+// Register adds an action, such as an agent or a workflow built in code,
+// under a name.
+func (r *ActionRegistry) Register(name string, action workflow.Action) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.actions[name]; exists {
+		return fmt.Errorf("action %s is already registered", name)
+	}
+	r.actions[name] = action
+	return nil
+}
+
+// RegisterFunc adds a function as a named action.
+func (r *ActionRegistry) RegisterFunc(name string, fn func(workflow.WorkContext) workflow.WorkReport) error {
+	return r.Register(name, workflow.NewActionFunc(name, fn))
+}
+
+// RegisterPredicate adds a named predicate. Conditions in workflow
+// definitions that match a predicate name use the predicate instead of being
+// parsed as an expression.
+func (r *ActionRegistry) RegisterPredicate(name string, predicate workflow.Predicate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.predicates[name]; exists {
+		return fmt.Errorf("predicate %s is already registered", name)
+	}
+	r.predicates[name] = predicate
+	return nil
+}
+
+// Action returns the action registered under a name.
+func (r *ActionRegistry) Action(name string) (workflow.Action, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	action, ok := r.actions[name]
+	return action, ok
+}
+
+// Predicate returns the predicate registered under a name.
+func (r *ActionRegistry) Predicate(name string) (workflow.Predicate, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	predicate, ok := r.predicates[name]
+	return predicate, ok
+}
@@ -0,0 +1,677 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/agent"
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// Step types of a workflow definition.
+const (
+	StepAction         = "action"
+	StepSequential     = "sequential"
+	StepParallel       = "parallel"
+	StepSwitch         = "switch"
+	StepConditional    = "conditional"
+	StepLoop           = "loop"
+	StepRetry          = "retry"
+	StepTry            = "try"
+	StepTimeout        = "timeout"
+	StepCircuitBreaker = "circuit_breaker"
+)
+
+// StepDefinition is a node of a declarative workflow. A step is either the
+// name of an action (an agent, a workflow or a registered action) or a
+// mapping with a type and the fields of that type:
+//
+//	workflows:
+//	  - name: support
+//	    type: sequential
+//	    mode: chain                  # then (default), chain or accumulate
+//	    steps:
+//	      - classify
+//	      - type: switch
+//	        cases:
+//	          - when: 'category == "billing"'
+//	            do: billing_agent
+//	          - when: is_urgent          # registered predicate
+//	            do: escalate
+//	        default: general_agent
+//	      - type: parallel
+//	        collect_errors: true
+//	        steps: [notify_crm, notify_slack]
+//	      - type: retry
+//	        max_attempts: 3
+//	        backoff: {type: exponential, delay: 1s, max_delay: 10s, factor: 2}
+//	        retry_on: [timeout, rate_limit]
+//	        do: flaky_lookup
+//	      - type: loop
+//	        over: order.items           # or count, while, until
+//	        do: check_item
+//	      - type: conditional
+//	        if: score < 0.5
+//	        then: review
+//	        else: publish
+//	      - type: try
+//	        do: {type: timeout, timeout: 30s, do: slow_agent}
+//	        catch:
+//	          - error: timeout           # any, timeout, network or validation
+//	            do: fallback
+//	          - message_contains: quota
+//	            do: wait_and_retry
+//	        finally: cleanup
+//	      - type: circuit_breaker
+//	        failure_threshold: 3
+//	        recovery_timeout: 30s
+//	        reset_timeout: 1m
+//	        do: external_api
+//
+// Conditions are registered predicate names or expressions (see
+// workflow.ParseExpression).
+type StepDefinition struct {
+	Type   string
+	Name   string
+	Action string // action: name of the action to run
+	Mode   string // sequential: then, chain or accumulate
+
+	Steps         []*StepDefinition // sequential, parallel
+	CollectErrors bool              // parallel: run every step and collect all errors
+	Cases         []CaseDefinition  // switch
+	Default       *StepDefinition   // switch
+	If            string            // conditional
+	Then, Else    *StepDefinition   // conditional
+	Do            *StepDefinition   // loop, retry, try, timeout, circuit_breaker
+
+	Count        int    // loop: fixed number of iterations
+	While, Until string // loop: conditions
+	Over         string // loop: expression yielding the items to iterate
+
+	MaxAttempts int                // retry
+	Backoff     *BackoffDefinition // retry
+	RetryOn     []string           // retry: any, timeout, rate_limit, network
+	StopWhen    string             // retry: condition that stops further attempts
+
+	Catch    []CatchDefinition // try
+	CatchAny *StepDefinition   // try
+	Finally  *StepDefinition   // try
+
+	Timeout          time.Duration // timeout
+	FailureThreshold int           // circuit_breaker
+	RecoveryTimeout  time.Duration // circuit_breaker
+	ResetTimeout     time.Duration // circuit_breaker
+
+	// Source and node locate the definition for errors found while building.
+	Source string
+	node   *Node
+}
+
+// CaseDefinition is a condition and the step it selects in a switch.
+type CaseDefinition struct {
+	When string
+	Do   *StepDefinition
+	node *Node
+}
+
+// BackoffDefinition configures the delay between retry attempts.
+type BackoffDefinition struct {
+	Type      string // fixed, linear or exponential
+	Delay     time.Duration
+	Increment time.Duration // linear
+	MaxDelay  time.Duration // exponential
+	Factor    float64       // exponential
+}
+
+// CatchDefinition handles errors matching a condition in a try step. The
+// caught error is available to the handler under constants.KeyCaughtError.
+type CatchDefinition struct {
+	Error           string // any, timeout, network or validation
+	MessageContains string
+	Do              *StepDefinition
+}
+
+// stepTypes lists the valid values of the type field.
+var stepTypes = []string{StepAction, StepSequential, StepParallel, StepSwitch, StepConditional, StepLoop, StepRetry, StepTry, StepTimeout, StepCircuitBreaker}
+
+// errorMatchers maps the error field of catch blocks to matchers.
+var errorMatchers = map[string]workflow.ErrorTypeMatcherFunc{
+	"any":        workflow.AnyError,
+	"timeout":    workflow.TimeoutError,
+	"network":    workflow.NetworkError,
+	"validation": workflow.ValidationError,
+}
+
+// retryConditions maps the retry_on values to retry conditions.
+var retryConditions = map[string]workflow.RetryConditionFunc{
+	"any":        workflow.DefaultRetryCondition,
+	"timeout":    workflow.RetryOnTimeoutCondition,
+	"rate_limit": workflow.RetryOnRateLimitCondition,
+	"network":    workflow.RetryOnNetworkCondition,
+}
+
+// parseWorkflowList parses the "workflows" field of a document.
+func parseWorkflowList(doc *object, source string, errs *ErrorList) []*StepDefinition {
+	var defs []*StepDefinition
+	seen := make(map[string]bool)
+	for _, item := range doc.sequence("workflows") {
+		if item.Kind != MappingNode {
+			errs.add(source, item, "workflow must be a mapping with a name and a type")
+			continue
+		}
+		def := parseStep(item, "workflow", source, errs)
+		if def.Name == "" {
+			errs.add(source, item, "workflow: name is required")
+			continue
+		}
+		if seen[def.Name] {
+			errs.add(source, item, "duplicate workflow name %q", def.Name)
+		}
+		seen[def.Name] = true
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// parseStep parses and validates a step and its children.
+func parseStep(n *Node, what, source string, errs *ErrorList) *StepDefinition {
+	step := &StepDefinition{Source: source, node: n}
+	if n.Kind == ScalarNode {
+		if n.IsNull() {
+			errs.add(source, n, "%s: step must name an action", what)
+		}
+		step.Type, step.Action = StepAction, n.Value
+		return step
+	}
+
+	o := newObject(n, what, source, errs)
+	step.Name = o.str("name")
+	if step.Name != "" {
+		what = fmt.Sprintf("%s %s", what, step.Name)
+		o.what = what
+	}
+
+	step.Type = o.oneOf("type", stepTypes...)
+	if step.Type == "" && o.node.Get("type") == nil && o.node.Get("action") != nil {
+		step.Type = StepAction
+	}
+	child := func(key string) *StepDefinition {
+		if c := o.get(key); c != nil {
+			return parseStep(c, what+": "+key, source, errs)
+		}
+		return nil
+	}
+	requiredChild := func(key string) *StepDefinition {
+		c := child(key)
+		if c == nil {
+			o.errorf(nil, "%s: %s is required", what, key)
+		}
+		return c
+	}
+
+	switch step.Type {
+	case StepAction:
+		step.Action = o.required("action")
+	case StepSequential, StepParallel:
+		for i, item := range o.sequence("steps") {
+			step.Steps = append(step.Steps, parseStep(item, fmt.Sprintf("%s: steps[%d]", what, i), source, errs))
+		}
+		if len(step.Steps) == 0 {
+			o.errorf(o.get("steps"), "%s: steps must list at least one step", what)
+		}
+		if step.Type == StepSequential {
+			step.Mode = o.oneOf("mode", "then", "chain", "accumulate")
+		} else if collect, ok := o.boolean("collect_errors"); ok {
+			step.CollectErrors = collect
+		}
+	case StepSwitch:
+		for i, item := range o.sequence("cases") {
+			co := newObject(item, fmt.Sprintf("%s: cases[%d]", what, i), source, errs)
+			c := CaseDefinition{When: co.required("when"), node: co.get("when")}
+			if body := co.get("do"); body != nil {
+				c.Do = parseStep(body, co.what+": do", source, errs)
+			} else {
+				co.errorf(nil, "%s: do is required", co.what)
+			}
+			co.finish()
+			step.Cases = append(step.Cases, c)
+		}
+		if len(step.Cases) == 0 {
+			o.errorf(o.get("cases"), "%s: cases must list at least one case", what)
+		}
+		step.Default = child("default")
+	case StepConditional:
+		step.If = o.required("if")
+		step.Then = requiredChild("then")
+		step.Else = child("else")
+	case StepLoop:
+		if count, ok := o.integer("count"); ok {
+			step.Count = count
+			if count <= 0 {
+				o.errorf(o.get("count"), "%s: count must be greater than 0", what)
+			}
+		}
+		step.While = o.str("while")
+		step.Until = o.str("until")
+		step.Over = o.str("over")
+		modes := 0
+		for _, set := range []bool{step.Count != 0, step.While != "", step.Until != "", step.Over != ""} {
+			if set {
+				modes++
+			}
+		}
+		if modes != 1 {
+			o.errorf(nil, "%s: exactly one of count, while, until or over is required", what)
+		}
+		step.Do = requiredChild("do")
+	case StepRetry:
+		step.MaxAttempts = positive(o, "max_attempts")
+		if step.MaxAttempts == 0 && o.get("max_attempts") == nil {
+			o.errorf(nil, "%s: max_attempts is required", what)
+		}
+		if b := o.get("backoff"); b != nil {
+			step.Backoff = parseBackoff(b, what+": backoff", source, errs)
+		}
+		for _, item := range o.sequence("retry_on") {
+			if _, ok := retryConditions[item.Value]; !ok || item.Kind != ScalarNode {
+				o.errorf(item, "%s: retry_on must list any, timeout, rate_limit or network, got %q", what, item.Value)
+				continue
+			}
+			step.RetryOn = append(step.RetryOn, item.Value)
+		}
+		step.StopWhen = o.str("stop_when")
+		step.Do = requiredChild("do")
+	case StepTry:
+		step.Do = requiredChild("do")
+		for i, item := range o.sequence("catch") {
+			step.Catch = append(step.Catch, parseCatch(item, fmt.Sprintf("%s: catch[%d]", what, i), source, errs))
+		}
+		step.CatchAny = child("catch_any")
+		step.Finally = child("finally")
+	case StepTimeout:
+		step.Timeout = duration(o, "timeout")
+		if step.Timeout <= 0 {
+			o.errorf(o.get("timeout"), "%s: timeout must be a positive duration such as 30s", what)
+		}
+		step.Do = requiredChild("do")
+	case StepCircuitBreaker:
+		step.FailureThreshold = positive(o, "failure_threshold")
+		if step.FailureThreshold == 0 && o.get("failure_threshold") == nil {
+			o.errorf(nil, "%s: failure_threshold is required", what)
+		}
+		step.RecoveryTimeout = duration(o, "recovery_timeout")
+		step.ResetTimeout = duration(o, "reset_timeout")
+		step.Do = requiredChild("do")
+	case "":
+		if o.node == nil {
+			break
+		}
+		if o.node.Get("type") == nil {
+			o.errorf(nil, "%s: type is required (one of %s)", what, strings.Join(stepTypes, ", "))
+		}
+		// Mark the remaining fields as read; they cannot be validated
+		// without a type.
+		for _, pair := range o.node.Pairs {
+			o.read[pair.Key.Value] = true
+		}
+	}
+
+	o.finish()
+	return step
+}
+
+// parseBackoff parses a backoff given as a fixed delay or as a mapping.
+func parseBackoff(n *Node, what, source string, errs *ErrorList) *BackoffDefinition {
+	if n.Kind == ScalarNode {
+		delay, err := time.ParseDuration(n.Value)
+		if err != nil {
+			errs.add(source, n, "%s: must be a duration such as 2s, got %q", what, n.Value)
+		}
+		return &BackoffDefinition{Type: "fixed", Delay: delay}
+	}
+
+	o := newObject(n, what, source, errs)
+	b := &BackoffDefinition{
+		Type:      o.oneOf("type", "fixed", "linear", "exponential"),
+		Delay:     duration(o, "delay"),
+		Increment: duration(o, "increment"),
+		MaxDelay:  duration(o, "max_delay"),
+		Factor:    2,
+	}
+	if b.Type == "" {
+		b.Type = "fixed"
+	}
+	if factor, ok := o.float("factor"); ok {
+		if factor < 1 {
+			o.errorf(o.get("factor"), "%s: factor must be at least 1", what)
+		}
+		b.Factor = factor
+	}
+	if b.Type == "exponential" && b.MaxDelay == 0 {
+		o.errorf(nil, "%s: exponential backoff requires max_delay", what)
+	}
+	o.finish()
+	return b
+}
+
+// parseCatch parses a catch block of a try step.
+func parseCatch(n *Node, what, source string, errs *ErrorList) CatchDefinition {
+	o := newObject(n, what, source, errs)
+	c := CatchDefinition{MessageContains: o.str("message_contains")}
+	if errorType := o.scalar("error"); errorType != nil {
+		if _, ok := errorMatchers[errorType.Value]; !ok {
+			o.errorf(errorType, "%s: error must be any, timeout, network or validation, got %q", what, errorType.Value)
+		}
+		c.Error = errorType.Value
+	}
+	if (c.Error == "") == (c.MessageContains == "") {
+		o.errorf(nil, "%s: exactly one of error or message_contains is required", what)
+	}
+	if body := o.get("do"); body != nil {
+		c.Do = parseStep(body, what+": do", source, errs)
+	} else {
+		o.errorf(nil, "%s: do is required", what)
+	}
+	o.finish()
+	return c
+}
+
+// duration returns a duration field written as a Go duration string.
+func duration(o *object, key string) time.Duration {
+	n := o.scalar(key)
+	if n == nil {
+		return 0
+	}
+	d, err := time.ParseDuration(n.Value)
+	if err != nil {
+		o.errorf(n, "%s: %s must be a duration such as 30s, got %q", o.what, key, n.Value)
+		return 0
+	}
+	return d
+}
+
+// workflowBuilder builds workflow definitions into actions, resolving action
+// names against the registry, the agents of the document and other workflows.
+type workflowBuilder struct {
+	registry *ActionRegistry
+	agents   map[string]agent.Agent
+	defs     map[string]*StepDefinition
+	built    map[string]workflow.Action
+	state    map[string]buildState
+	errs     *ErrorList
+}
+
+// buildState tracks workflows being built to detect reference cycles.
+type buildState int
+
+const (
+	stateBuilding buildState = iota + 1
+	stateDone
+)
+
+// build builds a named workflow, building the workflows it references first.
+func (b *workflowBuilder) build(def *StepDefinition) workflow.Action {
+	switch b.state[def.Name] {
+	case stateBuilding:
+		b.errs.add(def.Source, def.node, "workflow %s references itself", def.Name)
+		return nil
+	case stateDone:
+		return b.built[def.Name]
+	}
+
+	b.state[def.Name] = stateBuilding
+	action := b.step(def, def.Name)
+	b.state[def.Name] = stateDone
+	if action != nil {
+		b.built[def.Name] = action
+	}
+	return action
+}
+
+// resolve returns the action registered or defined under a name.
+func (b *workflowBuilder) resolve(name string, step *StepDefinition) workflow.Action {
+	if b.registry != nil {
+		if action, ok := b.registry.Action(name); ok {
+			return action
+		}
+	}
+	if a, ok := b.agents[name]; ok {
+		return a
+	}
+	if def, ok := b.defs[name]; ok {
+		return b.build(def)
+	}
+	b.errs.add(step.Source, step.node, "unknown action %q", name)
+	return nil
+}
+
+// predicate returns the registered predicate with the given name, or
+// compiles the condition as an expression.
+func (b *workflowBuilder) predicate(condition string, step *StepDefinition, at *Node) workflow.Predicate {
+	if b.registry != nil {
+		if predicate, ok := b.registry.Predicate(condition); ok {
+			return predicate
+		}
+	}
+	predicate, err := workflow.ExpressionPredicate(condition)
+	if err != nil {
+		if at == nil {
+			at = step.node
+		}
+		b.errs.add(step.Source, at, "%v", err)
+		return nil
+	}
+	return predicate
+}
+
+// field returns the node of a step field for errors.
+func (step *StepDefinition) field(key string) *Node {
+	if n := step.node.Get(key); n != nil {
+		return n
+	}
+	return step.node
+}
+
+// step builds a step. Steps without a name are named after their path in
+// the workflow, such as "support.steps[1]".
+func (b *workflowBuilder) step(step *StepDefinition, path string) workflow.Action {
+	if step == nil {
+		return nil
+	}
+	if step.Type == StepAction {
+		return b.resolve(step.Action, step)
+	}
+
+	name := step.Name
+	if name == "" {
+		name = path
+	}
+	failed := len(*b.errs)
+	child := func(c *StepDefinition, suffix string) workflow.Action {
+		if c == nil {
+			return nil
+		}
+		return b.step(c, path+"."+suffix)
+	}
+
+	switch step.Type {
+	case StepSequential:
+		flow := workflow.NewSequentialFlow(name)
+		for i, s := range step.Steps {
+			action := child(s, fmt.Sprintf("steps[%d]", i))
+			if action == nil {
+				continue
+			}
+			switch step.Mode {
+			case "chain":
+				flow.ThenChain(action)
+			case "accumulate":
+				flow.ThenAccumulate(action)
+			default:
+				flow.Then(action)
+			}
+		}
+		return b.result(flow, failed)
+
+	case StepParallel:
+		var actions []workflow.Action
+		for i, s := range step.Steps {
+			actions = append(actions, child(s, fmt.Sprintf("steps[%d]", i)))
+		}
+		if step.CollectErrors {
+			return b.result(workflow.NewParallelErrorCollector(name).AddActions(actions...), failed)
+		}
+		return b.result(workflow.NewParallelFlow(name, actions...), failed)
+
+	case StepSwitch:
+		builder := workflow.NewSwitchFlowBuilder(name)
+		for i, c := range step.Cases {
+			builder.Case(b.predicate(c.When, step, c.node), child(c.Do, fmt.Sprintf("cases[%d]", i)))
+		}
+		if step.Default != nil {
+			builder.Default(child(step.Default, "default"))
+		}
+		return b.result(builder.Build(), failed)
+
+	case StepConditional:
+		condition := b.predicate(step.If, step, step.field("if"))
+		var ifFalse workflow.Action
+		if step.Else != nil {
+			ifFalse = child(step.Else, "else")
+		}
+		return b.result(workflow.NewConditionalFlow(name, condition, child(step.Then, "then"), ifFalse), failed)
+
+	case StepLoop:
+		body := child(step.Do, "do")
+		switch {
+		case step.While != "":
+			return b.result(workflow.NewLoopWhile(name, b.predicate(step.While, step, step.field("while"))).WithAction(body), failed)
+		case step.Until != "":
+			return b.result(workflow.NewLoopUntil(name, b.predicate(step.Until, step, step.field("until"))).WithAction(body), failed)
+		case step.Over != "":
+			items, err := workflow.ParseExpression(step.Over)
+			if err != nil {
+				b.errs.add(step.Source, step.field("over"), "%v", err)
+				return nil
+			}
+			return b.result(&overLoop{name: name, items: items, body: body}, failed)
+		default:
+			return b.result(workflow.NewLoop(name, step.Count).WithAction(body), failed)
+		}
+
+	case StepRetry:
+		retry := workflow.NewRetry(name, step.MaxAttempts).WithAction(child(step.Do, "do"))
+		if step.Backoff != nil {
+			retry.WithBackoffStrategy(backoffStrategy(step.Backoff))
+		}
+		if len(step.RetryOn) > 0 {
+			var conditions []workflow.RetryConditionFunc
+			for _, on := range step.RetryOn {
+				conditions = append(conditions, retryConditions[on])
+			}
+			retry.WithRetryCondition(workflow.CombineRetryConditions(conditions...))
+		}
+		if step.StopWhen != "" {
+			if stop := b.predicate(step.StopWhen, step, step.field("stop_when")); stop != nil {
+				retry.WithStopCondition(func(wctx workflow.WorkContext) bool {
+					ok, err := stop(wctx)
+					return err == nil && ok
+				})
+			}
+		}
+		return b.result(retry, failed)
+
+	case StepTry:
+		tc := workflow.NewTryCatch(name).WithTryAction(child(step.Do, "do"))
+		for i, c := range step.Catch {
+			handler := &catchHandler{name: fmt.Sprintf("%s.catch[%d]", name, i), action: child(c.Do, fmt.Sprintf("catch[%d]", i))}
+			matcher := errorMatchers[c.Error]
+			if c.MessageContains != "" {
+				matcher = workflow.ErrorMessageContains(c.MessageContains)
+			}
+			tc.Catch(matcher, handler)
+		}
+		if step.CatchAny != nil {
+			tc.CatchAny(&catchHandler{name: name + ".catch_any", action: child(step.CatchAny, "catch_any")})
+		}
+		if step.Finally != nil {
+			tc.Finally(child(step.Finally, "finally"))
+		}
+		return b.result(tc, failed)
+
+	case StepTimeout:
+		return b.result(workflow.NewTimeoutWrapper(name, step.Timeout).WithAction(child(step.Do, "do")), failed)
+
+	case StepCircuitBreaker:
+		cb := workflow.NewCircuitBreaker(name, step.FailureThreshold, step.RecoveryTimeout, step.ResetTimeout)
+		return b.result(cb.WithAction(child(step.Do, "do")), failed)
+	}
+
+	b.errs.add(step.Source, step.node, "unknown step type %q", step.Type)
+	return nil
+}
+
+// result returns the built action, or nil when building it recorded errors.
+func (b *workflowBuilder) result(action workflow.Action, failed int) workflow.Action {
+	if len(*b.errs) > failed {
+		return nil
+	}
+	return action
+}
+
+// overLoop iterates over the items an expression yields when the step runs.
+type overLoop struct {
+	name  string
+	items *workflow.Expression
+	body  workflow.Action
+}
+
+func (l *overLoop) Name() string { return l.name }
+
+// Children returns the loop body.
+func (l *overLoop) Children() []workflow.Action { return []workflow.Action{l.body} }
+
+func (l *overLoop) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	value, err := l.items.Eval(wctx)
+	if err != nil {
+		return workflow.NewFailedWorkReport(err)
+	}
+	if value == nil {
+		return workflow.NewSkippedWorkReport()
+	}
+	return workflow.NewLoopOver(l.name, value).WithAction(l.body).Run(wctx)
+}
+
+// catchHandler runs an action as a catch block, exposing the caught error.
+type catchHandler struct {
+	name   string
+	action workflow.Action
+}
+
+func (c *catchHandler) Name() string { return c.name }
+
+// Children returns the catch block.
+func (c *catchHandler) Children() []workflow.Action { return []workflow.Action{c.action} }
+
+func (c *catchHandler) Run(wctx workflow.WorkContext) workflow.WorkReport {
+	// Catch blocks only run through HandleError
+	return workflow.NewCompletedWorkReport()
+}
+
+func (c *catchHandler) HandleError(wctx workflow.WorkContext, err error) workflow.WorkReport {
+	wctx.Set(constants.KeyCaughtError, err)
+	return c.action.Run(wctx)
+}
+
+// backoffStrategy converts a backoff definition to a strategy.
+func backoffStrategy(def *BackoffDefinition) workflow.BackoffStrategy {
+	switch def.Type {
+	case "linear":
+		return workflow.NewLinearBackoff(def.Delay, def.Increment)
+	case "exponential":
+		return workflow.NewExponentialBackoff(def.Delay, def.MaxDelay, def.Factor)
+	}
+	return workflow.NewFixedBackoff(def.Delay)
+}
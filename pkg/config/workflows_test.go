@@ -0,0 +1,202 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// setAction returns an action that records its name in the "trace" key and
+// applies an optional update.
+func setAction(name string, update func(workflow.WorkContext)) func(workflow.WorkContext) workflow.WorkReport {
+	return func(wctx workflow.WorkContext) workflow.WorkReport {
+		trace, _ := wctx.Get("trace")
+		list, _ := trace.([]string)
+		wctx.Set("trace", append(list, name))
+		if update != nil {
+			update(wctx)
+		}
+		report := workflow.NewCompletedWorkReport()
+		report.Data = name
+		return report
+	}
+}
+
+func TestLoader_Workflows(t *testing.T) {
+	src := `agents:
+  - name: writer
+    model: gpt-4o
+    prompt: You write replies.
+
+workflows:
+  - name: support
+    type: sequential
+    steps:
+      - classify
+      - type: switch
+        cases:
+          - when: 'category == "billing" && priority > 2'
+            do: urgent_billing
+          - when: is_refund
+            do: refund
+        default: general
+      - type: retry
+        max_attempts: 3
+        backoff: 1ms
+        do: flaky
+      - type: loop
+        over: order.items
+        do: check_item
+      - type: try
+        do: {type: timeout, timeout: 1s, do: failing}
+        catch:
+          - message_contains: quota
+            do: handle_quota
+        finally: cleanup
+      - type: parallel
+        steps: [notify_a, notify_b]
+      - type: conditional
+        if: len(trace) > 5
+        then: writer
+
+  - name: guarded
+    type: circuit_breaker
+    failure_threshold: 2
+    recovery_timeout: 1m
+    reset_timeout: 1m
+    do: support
+`
+	flakyCalls := 0
+	actions := NewActionRegistry()
+	_ = actions.RegisterFunc("classify", setAction("classify", func(wctx workflow.WorkContext) {
+		wctx.Set("category", "billing")
+		wctx.Set("priority", 3)
+		wctx.Set("order", map[string]interface{}{"items": []interface{}{"a", "b"}})
+	}))
+	_ = actions.RegisterPredicate("is_refund", func(workflow.WorkContext) (bool, error) { return true, nil })
+	for _, name := range []string{"urgent_billing", "refund", "general", "check_item", "cleanup", "notify_a", "notify_b", "handle_quota"} {
+		_ = actions.RegisterFunc(name, setAction(name, nil))
+	}
+	_ = actions.RegisterFunc("flaky", func(wctx workflow.WorkContext) workflow.WorkReport {
+		flakyCalls++
+		if flakyCalls < 3 {
+			return workflow.NewFailedWorkReport(errors.New("temporary failure"))
+		}
+		return setAction("flaky", nil)(wctx)
+	})
+	_ = actions.RegisterFunc("failing", func(workflow.WorkContext) workflow.WorkReport {
+		return workflow.NewFailedWorkReport(errors.New("quota exceeded"))
+	})
+
+	client := &recordingClient{}
+	bundle, err := NewLoader(client, nil).WithActions(actions).Load([]byte(src), FormatYAML, "workflows.yaml")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	flow, ok := bundle.Workflows["guarded"]
+	if !ok {
+		t.Fatalf("Expected workflow guarded, got %v", bundle.Workflows)
+	}
+	if _, ok := flow.(*workflow.CircuitBreaker); !ok {
+		t.Errorf("Expected a CircuitBreaker, got %T", flow)
+	}
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set(constants.KeyUserInput, "I was charged twice")
+	report := flow.Run(ctx)
+	if report.Status != workflow.StatusCompleted {
+		t.Fatalf("Expected completed workflow, got %v", report.Errors)
+	}
+
+	trace, _ := ctx.Get("trace")
+	got := strings.Join(trace.([]string), ",")
+	want := "classify,urgent_billing,flaky,check_item,check_item,handle_quota,cleanup"
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("Expected trace to start with %s, got %s", want, got)
+	}
+	if flakyCalls != 3 {
+		t.Errorf("Expected 3 attempts of flaky, got %d", flakyCalls)
+	}
+	if caught, _ := ctx.Get(constants.KeyCaughtError); fmt.Sprint(caught) != "quota exceeded" {
+		t.Errorf("Expected caught error in context, got %v", caught)
+	}
+	if len(client.requests) != 1 {
+		t.Errorf("Expected the conditional to run the writer agent once, got %d requests", len(client.requests))
+	}
+}
+
+func TestLoader_WorkflowValidation(t *testing.T) {
+	src := `workflows:
+  - name: broken
+    type: sequential
+    steps:
+      - missing_action
+      - type: loop
+        count: 2
+        while: done
+        do: step
+      - type: switch
+        cases:
+          - when: 'score >'
+            do: step
+      - type: retry
+        do: step
+        colour: red
+      - type: teleport
+  - name: loop_a
+    type: sequential
+    steps: [loop_b]
+  - name: loop_b
+    type: sequential
+    steps: [loop_a]
+`
+	actions := NewActionRegistry()
+	_ = actions.RegisterFunc("step", setAction("step", nil))
+	loader := NewLoader(&recordingClient{}, nil).WithActions(actions)
+
+	_, err := loader.Load([]byte(src), FormatYAML, "flows.yaml")
+	for _, want := range []string{
+		"flows.yaml:6: workflow broken: steps[1]: exactly one of count, while, until or over is required",
+		"flows.yaml:14: workflow broken: steps[3]: max_attempts is required",
+		"flows.yaml:16: workflow broken: steps[3]: unknown field \"colour\"",
+		"flows.yaml:17: workflow broken: steps[4]: type must be one of",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q in:\n%v", want, err)
+		}
+	}
+
+	// References and expressions are checked when building.
+	src = `workflows:
+  - name: broken
+    type: sequential
+    steps:
+      - missing_action
+      - type: switch
+        cases:
+          - when: 'score >'
+            do: step
+  - name: loop_a
+    type: sequential
+    steps: [loop_b]
+  - name: loop_b
+    type: sequential
+    steps: [loop_a]
+`
+	_, err = loader.Load([]byte(src), FormatYAML, "flows.yaml")
+	for _, want := range []string{
+		`flows.yaml:5: unknown action "missing_action"`,
+		`flows.yaml:8: invalid expression "score >"`,
+		"flows.yaml:10: workflow loop_a references itself",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q in:\n%v", want, err)
+		}
+	}
+}
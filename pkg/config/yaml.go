@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlErrorLine matches the line number in yaml.v3 error messages.
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// ParseYAML parses YAML data into a Node tree. The source name is used in errors.
+func ParseYAML(data []byte, source string) (*Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+
+	var doc yaml.Node
+	if err := dec.Decode(&doc); err != nil {
+		if err == io.EOF {
+			return &Node{Kind: ScalarNode, Line: 1, Column: 1}, nil
+		}
+		return nil, yamlError(source, err)
+	}
+
+	var next yaml.Node
+	if err := dec.Decode(&next); err != io.EOF {
+		if err != nil {
+			return nil, yamlError(source, err)
+		}
+		return nil, errorAt(source, next.Line, next.Column, "multiple documents are not supported")
+	}
+
+	if len(doc.Content) == 0 {
+		return &Node{Kind: ScalarNode, Line: 1, Column: 1}, nil
+	}
+	return convertYAML(doc.Content[0], source)
+}
+
+// yamlError converts a yaml.v3 error to an *Error with its line number.
+func yamlError(source string, err error) error {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	line := 0
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		message = m[2]
+	}
+	return errorAt(source, line, 0, "invalid YAML: %s", message)
+}
+
+// errorAt returns an *Error at a position in the source.
+func errorAt(source string, line, column int, format string, args ...interface{}) error {
+	var errs ErrorList
+	errs.add(source, &Node{Line: line, Column: column}, format, args...)
+	return errs[0]
+}
+
+// convertYAML converts a yaml.v3 node to a Node. Aliases are expanded and
+// merge keys (<<) are resolved into the mapping.
+func convertYAML(n *yaml.Node, source string) (*Node, error) {
+	node := &Node{Line: n.Line, Column: n.Column}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		return convertYAML(n.Alias, source)
+
+	case yaml.ScalarNode:
+		node.Kind = ScalarNode
+		node.Value = n.Value
+		// Quoted, block and explicitly tagged strings are never numbers or booleans
+		node.Quoted = n.ShortTag() == "!!str" && n.Style != 0
+
+	case yaml.SequenceNode:
+		node.Kind = SequenceNode
+		for _, item := range n.Content {
+			converted, err := convertYAML(item, source)
+			if err != nil {
+				return nil, err
+			}
+			node.Items = append(node.Items, converted)
+		}
+
+	case yaml.MappingNode:
+		node.Kind = MappingNode
+		var merged []Pair
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.ShortTag() == "!!merge" {
+				pairs, err := mergePairs(value, source)
+				if err != nil {
+					return nil, err
+				}
+				merged = append(merged, pairs...)
+				continue
+			}
+
+			convertedKey, err := convertYAML(key, source)
+			if err != nil {
+				return nil, err
+			}
+			if convertedKey.Kind != ScalarNode {
+				return nil, errorAt(source, key.Line, key.Column, "mapping keys must be scalars")
+			}
+			if node.Get(convertedKey.Value) != nil {
+				return nil, errorAt(source, key.Line, key.Column, "duplicate key %q", convertedKey.Value)
+			}
+			convertedValue, err := convertYAML(value, source)
+			if err != nil {
+				return nil, err
+			}
+			node.Pairs = append(node.Pairs, Pair{Key: convertedKey, Value: convertedValue})
+		}
+
+		// Keys of the mapping take precedence over merged keys
+		for _, pair := range merged {
+			if node.Get(pair.Key.Value) == nil {
+				node.Pairs = append(node.Pairs, pair)
+			}
+		}
+
+	default:
+		return nil, errorAt(source, n.Line, n.Column, "unsupported YAML node")
+	}
+	return node, nil
+}
+
+// mergePairs returns the pairs merged into a mapping by a merge key, whose
+// value is a mapping or a sequence of mappings. Earlier mappings take
+// precedence.
+func mergePairs(value *yaml.Node, source string) ([]Pair, error) {
+	merged, err := convertYAML(value, source)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []*Node{merged}
+	if merged.Kind == SequenceNode {
+		sources = merged.Items
+	}
+
+	result := &Node{Kind: MappingNode}
+	for _, m := range sources {
+		if m.Kind != MappingNode {
+			return nil, errorAt(source, m.Line, m.Column, "merge key expects a mapping or a sequence of mappings")
+		}
+		for _, pair := range m.Pairs {
+			if result.Get(pair.Key.Value) == nil {
+				result.Pairs = append(result.Pairs, pair)
+			}
+		}
+	}
+	return result.Pairs, nil
+}
@@ -26,6 +26,24 @@ const (
 	// Used by accumulating workflows to preserve the initial user message.
 	KeyOriginalInput = "original_input"
 
+	// KeyRetrievedChunks is the key for the chunks retrieved for the current input.
+	// Contains a slice of retrieval.Chunk set by RetrievalAgent.
+	KeyRetrievedChunks = "retrieved_chunks"
+
+	// KeyHandoffChain is the key for the names of the agents that handled the conversation.
+	// Contains a slice of strings, starting with the first agent, extended on every handoff.
+	KeyHandoffChain = "handoff_chain"
+
+	// Plan Context Keys - used by PlanAgent
+
+	// KeyPlan is the key for the current step plan of a PlanAgent.
+	// Contains an agent.Plan that is updated whenever the plan is revised.
+	KeyPlan = "plan"
+
+	// KeyPlanStepResults is the key for the outcomes of executed plan steps.
+	// Contains a slice of agent.StepResult in execution order.
+	KeyPlanStepResults = "plan_step_results"
+
 	// Loop Context Keys - used by loop constructs
 
 	// KeyCurrentItem is the key for the current item in an iterator loop.
@@ -39,6 +57,44 @@ const (
 	// KeyLoopIteration is the key for the current iteration number.
 	// Used by all loop constructs to track the current iteration (1-based).
 	KeyLoopIteration = "loop_iteration"
+
+	// KeyCaughtError is the key for the error handled by a catch block of a
+	// declarative try step. Contains the error value.
+	KeyCaughtError = "caught_error"
+
+	// KeyBudget is the key for the budget shared by the agents of a run.
+	// Contains an *agent.Budget checked before every LLM call.
+	KeyBudget = "budget"
+
+	// KeyParsedOutput is the key for the value parsed from the response of an
+	// agent with an output parser. Contains the value returned by the parser.
+	KeyParsedOutput = "parsed_output"
+
+	// KeyRoute is the key for the decision of the last router that ran.
+	// Contains an *agent.RouteDecision with the route, confidence and rationale.
+	KeyRoute = "route"
+
+	// KeyToolResults is the key for the store of large tool results that
+	// agents split into pages. Read through the read_tool_result tool.
+	KeyToolResults = "tool_results"
+
+	// KeySelectedTools is the key for the tools selected for the current turn
+	// of tool agents with a tool selector.
+	KeySelectedTools = "selected_tools"
+
+	// Session Keys - used by agents configured with a session store
+
+	// KeySessionID is the key for the ID of the session an agent loads and saves.
+	// Contains a string; agents without a session ID run without a session.
+	KeySessionID = "session_id"
+
+	// KeySessionState is the key for the agent-specific state of the session.
+	// Contains a map[string]interface{} that is saved at the end of the run.
+	KeySessionState = "session_state"
+
+	// KeyPendingToolCalls is the key for tool calls awaiting execution, e.g.
+	// approval by a human. Contains a slice of llm.ToolCall stored with the session.
+	KeyPendingToolCalls = "pending_tool_calls"
 )
 
 const (
@@ -51,6 +107,10 @@ const (
 	// KeyLogger is the key for storing a custom logger in the Go context.
 	// Used by the logging system to retrieve context-specific loggers.
 	KeyLogger ContextKey = "logger"
+
+	// KeyCompleter is the key for storing a memory.Completer in the Go context.
+	// Used by agents to account for the completions of memory strategies.
+	KeyCompleter ContextKey = "completer"
 )
 
 const (
@@ -206,6 +266,9 @@ const (
 	// SchemaTypeNumber indicates a number type in JSON schema.
 	SchemaTypeNumber = "number"
 
+	// SchemaTypeInteger indicates an integer type in JSON schema.
+	SchemaTypeInteger = "integer"
+
 	// SchemaTypeBoolean indicates a boolean type in JSON schema.
 	SchemaTypeBoolean = "boolean"
 
@@ -0,0 +1,47 @@
+package document
+
+// Standard metadata keys set by loaders and splitters.
+const (
+	// MetaSource is the file path or identifier a document was loaded from.
+	MetaSource = "source"
+	// MetaFormat is the loader format, e.g. "markdown" or "html".
+	MetaFormat = "format"
+	// MetaTitle is the document title when the format provides one.
+	MetaTitle = "title"
+	// MetaLanguage is the programming language of a source code document.
+	MetaLanguage = "language"
+	// MetaLine is the 1-based line number of a record in a JSONL file.
+	MetaLine = "line"
+	// MetaChunkIndex is the 0-based position of a chunk within its document.
+	MetaChunkIndex = "chunk_index"
+	// MetaChunkCount is the total number of chunks produced from the document.
+	MetaChunkCount = "chunk_count"
+	// MetaHeaders is the Markdown header path of a chunk, e.g. "Guide > Setup".
+	MetaHeaders = "headers"
+)
+
+// Document is a unit of text with metadata describing where it came from.
+// Loaders produce documents and splitters turn them into smaller chunks that
+// keep the source metadata, so that retrieved chunks can be cited.
+type Document struct {
+	ID       string                 `json:"id,omitempty"`
+	Content  string                 `json:"content"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Source returns the source metadata value of the document, if present.
+func (d Document) Source() string {
+	if source, ok := d.Metadata[MetaSource].(string); ok {
+		return source
+	}
+	return ""
+}
+
+// copyMetadata returns a shallow copy of the metadata map.
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(metadata)+2)
+	for k, v := range metadata {
+		result[k] = v
+	}
+	return result
+}
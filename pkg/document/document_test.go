@@ -0,0 +1,179 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoaders(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	mdPath := writeFile(t, dir, "guide.md", "---\nauthor: Ada\n---\n# Guide\n\nHello.")
+	docs, err := LoadFile(ctx, mdPath)
+	if err != nil {
+		t.Fatalf("Markdown load failed: %v", err)
+	}
+	if docs[0].Metadata["author"] != "Ada" || docs[0].Metadata[MetaTitle] != "Guide" {
+		t.Errorf("Unexpected markdown metadata: %v", docs[0].Metadata)
+	}
+	if strings.Contains(docs[0].Content, "author:") {
+		t.Error("Front matter should be removed from content")
+	}
+	if chunks := NewFixedSizeSplitter(4, 0).Split(docs[0]); chunks[0].ID != mdPath+"#0" {
+		t.Errorf("Expected chunk IDs derived from the path, got %q", chunks[0].ID)
+	}
+
+	htmlPath := writeFile(t, dir, "page.html", `<html><head><title>My &amp; Page</title><style>p{}</style></head>
+<body><h1>Welcome</h1><p>First   paragraph.</p><script>alert(1)</script><p>Second</p></body></html>`)
+	docs, err = LoadFile(ctx, htmlPath)
+	if err != nil {
+		t.Fatalf("HTML load failed: %v", err)
+	}
+	if docs[0].Metadata[MetaTitle] != "My & Page" {
+		t.Errorf("Unexpected HTML title: %v", docs[0].Metadata[MetaTitle])
+	}
+	if docs[0].Content != "Welcome\n\nFirst paragraph.\n\nSecond" {
+		t.Errorf("Unexpected HTML text: %q", docs[0].Content)
+	}
+
+	jsonlPath := writeFile(t, dir, "faq.jsonl", `{"id":"q1","content":"Refund policy","topic":"billing"}`+"\n\n"+`{"id":"q2","content":"Shipping","topic":"orders"}`)
+	docs, err = LoadFile(ctx, jsonlPath)
+	if err != nil {
+		t.Fatalf("JSONL load failed: %v", err)
+	}
+	if len(docs) != 2 || docs[1].ID != "q2" || docs[1].Metadata["topic"] != "orders" || docs[1].Metadata[MetaLine] != 3 {
+		t.Errorf("Unexpected JSONL documents: %+v", docs)
+	}
+
+	jsonPath := writeFile(t, dir, "items.json", `[{"text":"alpha"},{"text":"beta","tag":"b","source":"wiki"}]`)
+	docs, err = NewJSONLoader(jsonPath).WithContentField("text").Load(ctx)
+	if err != nil {
+		t.Fatalf("JSON load failed: %v", err)
+	}
+	if len(docs) != 2 || docs[1].Content != "beta" || docs[1].Metadata["tag"] != "b" || docs[1].ID != jsonPath+"[1]" {
+		t.Errorf("Unexpected JSON documents: %+v", docs)
+	}
+	if docs[1].Source() != jsonPath || docs[1].Metadata["record_source"] != "wiki" {
+		t.Errorf("Expected the record source not to replace the file, got %v", docs[1].Metadata)
+	}
+
+	codePath := writeFile(t, dir, "main.go", "package main\n")
+	docs, err = LoadFile(ctx, codePath)
+	if err != nil {
+		t.Fatalf("Code load failed: %v", err)
+	}
+	if docs[0].Metadata[MetaLanguage] != "go" || docs[0].Source() != codePath {
+		t.Errorf("Unexpected code metadata: %v", docs[0].Metadata)
+	}
+
+	docs, err = NewDirectoryLoader(dir).WithExtensions(".md", ".go").Load(ctx)
+	if err != nil {
+		t.Fatalf("Directory load failed: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Errorf("Expected 2 documents from directory, got %d", len(docs))
+	}
+}
+
+func TestFixedSizeSplitter(t *testing.T) {
+	doc := Document{ID: "doc", Content: "abcdefghij", Metadata: map[string]interface{}{MetaSource: "x.txt"}}
+	chunks := NewFixedSizeSplitter(4, 1).Split(doc)
+
+	want := []string{"abcd", "defg", "ghij"}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d", len(want), len(chunks))
+	}
+	for i, chunk := range chunks {
+		if chunk.Content != want[i] {
+			t.Errorf("Chunk %d: expected %q, got %q", i, want[i], chunk.Content)
+		}
+		if chunk.Source() != "x.txt" || chunk.Metadata[MetaChunkIndex] != i || chunk.Metadata[MetaChunkCount] != 3 {
+			t.Errorf("Chunk %d has unexpected metadata: %v", i, chunk.Metadata)
+		}
+	}
+	if chunks[1].ID != "doc#1" {
+		t.Errorf("Expected chunk ID 'doc#1', got '%s'", chunks[1].ID)
+	}
+}
+
+func TestRecursiveSplitter(t *testing.T) {
+	text := "First paragraph is short.\n\nSecond paragraph has a few more words in it. It has two sentences.\n\nThird."
+	chunks := NewRecursiveSplitter(50, 0).SplitText(text)
+
+	for _, chunk := range chunks {
+		if RuneLength(chunk) > 50 {
+			t.Errorf("Chunk exceeds size: %q", chunk)
+		}
+	}
+	if chunks[0] != "First paragraph is short." {
+		t.Errorf("Expected first paragraph kept intact, got %q", chunks[0])
+	}
+	if !strings.Contains(strings.Join(chunks, " "), "It has two sentences.") {
+		t.Errorf("Expected sentence boundary split, got %q", chunks)
+	}
+}
+
+func TestRecursiveSplitter_Overlap(t *testing.T) {
+	chunks := NewRecursiveSplitter(11, 5).SplitText("one two three four five")
+
+	if len(chunks) < 2 {
+		t.Fatalf("Expected multiple chunks, got %q", chunks)
+	}
+	for i := 1; i < len(chunks); i++ {
+		prevWords := strings.Fields(chunks[i-1])
+		if !strings.HasPrefix(chunks[i], prevWords[len(prevWords)-1]) {
+			t.Errorf("Expected chunk %d to overlap with previous: %q", i, chunks)
+		}
+	}
+}
+
+func TestTokenSplitter(t *testing.T) {
+	tokenizer := llm.NewApproxTokenizer()
+	text := strings.Repeat("word ", 200)
+	chunks := NewTokenSplitter(tokenizer, 20, 0).Split(Document{Content: text})
+
+	if len(chunks) < 2 {
+		t.Fatalf("Expected multiple chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if tokens := tokenizer.CountTokens(chunk.Content); tokens > 20 {
+			t.Errorf("Chunk has %d tokens, expected at most 20", tokens)
+		}
+	}
+}
+
+func TestMarkdownHeaderSplitter(t *testing.T) {
+	content := "# Guide\nIntro text.\n## Setup\nInstall it.\n```\n# not a header\n```\n## Usage\nRun it.\n### Flags\nUse -v."
+	chunks := NewMarkdownHeaderSplitter(2).Split(Document{Content: content})
+
+	want := []string{"Guide", "Guide > Setup", "Guide > Usage"}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Metadata[MetaHeaders] != want[i] {
+			t.Errorf("Chunk %d: expected headers %q, got %v", i, want[i], chunk.Metadata[MetaHeaders])
+		}
+	}
+	if !strings.Contains(chunks[1].Content, "# not a header") {
+		t.Error("Header inside code fence should not start a new section")
+	}
+	if !strings.Contains(chunks[2].Content, "### Flags") {
+		t.Error("Headers below the max level should stay in their section")
+	}
+}
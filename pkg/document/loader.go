@@ -0,0 +1,435 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Loader reads documents from a source.
+type Loader interface {
+	// Load returns the documents read from the source.
+	Load(ctx context.Context) ([]Document, error)
+}
+
+// codeLanguages maps source file extensions to language names.
+var codeLanguages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".ts": "typescript",
+	".tsx": "typescript", ".jsx": "javascript", ".java": "java", ".rb": "ruby",
+	".rs": "rust", ".c": "c", ".h": "c", ".cpp": "cpp", ".hpp": "cpp",
+	".cs": "csharp", ".php": "php", ".swift": "swift", ".kt": "kotlin",
+	".scala": "scala", ".sh": "shell", ".sql": "sql", ".proto": "protobuf",
+}
+
+// TextLoader loads a plain text file as a single document.
+type TextLoader struct {
+	path string
+}
+
+// NewTextLoader creates a new TextLoader for the file at path.
+func NewTextLoader(path string) *TextLoader {
+	return &TextLoader{path: path}
+}
+
+// Load reads the file.
+func (l *TextLoader) Load(ctx context.Context) ([]Document, error) {
+	data, err := readFile(ctx, l.path)
+	if err != nil {
+		return nil, err
+	}
+	return []Document{newDocument(l.path, "text", string(data))}, nil
+}
+
+// MarkdownLoader loads a Markdown file. Simple "key: value" front matter is
+// moved into the metadata and the first level-1 header becomes the title.
+type MarkdownLoader struct {
+	path string
+}
+
+// NewMarkdownLoader creates a new MarkdownLoader for the file at path.
+func NewMarkdownLoader(path string) *MarkdownLoader {
+	return &MarkdownLoader{path: path}
+}
+
+// Load reads and parses the file.
+func (l *MarkdownLoader) Load(ctx context.Context) ([]Document, error) {
+	data, err := readFile(ctx, l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, frontMatter := parseFrontMatter(string(data))
+	doc := newDocument(l.path, "markdown", content)
+	for k, v := range frontMatter {
+		doc.Metadata[k] = v
+	}
+	if _, ok := doc.Metadata[MetaTitle]; !ok {
+		for _, line := range strings.Split(content, "\n") {
+			if strings.HasPrefix(line, "# ") {
+				doc.Metadata[MetaTitle] = strings.TrimSpace(line[2:])
+				break
+			}
+		}
+	}
+	return []Document{doc}, nil
+}
+
+// parseFrontMatter splits "---" delimited front matter from Markdown content.
+func parseFrontMatter(content string) (string, map[string]interface{}) {
+	if !strings.HasPrefix(content, "---\n") {
+		return content, nil
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return content, nil
+	}
+
+	metadata := make(map[string]interface{})
+	for _, line := range strings.Split(content[4:4+end], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		metadata[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	rest := content[4+end+4:]
+	return strings.TrimLeft(rest, "\n"), metadata
+}
+
+// HTMLLoader loads an HTML file and extracts its visible text.
+type HTMLLoader struct {
+	path string
+}
+
+// NewHTMLLoader creates a new HTMLLoader for the file at path.
+func NewHTMLLoader(path string) *HTMLLoader {
+	return &HTMLLoader{path: path}
+}
+
+// Load reads the file and extracts the text.
+func (l *HTMLLoader) Load(ctx context.Context) ([]Document, error) {
+	data, err := readFile(ctx, l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	title, text := ExtractHTMLText(string(data))
+	doc := newDocument(l.path, "html", text)
+	if title != "" {
+		doc.Metadata[MetaTitle] = title
+	}
+	return []Document{doc}, nil
+}
+
+var (
+	htmlSkipRe    = regexp.MustCompile(`(?is)<(script|style|noscript|template|head)\b[^>]*>.*?</(script|style|noscript|template|head)\s*>`)
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTitleRe   = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	htmlBlockRe   = regexp.MustCompile(`(?i)</?(p|div|br|h[1-6]|li|ul|ol|tr|table|section|article|header|footer|pre|blockquote)\b[^>]*>`)
+	htmlTagRe     = regexp.MustCompile(`(?s)<[^>]+>`)
+	spaceRe       = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesRe  = regexp.MustCompile(`\n\s*\n\s*(\n\s*)+`)
+)
+
+// ExtractHTMLText returns the title and the visible text of an HTML page.
+// Block-level elements become line breaks; scripts, styles and comments are dropped.
+func ExtractHTMLText(page string) (title, text string) {
+	if match := htmlTitleRe.FindStringSubmatch(page); match != nil {
+		title = strings.TrimSpace(html.UnescapeString(htmlTagRe.ReplaceAllString(match[1], "")))
+	}
+
+	text = htmlCommentRe.ReplaceAllString(page, "")
+	text = htmlSkipRe.ReplaceAllString(text, "")
+	text = htmlBlockRe.ReplaceAllString(text, "\n")
+	text = htmlTagRe.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = spaceRe.ReplaceAllString(text, " ")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = strings.Join(lines, "\n")
+	text = blankLinesRe.ReplaceAllString(text, "\n\n")
+	return title, strings.TrimSpace(text)
+}
+
+// JSONLoader loads records from a JSON file (an object or an array of objects)
+// or a JSONL file (one object per line). The content field becomes the document
+// content and the remaining fields become metadata. Fields that clash with the
+// loader metadata, such as "source", are kept with a "record_" prefix.
+// Records without an ID field are identified by their position in the file.
+type JSONLoader struct {
+	path         string
+	contentField string
+	idField      string
+	lines        bool
+}
+
+// NewJSONLoader creates a new JSONLoader for a JSON file.
+func NewJSONLoader(path string) *JSONLoader {
+	return &JSONLoader{path: path, contentField: "content", idField: "id"}
+}
+
+// NewJSONLLoader creates a new JSONLoader for a JSONL file.
+func NewJSONLLoader(path string) *JSONLoader {
+	return &JSONLoader{path: path, contentField: "content", idField: "id", lines: true}
+}
+
+// WithContentField sets the field holding the document text (default "content").
+// Records without the field are loaded with their full JSON as content.
+func (l *JSONLoader) WithContentField(field string) *JSONLoader {
+	l.contentField = field
+	return l
+}
+
+// WithIDField sets the field holding the document ID (default "id").
+func (l *JSONLoader) WithIDField(field string) *JSONLoader {
+	l.idField = field
+	return l
+}
+
+// Load reads and decodes the file.
+func (l *JSONLoader) Load(ctx context.Context) ([]Document, error) {
+	data, err := readFile(ctx, l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.lines {
+		return l.loadLines(data)
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", l.path, err)
+	}
+
+	var records []interface{}
+	switch v := raw.(type) {
+	case []interface{}:
+		records = v
+	default:
+		records = []interface{}{v}
+	}
+
+	docs := make([]Document, 0, len(records))
+	for i, record := range records {
+		id := l.path
+		if _, ok := raw.([]interface{}); ok {
+			id = fmt.Sprintf("%s[%d]", l.path, i)
+		}
+		docs = append(docs, l.recordToDocument(record, id))
+	}
+	return docs, nil
+}
+
+// loadLines decodes one JSON record per non-empty line.
+func (l *JSONLoader) loadLines(data []byte) ([]Document, error) {
+	var docs []Document
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var record interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid JSON: %w", l.path, lineNumber, err)
+		}
+		doc := l.recordToDocument(record, fmt.Sprintf("%s:%d", l.path, lineNumber))
+		doc.Metadata[MetaLine] = lineNumber
+		docs = append(docs, doc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", l.path, err)
+	}
+	return docs, nil
+}
+
+// recordFieldsReserved are the metadata keys set by the JSON loader itself.
+var recordFieldsReserved = map[string]bool{MetaSource: true, MetaFormat: true, MetaLine: true}
+
+// recordToDocument converts a decoded JSON value into a document with the
+// given ID, unless the record has its own.
+func (l *JSONLoader) recordToDocument(record interface{}, id string) Document {
+	format := "json"
+	if l.lines {
+		format = "jsonl"
+	}
+
+	object, ok := record.(map[string]interface{})
+	if !ok {
+		encoded, _ := json.Marshal(record)
+		doc := newDocument(l.path, format, string(encoded))
+		doc.ID = id
+		return doc
+	}
+
+	content, hasContent := object[l.contentField].(string)
+	if !hasContent {
+		encoded, _ := json.Marshal(object)
+		content = string(encoded)
+	}
+
+	doc := newDocument(l.path, format, content)
+	doc.ID = id
+	for k, v := range object {
+		if k == l.contentField && hasContent {
+			continue
+		}
+		if k == l.idField {
+			doc.ID = fmt.Sprint(v)
+			continue
+		}
+		if recordFieldsReserved[k] {
+			k = "record_" + k
+		}
+		doc.Metadata[k] = v
+	}
+	return doc
+}
+
+// CodeLoader loads a source code file and records its language.
+type CodeLoader struct {
+	path string
+}
+
+// NewCodeLoader creates a new CodeLoader for the file at path.
+func NewCodeLoader(path string) *CodeLoader {
+	return &CodeLoader{path: path}
+}
+
+// Load reads the file.
+func (l *CodeLoader) Load(ctx context.Context) ([]Document, error) {
+	data, err := readFile(ctx, l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := newDocument(l.path, "code", string(data))
+	if language, ok := codeLanguages[strings.ToLower(filepath.Ext(l.path))]; ok {
+		doc.Metadata[MetaLanguage] = language
+	}
+	return []Document{doc}, nil
+}
+
+// LoaderForFile returns the loader matching the file extension.
+// Unknown extensions are loaded as plain text.
+func LoaderForFile(path string) Loader {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".md", ".markdown":
+		return NewMarkdownLoader(path)
+	case ".html", ".htm":
+		return NewHTMLLoader(path)
+	case ".json":
+		return NewJSONLoader(path)
+	case ".jsonl", ".ndjson":
+		return NewJSONLLoader(path)
+	}
+	if _, ok := codeLanguages[ext]; ok {
+		return NewCodeLoader(path)
+	}
+	return NewTextLoader(path)
+}
+
+// LoadFile loads a single file with the loader matching its extension.
+func LoadFile(ctx context.Context, path string) ([]Document, error) {
+	return LoaderForFile(path).Load(ctx)
+}
+
+// DirectoryLoader loads every matching file below a directory.
+type DirectoryLoader struct {
+	root       string
+	extensions map[string]bool
+}
+
+// NewDirectoryLoader creates a new DirectoryLoader for the directory at root.
+func NewDirectoryLoader(root string) *DirectoryLoader {
+	return &DirectoryLoader{root: root}
+}
+
+// WithExtensions restricts loading to files with the given extensions, e.g. ".md".
+func (l *DirectoryLoader) WithExtensions(extensions ...string) *DirectoryLoader {
+	l.extensions = make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		l.extensions[strings.ToLower(ext)] = true
+	}
+	return l
+}
+
+// Load walks the directory and loads each file. Hidden files and directories are skipped.
+func (l *DirectoryLoader) Load(ctx context.Context) ([]Document, error) {
+	var docs []Document
+	err := filepath.WalkDir(l.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path != l.root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if l.extensions != nil && !l.extensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		loaded, err := LoadFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, loaded...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// readFile reads a file after checking the context for cancellation.
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// newDocument creates a document with the standard source metadata. The path
+// is the default ID, so that chunks get IDs like "path#0".
+func newDocument(path, format, content string) Document {
+	return Document{
+		ID:      path,
+		Content: content,
+		Metadata: map[string]interface{}{
+			MetaSource: path,
+			MetaFormat: format,
+		},
+	}
+}
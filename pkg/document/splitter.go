@@ -0,0 +1,368 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// Splitter divides a document into smaller chunks. Every chunk keeps the
+// metadata of its document and records its position in MetaChunkIndex and
+// MetaChunkCount.
+type Splitter interface {
+	// Split returns the chunks of the document in order.
+	Split(doc Document) []Document
+}
+
+// SplitAll splits every document with the splitter and returns all chunks.
+func SplitAll(splitter Splitter, docs []Document) []Document {
+	var chunks []Document
+	for _, doc := range docs {
+		chunks = append(chunks, splitter.Split(doc)...)
+	}
+	return chunks
+}
+
+// LengthFunc measures the size of a piece of text, e.g. in runes or tokens.
+type LengthFunc func(text string) int
+
+// RuneLength measures text in Unicode characters.
+func RuneLength(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// buildChunks turns the chunk texts into documents carrying the source metadata.
+func buildChunks(doc Document, texts []string, extra []map[string]interface{}) []Document {
+	chunks := make([]Document, 0, len(texts))
+	for i, text := range texts {
+		metadata := copyMetadata(doc.Metadata)
+		if extra != nil {
+			for k, v := range extra[i] {
+				metadata[k] = v
+			}
+		}
+		metadata[MetaChunkIndex] = i
+		metadata[MetaChunkCount] = len(texts)
+
+		chunk := Document{Content: text, Metadata: metadata}
+		if doc.ID != "" {
+			chunk.ID = fmt.Sprintf("%s#%d", doc.ID, i)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// FixedSizeSplitter cuts text into windows of a fixed number of characters,
+// with consecutive windows sharing overlap characters.
+type FixedSizeSplitter struct {
+	size    int
+	overlap int
+}
+
+// NewFixedSizeSplitter creates a new FixedSizeSplitter.
+func NewFixedSizeSplitter(size, overlap int) *FixedSizeSplitter {
+	if size <= 0 {
+		size = 1000
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	return &FixedSizeSplitter{size: size, overlap: overlap}
+}
+
+// Split implements Splitter.
+func (s *FixedSizeSplitter) Split(doc Document) []Document {
+	runes := []rune(doc.Content)
+	var texts []string
+	step := s.size - s.overlap
+	for start := 0; start < len(runes); start += step {
+		end := start + s.size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		texts = append(texts, string(runes[start:end]))
+		if end == len(runes) {
+			break
+		}
+	}
+	return buildChunks(doc, texts, nil)
+}
+
+// DefaultSeparators are tried in order by RecursiveSplitter, from paragraphs
+// down to single characters.
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " ", ""}
+
+// RecursiveSplitter splits text on the first separator that occurs in it,
+// merges the pieces back into chunks up to the maximum size and recursively
+// splits pieces that are still too large with the next separator. This keeps
+// paragraphs and sentences together whenever they fit.
+type RecursiveSplitter struct {
+	size       int
+	overlap    int
+	separators []string
+	length     LengthFunc
+}
+
+// NewRecursiveSplitter creates a new RecursiveSplitter measuring size in characters.
+func NewRecursiveSplitter(size, overlap int) *RecursiveSplitter {
+	if size <= 0 {
+		size = 1000
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	return &RecursiveSplitter{
+		size:       size,
+		overlap:    overlap,
+		separators: DefaultSeparators,
+		length:     RuneLength,
+	}
+}
+
+// WithSeparators sets the separators tried in order. An empty string separator
+// splits into single characters and should come last.
+func (s *RecursiveSplitter) WithSeparators(separators ...string) *RecursiveSplitter {
+	s.separators = separators
+	return s
+}
+
+// WithLengthFunc sets how chunk sizes are measured.
+func (s *RecursiveSplitter) WithLengthFunc(length LengthFunc) *RecursiveSplitter {
+	s.length = length
+	return s
+}
+
+// Split implements Splitter.
+func (s *RecursiveSplitter) Split(doc Document) []Document {
+	return buildChunks(doc, s.SplitText(doc.Content), nil)
+}
+
+// SplitText splits raw text into chunks.
+func (s *RecursiveSplitter) SplitText(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return s.split(text, s.separators)
+}
+
+// split recursively splits text with the given separators.
+func (s *RecursiveSplitter) split(text string, separators []string) []string {
+	separator := ""
+	var remaining []string
+	for i, sep := range separators {
+		if sep == "" || strings.Contains(text, sep) {
+			separator = sep
+			remaining = separators[i+1:]
+			break
+		}
+	}
+
+	var pieces []string
+	if separator == "" {
+		for _, r := range text {
+			pieces = append(pieces, string(r))
+		}
+	} else {
+		pieces = strings.Split(text, separator)
+	}
+
+	var chunks []string
+	var pending []string
+	for _, piece := range pieces {
+		if piece == "" {
+			continue
+		}
+		if s.length(piece) <= s.size {
+			pending = append(pending, piece)
+			continue
+		}
+
+		// The piece alone is too large: flush what we have and split it further
+		if len(pending) > 0 {
+			chunks = append(chunks, s.merge(pending, separator)...)
+			pending = nil
+		}
+		if len(remaining) == 0 {
+			chunks = append(chunks, piece)
+		} else {
+			chunks = append(chunks, s.split(piece, remaining)...)
+		}
+	}
+	if len(pending) > 0 {
+		chunks = append(chunks, s.merge(pending, separator)...)
+	}
+	return chunks
+}
+
+// merge combines small pieces into chunks no larger than the maximum size,
+// carrying trailing pieces over into the next chunk up to the overlap size.
+func (s *RecursiveSplitter) merge(pieces []string, separator string) []string {
+	sepLength := s.length(separator)
+	var chunks []string
+	var current []string
+	total := 0
+
+	for _, piece := range pieces {
+		pieceLength := s.length(piece)
+		joinLength := 0
+		if len(current) > 0 {
+			joinLength = sepLength
+		}
+
+		if total+joinLength+pieceLength > s.size && len(current) > 0 {
+			if chunk := strings.TrimSpace(strings.Join(current, separator)); chunk != "" {
+				chunks = append(chunks, chunk)
+			}
+			// Drop pieces from the front until only the overlap remains
+			for len(current) > 0 && (total > s.overlap || total+sepLength+pieceLength > s.size) {
+				total -= s.length(current[0])
+				if len(current) > 1 {
+					total -= sepLength
+				}
+				current = current[1:]
+			}
+		}
+
+		if len(current) > 0 {
+			total += sepLength
+		}
+		current = append(current, piece)
+		total += pieceLength
+	}
+
+	if chunk := strings.TrimSpace(strings.Join(current, separator)); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// TokenSplitter splits text into chunks that fit a token budget as measured by
+// a tokenizer, preferring paragraph and sentence boundaries.
+type TokenSplitter struct {
+	recursive *RecursiveSplitter
+}
+
+// NewTokenSplitter creates a new TokenSplitter with chunks of at most maxTokens
+// tokens and overlapTokens tokens shared between consecutive chunks.
+func NewTokenSplitter(tokenizer llm.Tokenizer, maxTokens, overlapTokens int) *TokenSplitter {
+	recursive := NewRecursiveSplitter(maxTokens, overlapTokens).
+		WithLengthFunc(tokenizer.CountTokens)
+	return &TokenSplitter{recursive: recursive}
+}
+
+// Split implements Splitter.
+func (s *TokenSplitter) Split(doc Document) []Document {
+	return buildChunks(doc, s.recursive.SplitText(doc.Content), nil)
+}
+
+// MarkdownHeaderSplitter splits Markdown at headers up to a maximum level and
+// records the header path of each section in MetaHeaders. Sections that are
+// still too large can be split further with an inner splitter.
+type MarkdownHeaderSplitter struct {
+	maxLevel int
+	inner    *RecursiveSplitter
+}
+
+// NewMarkdownHeaderSplitter creates a new MarkdownHeaderSplitter that splits at
+// headers of level 1 through maxLevel.
+func NewMarkdownHeaderSplitter(maxLevel int) *MarkdownHeaderSplitter {
+	if maxLevel <= 0 || maxLevel > 6 {
+		maxLevel = 3
+	}
+	return &MarkdownHeaderSplitter{maxLevel: maxLevel}
+}
+
+// WithSplitter sets a splitter applied to sections that exceed its chunk size.
+func (s *MarkdownHeaderSplitter) WithSplitter(inner *RecursiveSplitter) *MarkdownHeaderSplitter {
+	s.inner = inner
+	return s
+}
+
+// Split implements Splitter.
+func (s *MarkdownHeaderSplitter) Split(doc Document) []Document {
+	type section struct {
+		headers []string
+		lines   []string
+	}
+
+	var sections []section
+	var headers []string
+	current := section{}
+	inFence := false
+
+	flush := func() {
+		if strings.TrimSpace(strings.Join(current.lines, "\n")) != "" {
+			sections = append(sections, current)
+		}
+	}
+
+	for _, line := range strings.Split(doc.Content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+
+		level := headerLevel(trimmed)
+		if inFence || level == 0 || level > s.maxLevel {
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		flush()
+		if len(headers) >= level {
+			headers = headers[:level-1]
+		}
+		for len(headers) < level-1 {
+			headers = append(headers, "")
+		}
+		headers = append(headers, strings.TrimSpace(trimmed[level:]))
+		current = section{
+			headers: append([]string(nil), headers...),
+			lines:   []string{line},
+		}
+	}
+	flush()
+
+	var texts []string
+	var extra []map[string]interface{}
+	for _, sec := range sections {
+		path := joinHeaders(sec.headers)
+		text := strings.TrimSpace(strings.Join(sec.lines, "\n"))
+
+		parts := []string{text}
+		if s.inner != nil {
+			parts = s.inner.SplitText(text)
+		}
+		for _, part := range parts {
+			texts = append(texts, part)
+			extra = append(extra, map[string]interface{}{MetaHeaders: path})
+		}
+	}
+	return buildChunks(doc, texts, extra)
+}
+
+// headerLevel returns the ATX header level of a line, or 0 if it is not a header.
+func headerLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// joinHeaders renders a header path, skipping levels that were never set.
+func joinHeaders(headers []string) string {
+	var parts []string
+	for _, h := range headers {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
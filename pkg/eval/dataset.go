@@ -0,0 +1,102 @@
+// Package eval measures agents and workflows against datasets. A Runner
+// sends every case of a Dataset through a workflow.Action, scores the output
+// with pluggable Scorers and collects per-case results and aggregates in a
+// Report. Reports of two runs, e.g. before and after a prompt or model
+// change, are compared with Compare to find regressions.
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Case is one input of a dataset with its expected output.
+type Case struct {
+	ID    string `json:"id"`
+	Input string `json:"input"`
+	// Expected is the expected output: a string, a number or a JSON object,
+	// depending on the scorers used.
+	Expected interface{} `json:"expected,omitempty"`
+	// Context holds additional WorkContext values set before the run. Numbers
+	// are set as float64.
+	Context  map[string]interface{} `json:"context,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ExpectedString returns the expected output as text. Non-string values are
+// encoded as JSON.
+func (c Case) ExpectedString() string {
+	switch v := c.Expected.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// Dataset is a named list of cases.
+type Dataset struct {
+	Name  string
+	Cases []Case
+}
+
+// LoadDataset reads a JSONL dataset with one case per line. The dataset is
+// named after the file.
+func LoadDataset(path string) (*Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer file.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return ReadDataset(name, file)
+}
+
+// ReadDataset reads a JSONL dataset from r. Blank lines are skipped; cases
+// without an ID are numbered by line.
+func ReadDataset(name string, r io.Reader) (*Dataset, error) {
+	dataset := &Dataset{Name: name}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	seen := make(map[string]int)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var c Case
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if err := decoder.Decode(&c); err != nil {
+			return nil, fmt.Errorf("failed to decode case on line %d: %w", lineNumber, err)
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("line-%d", lineNumber)
+		}
+		if previous, ok := seen[c.ID]; ok {
+			return nil, fmt.Errorf("duplicate case ID %q on lines %d and %d", c.ID, previous, lineNumber)
+		}
+		seen[c.ID] = lineNumber
+		dataset.Cases = append(dataset.Cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	return dataset, nil
+}
@@ -0,0 +1,297 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+const testDataset = `{"id": "capital", "input": "What is the capital of France?", "expected": "Paris"}
+{"id": "sum", "input": "What is 2+2?", "expected": 4}
+
+{"input": "Classify: I was charged twice", "expected": {"label": "billing", "urgent": true}}
+`
+
+// judgeClient answers every judge request with the same grade.
+type judgeClient struct {
+	content  string
+	err      error
+	requests []llm.CompletionRequest
+}
+
+func (c *judgeClient) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	c.requests = append(c.requests, req)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &llm.CompletionResponse{Content: c.content}, nil
+}
+
+func (c *judgeClient) Close() error { return nil }
+
+// answers returns an action that answers inputs from a table.
+func answers(table map[string]string) workflow.Action {
+	return workflow.NewActionFunc("answers", func(wctx workflow.WorkContext) workflow.WorkReport {
+		input, _ := wctx.Get(constants.KeyUserInput)
+		answer, ok := table[input.(string)]
+		if !ok {
+			return workflow.NewFailedWorkReport(fmt.Errorf("no answer for %q", input))
+		}
+		report := workflow.NewCompletedWorkReport()
+		report.Data = &llm.CompletionResponse{Content: answer}
+		report.SetMetadata("total_tokens", 10)
+		return report
+	})
+}
+
+func TestReadDataset(t *testing.T) {
+	dataset, err := ReadDataset("smoke", strings.NewReader(testDataset))
+	if err != nil {
+		t.Fatalf("ReadDataset failed: %v", err)
+	}
+	if len(dataset.Cases) != 3 || dataset.Cases[2].ID != "line-4" {
+		t.Fatalf("Expected 3 cases with a numbered third case, got %+v", dataset.Cases)
+	}
+	if got := dataset.Cases[1].ExpectedString(); got != "4" {
+		t.Errorf("Expected the number as text, got %q", got)
+	}
+
+	_, err = ReadDataset("dup", strings.NewReader(`{"id": "a", "input": "x"}`+"\n"+`{"id": "a", "input": "y"}`))
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("Expected a duplicate ID error, got %v", err)
+	}
+	if _, err := ReadDataset("bad", strings.NewReader("{not json")); err == nil {
+		t.Error("Expected an error for an invalid line")
+	}
+}
+
+func TestScorers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		scorer Scorer
+		c      Case
+		output string
+		value  float64
+		passed bool
+	}{
+		{"exact", NewExactScorer(), Case{Expected: "Paris"}, " Paris\n", 1, true},
+		{"exact case", NewExactScorer(), Case{Expected: "Paris"}, "paris", 0, false},
+		{"exact ignore case", NewExactScorer().IgnoreCase(), Case{Expected: "Paris"}, "paris", 1, true},
+		{"regex from case", mustRegex(t, ""), Case{Expected: `(?i)\bparis\b`}, "It is Paris.", 1, true},
+		{"regex", mustRegex(t, `^\d+$`), Case{}, "four", 0, false},
+		{"numeric", NewNumericScorer(0), Case{Expected: 1234.5}, "The total is 1,234.50 EUR", 1, true},
+		{"numeric relative", NewNumericScorer(0.05).Relative(), Case{Expected: 100}, "about 110", 0, false},
+		{"json fields", NewJSONFieldScorer(), Case{Expected: map[string]interface{}{"label": "billing", "urgent": true}},
+			"Result: {\"label\": \"billing\", \"urgent\": false}", 0.5, false},
+		{"json nested", NewJSONFieldScorer("result.label"), Case{Expected: `{"result": {"label": "billing"}}`},
+			`{"result": {"label": "billing", "score": 0.9}}`, 1, true},
+		{"json invalid", NewJSONFieldScorer(), Case{Expected: map[string]interface{}{"a": 1}}, "no json", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := tt.scorer.Score(ctx, tt.c, tt.output)
+			if err != nil {
+				t.Fatalf("Score failed: %v", err)
+			}
+			if score.Value != tt.value || score.Passed != tt.passed {
+				t.Errorf("Expected %v/%v, got %+v", tt.value, tt.passed, score)
+			}
+		})
+	}
+
+	if _, err := NewNumericScorer(0).Score(ctx, Case{ID: "x", Expected: "many"}, "3"); err == nil {
+		t.Error("Expected an error for a non-numeric expectation")
+	}
+	if _, err := NewRegexScorer("("); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func mustRegex(t *testing.T, pattern string) *RegexScorer {
+	t.Helper()
+	scorer, err := NewRegexScorer(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scorer
+}
+
+func TestJudgeScorer(t *testing.T) {
+	client := &judgeClient{content: `{"score": 0.8, "reasoning": "Correct but terse."}`}
+	scorer := NewJudgeScorer(client, "judge-model", "The answer must name the capital.")
+
+	score, err := scorer.Score(context.Background(), Case{ID: "capital", Input: "Capital of France?", Expected: "Paris"}, "Paris")
+	if err != nil || !score.Passed || score.Value != 0.8 || score.Reason != "Correct but terse." {
+		t.Fatalf("Expected a passing grade, got %+v, %v", score, err)
+	}
+	req := client.requests[0]
+	if req.Model != "judge-model" || req.JSONSchema == nil || !strings.Contains(req.Messages[1].Content, "Reference answer:\nParis") {
+		t.Errorf("Unexpected judge request %+v", req)
+	}
+
+	score, _ = scorer.WithThreshold(0.9).Score(context.Background(), Case{Input: "x"}, "y")
+	if score.Passed {
+		t.Error("Expected the grade to fail the raised threshold")
+	}
+	client.err = errors.New("overloaded")
+	if _, err := scorer.Score(context.Background(), Case{Input: "x"}, "y"); err == nil {
+		t.Error("Expected an error when the judge fails")
+	}
+}
+
+func TestRunner_ScoresAndSummarizes(t *testing.T) {
+	dataset, _ := ReadDataset("smoke", strings.NewReader(testDataset))
+	action := answers(map[string]string{
+		"What is the capital of France?": "Paris",
+		"What is 2+2?":                   "5",
+	})
+	var setups atomic.Int32
+	runner := NewRunner(action, NewExactScorer()).
+		WithName("v1").
+		WithConcurrency(2).
+		WithSetup(func(wctx workflow.WorkContext, c Case) { setups.Add(1) })
+
+	report, err := runner.Run(context.Background(), dataset)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Name != "v1" || report.Dataset != "smoke" || len(report.Results) != 3 || setups.Load() != 3 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	capital, sum, classify := report.Results[0], report.Results[1], report.Results[2]
+	if !capital.Passed || capital.Output != "Paris" || capital.Tokens != 10 {
+		t.Errorf("Expected the capital case to pass, got %+v", capital)
+	}
+	if sum.Passed || sum.Error != "" || sum.Scores[0].Value != 0 {
+		t.Errorf("Expected the sum case to fail its score, got %+v", sum)
+	}
+	if classify.Passed || !strings.Contains(classify.Error, "no answer") || classify.Scores[0].Reason != "run failed" {
+		t.Errorf("Expected the classify case to fail its run, got %+v", classify)
+	}
+
+	s := report.Summary
+	if s.Cases != 3 || s.Passed != 1 || s.Failed != 2 || s.Errors != 1 || s.TotalTokens != 20 {
+		t.Errorf("Unexpected summary %+v", s)
+	}
+	if s.MeanScores["exact"] != 1.0/3 {
+		t.Errorf("Expected a mean exact score of 1/3, got %v", s.MeanScores["exact"])
+	}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil || !strings.Contains(text.String(), "1/3 passed") || !strings.Contains(text.String(), "FAIL sum") {
+		t.Errorf("Unexpected text report:\n%s", text.String())
+	}
+}
+
+func TestRunner_NumericContext(t *testing.T) {
+	dataset, err := ReadDataset("retries", strings.NewReader(`{"id": "few", "input": "Retry?", "expected": "yes", "context": {"attempts": 2}}`))
+	if err != nil {
+		t.Fatalf("ReadDataset failed: %v", err)
+	}
+	canRetry := workflow.MustParseExpression("attempts < 3").Predicate()
+	action := workflow.NewActionFunc("retry", func(wctx workflow.WorkContext) workflow.WorkReport {
+		allowed, err := canRetry(wctx)
+		if err != nil {
+			return workflow.NewFailedWorkReport(err)
+		}
+		report := workflow.NewCompletedWorkReport()
+		report.Data = map[bool]string{true: "yes", false: "no"}[allowed]
+		return report
+	})
+
+	report, err := NewRunner(action, NewExactScorer()).Run(context.Background(), dataset)
+	if err != nil || !report.Results[0].Passed {
+		t.Errorf("Expected the numeric context to be usable, got %+v, %v", report.Results[0], err)
+	}
+}
+
+func TestRunner_Errors(t *testing.T) {
+	dataset := &Dataset{Name: "one", Cases: []Case{{ID: "a", Input: "x"}}}
+	if _, err := NewRunner(nil).Run(context.Background(), dataset); err == nil {
+		t.Error("Expected an error without an action")
+	}
+	if _, err := NewRunner(answers(nil)).Run(context.Background(), &Dataset{}); err == nil {
+		t.Error("Expected an error without cases")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewRunner(answers(nil)).Run(ctx, dataset); err == nil {
+		t.Error("Expected an error for a cancelled run")
+	}
+
+	failing := NewScorerFunc("broken", func(ctx context.Context, c Case, output string) (Score, error) {
+		return Score{}, errors.New("boom")
+	})
+	report, err := NewRunner(answers(map[string]string{"x": "y"}), failing).Run(context.Background(), dataset)
+	if err != nil || report.Results[0].Passed || !strings.Contains(report.Results[0].Error, "broken: boom") {
+		t.Errorf("Expected the scorer error in the result, got %+v, %v", report.Results[0], err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dataset, _ := ReadDataset("smoke", strings.NewReader(testDataset))
+	dataset.Cases = dataset.Cases[:2]
+	baseline, _ := NewRunner(answers(map[string]string{
+		"What is the capital of France?": "Paris",
+		"What is 2+2?":                   "5",
+	}), NewExactScorer()).WithName("v1").Run(context.Background(), dataset)
+
+	dataset.Cases = append(dataset.Cases, Case{ID: "new", Input: "Hi", Expected: "Hello"})
+	candidate, _ := NewRunner(answers(map[string]string{
+		"What is the capital of France?": "Lyon",
+		"What is 2+2?":                   "4",
+		"Hi":                             "Hello",
+	}), NewExactScorer()).WithName("v2").Run(context.Background(), dataset)
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadReport(path)
+	if err != nil || loaded.Name != "v1" || len(loaded.Results) != 2 {
+		t.Fatalf("Expected the saved report, got %+v, %v", loaded, err)
+	}
+
+	comparison := Compare(loaded, candidate, 0.1)
+	if !comparison.HasRegressions() || len(comparison.Regressions) != 2 || comparison.Regressions[0].ID != "capital" {
+		t.Errorf("Expected the capital case to regress, got %+v", comparison.Regressions)
+	}
+	if len(comparison.Improvements) != 2 || comparison.Improvements[0].ID != "sum" {
+		t.Errorf("Expected the sum case to improve, got %+v", comparison.Improvements)
+	}
+	if len(comparison.Added) != 1 || comparison.Added[0] != "new" || len(comparison.Missing) != 0 {
+		t.Errorf("Expected one added case, got %v / %v", comparison.Added, comparison.Missing)
+	}
+	if comparison.PassRateDelta <= 0 {
+		t.Errorf("Expected a higher pass rate, got %v", comparison.PassRateDelta)
+	}
+
+	var text bytes.Buffer
+	if err := comparison.WriteText(&text); err != nil || !strings.Contains(text.String(), "REGRESSION capital now fails") {
+		t.Errorf("Unexpected comparison text:\n%s", text.String())
+	}
+	if Compare(baseline, baseline, 0).HasRegressions() {
+		t.Error("Expected no regressions against itself")
+	}
+
+	// Cases that disappear from the candidate run count as regressions
+	reverse := Compare(candidate, baseline, 0.1)
+	if !reverse.HasRegressions() || len(reverse.Missing) != 1 || reverse.Missing[0] != "new" {
+		t.Errorf("Expected the missing case to count as a regression, got %+v", reverse)
+	}
+	text.Reset()
+	if err := reverse.WriteText(&text); err != nil || !strings.Contains(text.String(), "MISSING    new") {
+		t.Errorf("Unexpected comparison text:\n%s", text.String())
+	}
+}
@@ -0,0 +1,301 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	ID       string                 `json:"id"`
+	Input    string                 `json:"input"`
+	Expected interface{}            `json:"expected,omitempty"`
+	Output   string                 `json:"output"`
+	Error    string                 `json:"error,omitempty"`
+	Scores   []Score                `json:"scores"`
+	Passed   bool                   `json:"passed"`
+	Tokens   int                    `json:"tokens"`
+	Latency  time.Duration          `json:"latency"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Score returns the score of the named scorer.
+func (r CaseResult) Score(scorer string) (Score, bool) {
+	for _, score := range r.Scores {
+		if score.Scorer == scorer {
+			return score, true
+		}
+	}
+	return Score{}, false
+}
+
+// Summary aggregates the results of a run.
+type Summary struct {
+	Cases       int                `json:"cases"`
+	Passed      int                `json:"passed"`
+	Failed      int                `json:"failed"`
+	Errors      int                `json:"errors"` // Cases whose run or scoring failed
+	PassRate    float64            `json:"pass_rate"`
+	MeanScores  map[string]float64 `json:"mean_scores"`
+	TotalTokens int                `json:"total_tokens"`
+	MeanLatency time.Duration      `json:"mean_latency"`
+	P95Latency  time.Duration      `json:"p95_latency"`
+}
+
+// Report is the result of an evaluation run.
+type Report struct {
+	Name    string        `json:"name"`
+	Dataset string        `json:"dataset"`
+	Started time.Time     `json:"started"`
+	Elapsed time.Duration `json:"elapsed"`
+	Results []CaseResult  `json:"results"`
+	Summary Summary       `json:"summary"`
+}
+
+// Failures returns the results of the cases that did not pass.
+func (r *Report) Failures() []CaseResult {
+	var failures []CaseResult
+	for _, result := range r.Results {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Save writes the report as JSON.
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// LoadReport reads a report written by Save.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode report: %w", err)
+	}
+	return &report, nil
+}
+
+// WriteText writes a readable summary with the failed cases.
+func (r *Report) WriteText(w io.Writer) error {
+	s := r.Summary
+	fmt.Fprintf(w, "Evaluation %s on %s: %d/%d passed (%.1f%%), %d errors\n", r.Name, r.Dataset, s.Passed, s.Cases, s.PassRate*100, s.Errors)
+	for _, scorer := range sortedKeys(s.MeanScores) {
+		fmt.Fprintf(w, "  %-12s mean %.3f\n", scorer, s.MeanScores[scorer])
+	}
+	fmt.Fprintf(w, "  tokens %d, latency mean %v, p95 %v\n", s.TotalTokens, s.MeanLatency, s.P95Latency)
+
+	for _, result := range r.Failures() {
+		fmt.Fprintf(w, "FAIL %s\n", result.ID)
+		if result.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", result.Error)
+		}
+		for _, score := range result.Scores {
+			if !score.Passed {
+				fmt.Fprintf(w, "  %s %.3f: %s\n", score.Scorer, score.Value, score.Reason)
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// summarize aggregates the results.
+func summarize(results []CaseResult) Summary {
+	summary := Summary{Cases: len(results), MeanScores: make(map[string]float64)}
+	counts := make(map[string]int)
+	latencies := make([]time.Duration, 0, len(results))
+	var totalLatency time.Duration
+	for _, result := range results {
+		if result.Passed {
+			summary.Passed++
+		} else {
+			summary.Failed++
+		}
+		if result.Error != "" {
+			summary.Errors++
+		}
+		for _, score := range result.Scores {
+			summary.MeanScores[score.Scorer] += score.Value
+			counts[score.Scorer]++
+		}
+		summary.TotalTokens += result.Tokens
+		totalLatency += result.Latency
+		latencies = append(latencies, result.Latency)
+	}
+	for scorer, total := range summary.MeanScores {
+		summary.MeanScores[scorer] = total / float64(counts[scorer])
+	}
+	if len(results) > 0 {
+		summary.PassRate = float64(summary.Passed) / float64(len(results))
+		summary.MeanLatency = totalLatency / time.Duration(len(results))
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		summary.P95Latency = latencies[int(math.Ceil(0.95*float64(len(latencies))))-1]
+	}
+	return summary
+}
+
+// CaseChange is a change of a case between two runs. A change of the case
+// outcome has an empty Scorer.
+type CaseChange struct {
+	ID     string  `json:"id"`
+	Scorer string  `json:"scorer,omitempty"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Reason string  `json:"reason,omitempty"` // Reason of the new score
+}
+
+// Comparison is the difference between a baseline and a candidate run.
+type Comparison struct {
+	Baseline      string             `json:"baseline"`
+	Candidate     string             `json:"candidate"`
+	PassRateDelta float64            `json:"pass_rate_delta"`
+	ScoreDeltas   map[string]float64 `json:"score_deltas"`
+	TokenDelta    int                `json:"token_delta"`
+	Regressions   []CaseChange       `json:"regressions"`
+	Improvements  []CaseChange       `json:"improvements"`
+	Missing       []string           `json:"missing,omitempty"` // Cases only in the baseline
+	Added         []string           `json:"added,omitempty"`   // Cases only in the candidate
+}
+
+// HasRegressions reports whether any case got worse or is missing from the
+// candidate run.
+func (c *Comparison) HasRegressions() bool {
+	return len(c.Regressions) > 0 || len(c.Missing) > 0
+}
+
+// Compare compares a candidate run with a baseline run, case by case. A
+// case regresses when it passed before and fails now, or when a score drops
+// by more than the tolerance; improvements are the reverse.
+func Compare(baseline, candidate *Report, tolerance float64) *Comparison {
+	comparison := &Comparison{
+		Baseline:      baseline.Name,
+		Candidate:     candidate.Name,
+		PassRateDelta: candidate.Summary.PassRate - baseline.Summary.PassRate,
+		ScoreDeltas:   make(map[string]float64),
+		TokenDelta:    candidate.Summary.TotalTokens - baseline.Summary.TotalTokens,
+	}
+	for scorer, mean := range candidate.Summary.MeanScores {
+		if before, ok := baseline.Summary.MeanScores[scorer]; ok {
+			comparison.ScoreDeltas[scorer] = mean - before
+		}
+	}
+
+	before := make(map[string]CaseResult, len(baseline.Results))
+	for _, result := range baseline.Results {
+		before[result.ID] = result
+	}
+	seen := make(map[string]bool, len(candidate.Results))
+	for _, after := range candidate.Results {
+		seen[after.ID] = true
+		previous, ok := before[after.ID]
+		if !ok {
+			comparison.Added = append(comparison.Added, after.ID)
+			continue
+		}
+		comparison.compareCase(previous, after, tolerance)
+	}
+	for _, result := range baseline.Results {
+		if !seen[result.ID] {
+			comparison.Missing = append(comparison.Missing, result.ID)
+		}
+	}
+	return comparison
+}
+
+// compareCase records the changes of one case.
+func (c *Comparison) compareCase(before, after CaseResult, tolerance float64) {
+	if before.Passed != after.Passed {
+		change := CaseChange{ID: after.ID, Before: passValue(before.Passed), After: passValue(after.Passed), Reason: after.Error}
+		if after.Passed {
+			c.Improvements = append(c.Improvements, change)
+		} else {
+			c.Regressions = append(c.Regressions, change)
+		}
+	}
+	for _, score := range after.Scores {
+		previous, ok := before.Score(score.Scorer)
+		if !ok {
+			continue
+		}
+		change := CaseChange{ID: after.ID, Scorer: score.Scorer, Before: previous.Value, After: score.Value, Reason: score.Reason}
+		switch delta := score.Value - previous.Value; {
+		case delta < -tolerance:
+			c.Regressions = append(c.Regressions, change)
+		case delta > tolerance:
+			c.Improvements = append(c.Improvements, change)
+		}
+	}
+}
+
+// WriteText writes a readable summary of the comparison.
+func (c *Comparison) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%s vs %s: pass rate %+.1f%%, tokens %+d\n", c.Candidate, c.Baseline, c.PassRateDelta*100, c.TokenDelta)
+	for _, scorer := range sortedKeys(c.ScoreDeltas) {
+		fmt.Fprintf(w, "  %-12s %+.3f\n", scorer, c.ScoreDeltas[scorer])
+	}
+	for _, change := range c.Regressions {
+		fmt.Fprintf(w, "REGRESSION %s\n", describeChange(change))
+	}
+	for _, id := range c.Missing {
+		fmt.Fprintf(w, "MISSING    %s\n", id)
+	}
+	for _, change := range c.Improvements {
+		fmt.Fprintf(w, "IMPROVED   %s\n", describeChange(change))
+	}
+	if len(c.Added) > 0 {
+		fmt.Fprintf(w, "  %d cases added\n", len(c.Added))
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// describeChange formats a case change.
+func describeChange(change CaseChange) string {
+	if change.Scorer == "" {
+		outcome := "now fails"
+		if change.After > change.Before {
+			outcome = "now passes"
+		}
+		if change.Reason != "" {
+			return fmt.Sprintf("%s %s (%s)", change.ID, outcome, change.Reason)
+		}
+		return fmt.Sprintf("%s %s", change.ID, outcome)
+	}
+	return fmt.Sprintf("%s %s %.3f -> %.3f", change.ID, change.Scorer, change.Before, change.After)
+}
+
+// passValue converts a case outcome to a value for comparisons.
+func passValue(passed bool) float64 {
+	if passed {
+		return 1
+	}
+	return 0
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// Runner runs the cases of a dataset through an action and scores the
+// outputs. Every case runs in its own WorkContext with the input under
+// constants.KeyUserInput and the values of the case context.
+type Runner struct {
+	name        string
+	action      workflow.Action
+	scorers     []Scorer
+	concurrency int
+	timeout     time.Duration
+	setup       func(wctx workflow.WorkContext, c Case)
+}
+
+// NewRunner creates a runner for the action that scores with the scorers.
+// Without scorers a case passes when the action completes.
+func NewRunner(action workflow.Action, scorers ...Scorer) *Runner {
+	name := ""
+	if action != nil {
+		name = action.Name()
+	}
+	return &Runner{
+		name:        name,
+		action:      action,
+		scorers:     scorers,
+		concurrency: 4, // Default number of concurrent cases
+	}
+}
+
+// WithName sets the name of the run, e.g. the prompt version or model under
+// test. It defaults to the name of the action.
+func (r *Runner) WithName(name string) *Runner {
+	r.name = name
+	return r
+}
+
+// WithScorers adds scorers.
+func (r *Runner) WithScorers(scorers ...Scorer) *Runner {
+	r.scorers = append(r.scorers, scorers...)
+	return r
+}
+
+// WithConcurrency sets how many cases run at the same time.
+func (r *Runner) WithConcurrency(n int) *Runner {
+	if n > 0 {
+		r.concurrency = n
+	}
+	return r
+}
+
+// WithTimeout limits the time of every case.
+func (r *Runner) WithTimeout(timeout time.Duration) *Runner {
+	r.timeout = timeout
+	return r
+}
+
+// WithSetup sets a function that prepares the WorkContext of every case,
+// e.g. to add message history or a budget.
+func (r *Runner) WithSetup(setup func(wctx workflow.WorkContext, c Case)) *Runner {
+	r.setup = setup
+	return r
+}
+
+// Run runs all cases of the dataset and returns the report. Failed cases
+// are part of the report; an error is returned only when the run could not
+// be performed or was cancelled.
+func (r *Runner) Run(ctx context.Context, dataset *Dataset) (*Report, error) {
+	if r.action == nil {
+		return nil, fmt.Errorf("no action to evaluate")
+	}
+	if dataset == nil || len(dataset.Cases) == 0 {
+		return nil, fmt.Errorf("dataset has no cases")
+	}
+
+	report := &Report{
+		Name:    r.name,
+		Dataset: dataset.Name,
+		Started: time.Now(),
+		Results: make([]CaseResult, len(dataset.Cases)),
+	}
+
+	cases := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < r.concurrency && w < len(dataset.Cases); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range cases {
+				report.Results[i] = r.runCase(ctx, dataset.Cases[i])
+			}
+		}()
+	}
+	for i := range dataset.Cases {
+		if ctx.Err() != nil {
+			break
+		}
+		cases <- i
+	}
+	close(cases)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("evaluation cancelled: %w", err)
+	}
+	report.Elapsed = time.Since(report.Started)
+	report.Summary = summarize(report.Results)
+	return report, nil
+}
+
+// runCase runs and scores one case.
+func (r *Runner) runCase(ctx context.Context, c Case) CaseResult {
+	result := CaseResult{
+		ID:       c.ID,
+		Input:    c.Input,
+		Expected: c.Expected,
+		Metadata: c.Metadata,
+	}
+
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	wctx := workflow.NewWorkContext(ctx)
+	wctx.Set(constants.KeyUserInput, c.Input)
+	// Numbers become float64 like in any decoded JSON, e.g. for expression predicates
+	for key, value := range c.Context {
+		wctx.Set(key, normalizeJSON(value))
+	}
+	if r.setup != nil {
+		r.setup(wctx, c)
+	}
+
+	start := time.Now()
+	report := r.action.Run(wctx)
+	result.Latency = time.Since(start)
+	result.Tokens = tokens(report)
+
+	if report.Status == workflow.StatusFailure {
+		result.Error = "run failed"
+		if len(report.Errors) > 0 {
+			result.Error = report.Errors[0].Error()
+		}
+		for _, scorer := range r.scorers {
+			result.Scores = append(result.Scores, Score{Scorer: scorer.Name(), Reason: "run failed"})
+		}
+		return result
+	}
+	result.Output = workflow.ExtractContent(report.Data)
+
+	result.Passed = true
+	var scorerErrors []string
+	for _, scorer := range r.scorers {
+		score, err := scorer.Score(ctx, c, result.Output)
+		if err != nil {
+			scorerErrors = append(scorerErrors, fmt.Sprintf("%s: %v", scorer.Name(), err))
+			score = Score{Scorer: scorer.Name(), Reason: err.Error()}
+		}
+		score.Scorer = scorer.Name()
+		result.Passed = result.Passed && score.Passed
+		result.Scores = append(result.Scores, score)
+	}
+	if len(scorerErrors) > 0 {
+		result.Error = "scorer failed: " + strings.Join(scorerErrors, "; ")
+	}
+	return result
+}
+
+// tokens returns the tokens a report accounts for.
+func tokens(report workflow.WorkReport) int {
+	if total, ok := report.Metadata["total_tokens"].(int); ok {
+		return total
+	}
+	if usage, ok := report.Metadata["token_usage"].(llm.Usage); ok {
+		return usage.TotalTokens
+	}
+	return 0
+}
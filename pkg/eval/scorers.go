@@ -0,0 +1,462 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/output"
+)
+
+// Score is the result of one scorer for one case.
+type Score struct {
+	Scorer string  `json:"scorer"`
+	Value  float64 `json:"value"` // From 0 to 1
+	Passed bool    `json:"passed"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// Scorer scores the output of a case. An error means the output could not be
+// scored at all, e.g. because a judge model failed; outputs that are merely
+// wrong get a low score.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, c Case, output string) (Score, error)
+}
+
+// ScorerFunc adapts a function to the Scorer interface.
+type ScorerFunc struct {
+	name string
+	fn   func(ctx context.Context, c Case, output string) (Score, error)
+}
+
+// NewScorerFunc creates a scorer from a function.
+func NewScorerFunc(name string, fn func(ctx context.Context, c Case, output string) (Score, error)) *ScorerFunc {
+	return &ScorerFunc{name: name, fn: fn}
+}
+
+// Name returns the name of the scorer.
+func (s *ScorerFunc) Name() string {
+	return s.name
+}
+
+// Score calls the function.
+func (s *ScorerFunc) Score(ctx context.Context, c Case, output string) (Score, error) {
+	score, err := s.fn(ctx, c, output)
+	score.Scorer = s.name
+	return score, err
+}
+
+// binary returns a passing or failing score.
+func binary(scorer string, passed bool, reason string) Score {
+	score := Score{Scorer: scorer, Passed: passed, Reason: reason}
+	if passed {
+		score.Value = 1
+	}
+	return score
+}
+
+// ExactScorer passes outputs equal to the expected output after trimming
+// surrounding whitespace.
+type ExactScorer struct {
+	ignoreCase bool
+}
+
+// NewExactScorer creates an exact match scorer.
+func NewExactScorer() *ExactScorer {
+	return &ExactScorer{}
+}
+
+// IgnoreCase compares case-insensitively.
+func (s *ExactScorer) IgnoreCase() *ExactScorer {
+	s.ignoreCase = true
+	return s
+}
+
+// Name returns the name of the scorer.
+func (s *ExactScorer) Name() string {
+	return "exact"
+}
+
+// Score compares the output with the expected output.
+func (s *ExactScorer) Score(ctx context.Context, c Case, output string) (Score, error) {
+	got, want := strings.TrimSpace(output), strings.TrimSpace(c.ExpectedString())
+	passed := got == want
+	if s.ignoreCase {
+		passed = strings.EqualFold(got, want)
+	}
+	if passed {
+		return binary(s.Name(), true, ""), nil
+	}
+	return binary(s.Name(), false, fmt.Sprintf("expected %q", want)), nil
+}
+
+// RegexScorer passes outputs that match a regular expression.
+type RegexScorer struct {
+	pattern *regexp.Regexp
+}
+
+// NewRegexScorer creates a scorer for the pattern. An empty pattern uses the
+// expected output of each case as its pattern.
+func NewRegexScorer(pattern string) (*RegexScorer, error) {
+	if pattern == "" {
+		return &RegexScorer{}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return &RegexScorer{pattern: re}, nil
+}
+
+// Name returns the name of the scorer.
+func (s *RegexScorer) Name() string {
+	return "regex"
+}
+
+// Score matches the output against the pattern.
+func (s *RegexScorer) Score(ctx context.Context, c Case, output string) (Score, error) {
+	re := s.pattern
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(c.ExpectedString()); err != nil {
+			return Score{}, fmt.Errorf("invalid expected pattern of case %s: %w", c.ID, err)
+		}
+	}
+	if re.MatchString(output) {
+		return binary(s.Name(), true, ""), nil
+	}
+	return binary(s.Name(), false, fmt.Sprintf("no match for %s", re)), nil
+}
+
+// JSONFieldScorer compares fields of the JSON object in the output with the
+// same fields of the expected JSON object. The score is the share of
+// matching fields; the case passes when all fields match.
+type JSONFieldScorer struct {
+	fields []string
+	parser *output.JSONParser
+}
+
+// NewJSONFieldScorer creates a scorer for the fields. Nested fields are
+// separated by dots, e.g. "result.label". Without fields, every field of
+// the expected object is compared.
+func NewJSONFieldScorer(fields ...string) *JSONFieldScorer {
+	return &JSONFieldScorer{fields: fields, parser: output.NewJSONParser(nil)}
+}
+
+// Name returns the name of the scorer.
+func (s *JSONFieldScorer) Name() string {
+	return "json_field"
+}
+
+// Score compares the fields.
+func (s *JSONFieldScorer) Score(ctx context.Context, c Case, content string) (Score, error) {
+	expected, ok := normalizeJSON(c.Expected).(map[string]interface{})
+	if !ok {
+		if parsed, err := s.parser.Parse(c.ExpectedString()); err == nil {
+			expected, ok = parsed.(map[string]interface{})
+		}
+	}
+	if !ok {
+		return Score{}, fmt.Errorf("expected output of case %s is not a JSON object", c.ID)
+	}
+	parsed, err := s.parser.Parse(content)
+	if err != nil {
+		return binary(s.Name(), false, err.Error()), nil
+	}
+	actual, ok := parsed.(map[string]interface{})
+	if !ok {
+		return binary(s.Name(), false, "output is not a JSON object"), nil
+	}
+
+	fields := s.fields
+	if len(fields) == 0 {
+		for field := range expected {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+	}
+	if len(fields) == 0 {
+		return binary(s.Name(), true, ""), nil
+	}
+
+	matched := 0
+	var mismatches []string
+	for _, field := range fields {
+		want, _ := lookup(expected, field)
+		got, found := lookup(actual, field)
+		if found && equalValues(got, want) {
+			matched++
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("%s: expected %v, got %v", field, want, got))
+	}
+	score := Score{
+		Scorer: s.Name(),
+		Value:  float64(matched) / float64(len(fields)),
+		Passed: matched == len(fields),
+		Reason: strings.Join(mismatches, "; "),
+	}
+	return score, nil
+}
+
+// NumericScorer passes outputs whose first number is within a tolerance of
+// the expected number.
+type NumericScorer struct {
+	tolerance float64
+	relative  bool
+}
+
+// NewNumericScorer creates a scorer with an absolute tolerance.
+func NewNumericScorer(tolerance float64) *NumericScorer {
+	return &NumericScorer{tolerance: tolerance}
+}
+
+// Relative makes the tolerance relative to the expected number, e.g. 0.05
+// for 5%.
+func (s *NumericScorer) Relative() *NumericScorer {
+	s.relative = true
+	return s
+}
+
+// numberRe matches a decimal number, allowing thousands separators.
+var numberRe = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?|-?\.\d+`)
+
+// Name returns the name of the scorer.
+func (s *NumericScorer) Name() string {
+	return "numeric"
+}
+
+// Score compares the first number of the output with the expected number.
+func (s *NumericScorer) Score(ctx context.Context, c Case, content string) (Score, error) {
+	want, ok := toFloat(c.Expected)
+	if !ok {
+		return Score{}, fmt.Errorf("expected output of case %s is not a number", c.ID)
+	}
+	match := numberRe.FindString(content)
+	if match == "" {
+		return binary(s.Name(), false, "no number in output"), nil
+	}
+	got, err := strconv.ParseFloat(strings.ReplaceAll(match, ",", ""), 64)
+	if err != nil {
+		return binary(s.Name(), false, err.Error()), nil
+	}
+
+	tolerance := s.tolerance
+	if s.relative {
+		tolerance *= math.Abs(want)
+	}
+	if diff := math.Abs(got - want); diff > tolerance {
+		return binary(s.Name(), false, fmt.Sprintf("expected %v ± %v, got %v", want, tolerance, got)), nil
+	}
+	return binary(s.Name(), true, ""), nil
+}
+
+// EmbeddingScorer scores the cosine similarity of the output and the
+// expected output. Outputs pass at or above the threshold.
+type EmbeddingScorer struct {
+	embedder  llm.Embedder
+	threshold float64
+}
+
+// NewEmbeddingScorer creates a similarity scorer.
+func NewEmbeddingScorer(embedder llm.Embedder, threshold float64) *EmbeddingScorer {
+	return &EmbeddingScorer{embedder: embedder, threshold: threshold}
+}
+
+// Name returns the name of the scorer.
+func (s *EmbeddingScorer) Name() string {
+	return "embedding"
+}
+
+// Score embeds both texts and compares them.
+func (s *EmbeddingScorer) Score(ctx context.Context, c Case, content string) (Score, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{content, c.ExpectedString()})
+	if err != nil {
+		return Score{}, fmt.Errorf("failed to embed output: %w", err)
+	}
+	if len(vectors) != 2 {
+		return Score{}, fmt.Errorf("embedder returned %d vectors for 2 texts", len(vectors))
+	}
+	similarity := math.Max(0, llm.CosineSimilarity(vectors[0], vectors[1]))
+	return Score{
+		Scorer: s.Name(),
+		Value:  similarity,
+		Passed: similarity >= s.threshold,
+		Reason: fmt.Sprintf("similarity %.3f", similarity),
+	}, nil
+}
+
+// DefaultJudgePrompt is the system prompt of the JudgeScorer. The rubric is
+// appended to it.
+const DefaultJudgePrompt = `You are a strict evaluator. Grade the response to the input against the rubric.
+Use the reference answer, if given, as the standard for correctness.
+Respond with JSON only: {"score": <number from 0 to 1>, "reasoning": "<one or two sentences>"}`
+
+// JudgeScorer lets a model grade the output against a rubric, optionally
+// with the expected output as reference answer.
+type JudgeScorer struct {
+	client    llm.Client
+	model     string
+	rubric    string
+	prompt    string
+	threshold float64
+}
+
+// NewJudgeScorer creates an LLM-as-judge scorer. Outputs pass with a score
+// of 0.7 or more unless set otherwise with WithThreshold.
+func NewJudgeScorer(client llm.Client, model, rubric string) *JudgeScorer {
+	return &JudgeScorer{client: client, model: model, rubric: rubric, prompt: DefaultJudgePrompt, threshold: 0.7}
+}
+
+// WithThreshold sets the score an output needs to pass.
+func (s *JudgeScorer) WithThreshold(threshold float64) *JudgeScorer {
+	s.threshold = threshold
+	return s
+}
+
+// WithPrompt sets the system prompt that precedes the rubric.
+func (s *JudgeScorer) WithPrompt(prompt string) *JudgeScorer {
+	s.prompt = prompt
+	return s
+}
+
+// Name returns the name of the scorer.
+func (s *JudgeScorer) Name() string {
+	return "judge"
+}
+
+// judgmentSchema is the structured output schema of the judge.
+var judgmentSchema = &llm.JSONSchema{
+	Name:        "grade",
+	Description: "The grade of the response",
+	Strict:      true,
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"score":     map[string]interface{}{"type": "number"},
+			"reasoning": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"score", "reasoning"},
+		"additionalProperties": false,
+	},
+}
+
+// Score asks the judge for a grade.
+func (s *JudgeScorer) Score(ctx context.Context, c Case, content string) (Score, error) {
+	var task strings.Builder
+	fmt.Fprintf(&task, "Input:\n%s\n\n", c.Input)
+	if reference := c.ExpectedString(); reference != "" {
+		fmt.Fprintf(&task, "Reference answer:\n%s\n\n", reference)
+	}
+	fmt.Fprintf(&task, "Response:\n%s", content)
+
+	response, err := s.client.Complete(ctx, llm.CompletionRequest{
+		Model: s.model,
+		Messages: []llm.Message{
+			{Role: constants.RoleSystem, Content: s.prompt + "\n\nRubric:\n" + s.rubric},
+			{Role: constants.RoleUser, Content: task.String()},
+		},
+		JSONSchema:   judgmentSchema,
+		ResponseType: llm.ResponseTypeJSONSchema,
+		MaxTokens:    500,
+		Temperature:  0,
+		Metadata:     map[string]interface{}{"case_id": c.ID, "phase": "eval_judge"},
+	})
+	if err != nil {
+		return Score{}, fmt.Errorf("judge failed: %w", err)
+	}
+
+	parsed, err := output.NewJSONParser(&grade{}).WithRequired("score").Parse(response.Content)
+	if err != nil {
+		return Score{}, fmt.Errorf("judge gave no grade: %w", err)
+	}
+	g := parsed.(*grade)
+	value := math.Min(1, math.Max(0, g.Score))
+	return Score{
+		Scorer: s.Name(),
+		Value:  value,
+		Passed: value >= s.threshold,
+		Reason: g.Reasoning,
+	}, nil
+}
+
+// grade is the response of the judge.
+type grade struct {
+	Score     float64 `json:"score"`
+	Reasoning string  `json:"reasoning"`
+}
+
+// lookup returns a nested field of a JSON object.
+func lookup(object map[string]interface{}, field string) (interface{}, bool) {
+	var value interface{} = object
+	for _, key := range strings.Split(field, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// equalValues compares JSON values, numbers by value.
+func equalValues(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(normalizeJSON(a), normalizeJSON(b))
+}
+
+// normalizeJSON converts json.Number values to float64, so that values
+// decoded with and without UseNumber compare equal.
+func normalizeJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[key] = normalizeJSON(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeJSON(item)
+		}
+		return normalized
+	default:
+		return v
+	}
+}
+
+// toFloat converts a number, or a string holding one, to float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
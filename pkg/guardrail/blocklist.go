@@ -0,0 +1,94 @@
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultInjectionPatterns are regular expressions for common prompt-injection phrasings.
+var DefaultInjectionPatterns = []string{
+	`(?i)\b(ignore|disregard|forget)\b.{0,20}\b(previous|prior|above|earlier|all)\b.{0,20}\b(instructions|prompts?|rules|directions)\b`,
+	`(?i)\byou are now\b.{0,40}\b(unrestricted|jailbroken|dan|developer mode)\b`,
+	`(?i)\b(reveal|print|show|repeat)\b.{0,30}\b(system prompt|hidden instructions|initial instructions)\b`,
+	`(?i)\bpretend\b.{0,30}\b(no|without)\b.{0,20}\b(rules|restrictions|guidelines)\b`,
+}
+
+// Blocklist blocks content that contains a keyword or matches a pattern.
+type Blocklist struct {
+	name     string
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// NewKeywordBlocklist creates a blocklist of case-insensitive keywords.
+func NewKeywordBlocklist(keywords ...string) *Blocklist {
+	b := &Blocklist{name: "keyword_blocklist"}
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			b.keywords = append(b.keywords, strings.ToLower(keyword))
+		}
+	}
+	return b
+}
+
+// NewRegexBlocklist creates a blocklist of regular expressions.
+func NewRegexBlocklist(patterns ...string) (*Blocklist, error) {
+	b := &Blocklist{name: "regex_blocklist"}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist pattern %q: %w", pattern, err)
+		}
+		b.patterns = append(b.patterns, re)
+	}
+	return b, nil
+}
+
+// NewPromptInjectionBlocklist creates a blocklist of DefaultInjectionPatterns.
+func NewPromptInjectionBlocklist() *Blocklist {
+	b, err := NewRegexBlocklist(DefaultInjectionPatterns...)
+	if err != nil {
+		panic(err)
+	}
+	b.name = "prompt_injection"
+	return b
+}
+
+// WithName sets the name of the blocklist.
+func (b *Blocklist) WithName(name string) *Blocklist {
+	b.name = name
+	return b
+}
+
+// WithKeywords adds case-insensitive keywords to the blocklist.
+func (b *Blocklist) WithKeywords(keywords ...string) *Blocklist {
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			b.keywords = append(b.keywords, strings.ToLower(keyword))
+		}
+	}
+	return b
+}
+
+// Name returns the name of the blocklist.
+func (b *Blocklist) Name() string {
+	return b.name
+}
+
+// Check blocks content containing a keyword or matching a pattern.
+func (b *Blocklist) Check(ctx context.Context, check Check) (Result, error) {
+	lower := strings.ToLower(check.Content)
+	for _, keyword := range b.keywords {
+		if strings.Contains(lower, keyword) {
+			return Blocked(fmt.Sprintf("contains blocked keyword %q", keyword)), nil
+		}
+	}
+	for _, re := range b.patterns {
+		if re.MatchString(check.Content) {
+			return Blocked(fmt.Sprintf("matches blocked pattern %q", re.String())), nil
+		}
+	}
+	return Allowed(), nil
+}
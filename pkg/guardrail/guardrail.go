@@ -0,0 +1,185 @@
+// Package guardrail provides checks that agents run on input messages, tool
+// results and final output. A guardrail can allow content, modify it (for
+// example to redact PII) or block it with a typed error.
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Stage identifies where in an agent run a guardrail is applied.
+type Stage string
+
+const (
+	// StageInput applies to user messages before they are sent to the provider.
+	StageInput Stage = "input"
+
+	// StageToolResult applies to tool results before they are returned to the model.
+	StageToolResult Stage = "tool_result"
+
+	// StageOutput applies to the final output of the agent.
+	StageOutput Stage = "output"
+)
+
+// Verdict is the decision of a guardrail.
+type Verdict string
+
+const (
+	// Allow passes the content on unchanged.
+	Allow Verdict = "allow"
+
+	// Modify replaces the content, e.g. with a redacted version.
+	Modify Verdict = "modify"
+
+	// Block rejects the content and stops the agent run.
+	Block Verdict = "block"
+)
+
+// ErrBlocked is matched by errors.Is for every BlockedError.
+var ErrBlocked = errors.New("blocked by guardrail")
+
+// Check is the content a guardrail is asked to evaluate.
+type Check struct {
+	Stage    Stage
+	Content  string
+	Agent    string // Name of the agent running the guardrail
+	Role     string // Message role for the input stage
+	ToolName string // Tool name for the tool result stage
+}
+
+// Result is the outcome of a guardrail check.
+type Result struct {
+	Verdict Verdict
+	Content string // Replacement content when the verdict is Modify
+	Reason  string
+}
+
+// Allowed returns a result that passes the content on unchanged.
+func Allowed() Result {
+	return Result{Verdict: Allow}
+}
+
+// Modified returns a result that replaces the content.
+func Modified(content, reason string) Result {
+	return Result{Verdict: Modify, Content: content, Reason: reason}
+}
+
+// Blocked returns a result that rejects the content.
+func Blocked(reason string) Result {
+	return Result{Verdict: Block, Reason: reason}
+}
+
+// Guardrail checks content at one or more stages of an agent run.
+type Guardrail interface {
+	// Name returns the name of the guardrail, used in errors and metadata.
+	Name() string
+
+	// Check evaluates the content. An error means the check itself failed and
+	// is treated like a block.
+	Check(ctx context.Context, check Check) (Result, error)
+}
+
+// BlockedError is returned when a guardrail blocks content.
+type BlockedError struct {
+	Guardrail string
+	Stage     Stage
+	Reason    string
+	ToolName  string
+}
+
+// Error implements the error interface.
+func (e *BlockedError) Error() string {
+	if e.ToolName != "" {
+		return fmt.Sprintf("guardrail %s blocked %s of tool %s: %s", e.Guardrail, e.Stage, e.ToolName, e.Reason)
+	}
+	return fmt.Sprintf("guardrail %s blocked %s: %s", e.Guardrail, e.Stage, e.Reason)
+}
+
+// Is reports whether target is ErrBlocked.
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// IsBlocked reports whether err was caused by a guardrail block.
+func IsBlocked(err error) bool {
+	return errors.Is(err, ErrBlocked)
+}
+
+// Func adapts a function to the Guardrail interface.
+type Func struct {
+	name string
+	fn   func(ctx context.Context, check Check) (Result, error)
+}
+
+// NewFunc creates a guardrail from a function.
+func NewFunc(name string, fn func(ctx context.Context, check Check) (Result, error)) *Func {
+	return &Func{name: name, fn: fn}
+}
+
+// Name returns the name of the guardrail.
+func (f *Func) Name() string {
+	return f.name
+}
+
+// Check calls the function.
+func (f *Func) Check(ctx context.Context, check Check) (Result, error) {
+	return f.fn(ctx, check)
+}
+
+// staged restricts a guardrail to some stages.
+type staged struct {
+	Guardrail
+	stages []Stage
+}
+
+// OnStages restricts a guardrail to the given stages. Guardrails run at all
+// stages unless restricted.
+func OnStages(g Guardrail, stages ...Stage) Guardrail {
+	return &staged{Guardrail: g, stages: stages}
+}
+
+// Check runs the wrapped guardrail only for its stages.
+func (s *staged) Check(ctx context.Context, check Check) (Result, error) {
+	for _, stage := range s.stages {
+		if stage == check.Stage {
+			return s.Guardrail.Check(ctx, check)
+		}
+	}
+	return Allowed(), nil
+}
+
+// Outcome is the result of running a pipeline.
+type Outcome struct {
+	Content  string
+	Modified []string // Names of the guardrails that modified the content
+}
+
+// Pipeline runs guardrails in order. Modifications are passed on to the next
+// guardrail and the first block stops the pipeline.
+type Pipeline []Guardrail
+
+// Run checks the content with every guardrail in the pipeline. A block is
+// returned as a *BlockedError.
+func (p Pipeline) Run(ctx context.Context, check Check) (Outcome, error) {
+	outcome := Outcome{Content: check.Content}
+	for _, g := range p {
+		check.Content = outcome.Content
+		result, err := g.Check(ctx, check)
+		if err != nil {
+			return outcome, &BlockedError{Guardrail: g.Name(), Stage: check.Stage, Reason: err.Error(), ToolName: check.ToolName}
+		}
+
+		switch result.Verdict {
+		case Block:
+			return outcome, &BlockedError{Guardrail: g.Name(), Stage: check.Stage, Reason: result.Reason, ToolName: check.ToolName}
+		case Modify:
+			if result.Content != outcome.Content {
+				outcome.Content = result.Content
+				outcome.Modified = append(outcome.Modified, g.Name())
+			}
+		}
+	}
+	return outcome, nil
+}
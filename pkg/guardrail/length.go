@@ -0,0 +1,46 @@
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxLength limits content to a number of characters.
+type MaxLength struct {
+	max      int
+	truncate bool
+}
+
+// NewMaxLength creates a guardrail that blocks content longer than max characters.
+// A negative max is treated as 0.
+func NewMaxLength(max int) *MaxLength {
+	if max < 0 {
+		max = 0
+	}
+	return &MaxLength{max: max}
+}
+
+// WithTruncate truncates long content instead of blocking it.
+func (m *MaxLength) WithTruncate() *MaxLength {
+	m.truncate = true
+	return m
+}
+
+// Name returns the name of the guardrail.
+func (m *MaxLength) Name() string {
+	return "max_length"
+}
+
+// Check blocks or truncates content longer than the limit.
+func (m *MaxLength) Check(ctx context.Context, check Check) (Result, error) {
+	length := utf8.RuneCountInString(check.Content)
+	if length <= m.max {
+		return Allowed(), nil
+	}
+	if m.truncate {
+		runes := []rune(check.Content)
+		return Modified(string(runes[:m.max]), fmt.Sprintf("truncated from %d to %d characters", length, m.max)), nil
+	}
+	return Blocked(fmt.Sprintf("length %d exceeds maximum of %d characters", length, m.max)), nil
+}
@@ -0,0 +1,162 @@
+package guardrail
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// PIIType identifies a kind of personally identifiable information.
+type PIIType string
+
+const (
+	PIIEmail PIIType = "email"
+	PIIPhone PIIType = "phone"
+	PIICard  PIIType = "card"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{2,4}\)|\d{2,4})[\s.\-]?\d{3,4}[\s.\-]?\d{3,4}\b`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+)
+
+// PIIRedactor replaces emails, phone numbers and card numbers with placeholders.
+type PIIRedactor struct {
+	types       []PIIType
+	placeholder func(PIIType) string
+	block       bool
+}
+
+// NewPIIRedactor creates a redactor for emails, phone numbers and card numbers.
+// Card numbers are only redacted when they pass the Luhn checksum.
+func NewPIIRedactor() *PIIRedactor {
+	return &PIIRedactor{
+		types: []PIIType{PIICard, PIIEmail, PIIPhone},
+		placeholder: func(t PIIType) string {
+			return "[REDACTED_" + strings.ToUpper(string(t)) + "]"
+		},
+	}
+}
+
+// WithTypes limits the redactor to the given PII types.
+func (p *PIIRedactor) WithTypes(types ...PIIType) *PIIRedactor {
+	p.types = types
+	return p
+}
+
+// WithPlaceholder sets the function that returns the replacement for a PII type.
+func (p *PIIRedactor) WithPlaceholder(placeholder func(PIIType) string) *PIIRedactor {
+	p.placeholder = placeholder
+	return p
+}
+
+// WithBlock blocks content containing PII instead of redacting it.
+func (p *PIIRedactor) WithBlock() *PIIRedactor {
+	p.block = true
+	return p
+}
+
+// Name returns the name of the guardrail.
+func (p *PIIRedactor) Name() string {
+	return "pii_redactor"
+}
+
+// Check redacts or blocks PII in the content.
+func (p *PIIRedactor) Check(ctx context.Context, check Check) (Result, error) {
+	content := check.Content
+	var found []string
+
+	// Cards are redacted first so their digits are not mistaken for phone numbers
+	for _, t := range p.types {
+		var re *regexp.Regexp
+		var valid func(string) bool
+		switch t {
+		case PIIEmail:
+			re = emailPattern
+		case PIIPhone:
+			re, valid = phonePattern, phoneValid
+		case PIICard:
+			re, valid = cardPattern, luhnValid
+		default:
+			continue
+		}
+
+		var sb strings.Builder
+		last := 0
+		for _, loc := range re.FindAllStringIndex(content, -1) {
+			match := content[loc[0]:loc[1]]
+			// Matches inside longer digit runs are not PII of this type
+			if valid != nil && (!valid(match) || isDigitAt(content, loc[0]-1) || isDigitAt(content, loc[1])) {
+				continue
+			}
+			sb.WriteString(content[last:loc[0]])
+			sb.WriteString(p.placeholder(t))
+			last = loc[1]
+			found = append(found, string(t))
+		}
+		sb.WriteString(content[last:])
+		content = sb.String()
+	}
+
+	if len(found) == 0 {
+		return Allowed(), nil
+	}
+	reason := "redacted " + strings.Join(unique(found), ", ")
+	if p.block {
+		return Blocked("contains " + strings.Join(unique(found), ", ")), nil
+	}
+	return Modified(content, reason), nil
+}
+
+// phoneValid reports whether a match has a plausible number of phone digits.
+func phoneValid(s string) bool {
+	digits := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// isDigitAt reports whether s has an ASCII digit at index i.
+func isDigitAt(s string, i int) bool {
+	return i >= 0 && i < len(s) && s[i] >= '0' && s[i] <= '9'
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, count := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		count++
+	}
+	return count >= 13 && sum%10 == 0
+}
+
+// unique returns the distinct values in order of first appearance.
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
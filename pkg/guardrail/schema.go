@@ -0,0 +1,287 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// JSONSchema blocks content that is not JSON valid against a schema.
+type JSONSchema struct {
+	schema map[string]interface{}
+	strip  bool
+}
+
+// NewJSONSchema creates a guardrail that validates content against a JSON schema.
+// It supports the common subset of JSON Schema used for structured output:
+// type, properties, required, additionalProperties, items, enum, const,
+// minLength, maxLength, pattern, minimum, maximum, minItems and maxItems.
+func NewJSONSchema(schema map[string]interface{}) *JSONSchema {
+	return &JSONSchema{schema: schema}
+}
+
+// NewJSONSchemaFromLLM creates a guardrail from a structured output schema.
+func NewJSONSchemaFromLLM(schema *llm.JSONSchema) *JSONSchema {
+	return NewJSONSchema(schema.Schema)
+}
+
+// WithCodeFenceStripping accepts JSON wrapped in a Markdown code fence and
+// replaces the content with the bare JSON.
+func (j *JSONSchema) WithCodeFenceStripping() *JSONSchema {
+	j.strip = true
+	return j
+}
+
+// Name returns the name of the guardrail.
+func (j *JSONSchema) Name() string {
+	return "json_schema"
+}
+
+// Check blocks content that is not valid JSON or does not match the schema.
+func (j *JSONSchema) Check(ctx context.Context, check Check) (Result, error) {
+	content := strings.TrimSpace(check.Content)
+	stripped := false
+	if j.strip && strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSpace(strings.TrimSuffix(content, "```"))
+		stripped = true
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return Blocked(fmt.Sprintf("invalid JSON: %v", err)), nil
+	}
+	if err := ValidateSchema(value, j.schema); err != nil {
+		return Blocked(err.Error()), nil
+	}
+	if stripped {
+		return Modified(content, "removed code fence"), nil
+	}
+	return Allowed(), nil
+}
+
+// ValidateSchema validates a decoded JSON value against a JSON schema subset.
+func ValidateSchema(value interface{}, schema map[string]interface{}) error {
+	return validate("$", value, schema)
+}
+
+func validate(path string, value interface{}, schema map[string]interface{}) error {
+	if schema == nil {
+		return nil
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if hasType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), typeName(value))
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if !inEnum(value, enum) {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(normalize(constant), value) {
+		return fmt.Errorf("%s: value must be %v", path, constant)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateObject(path, v, schema)
+	case []interface{}:
+		if min, ok := number(schema["minItems"]); ok && float64(len(v)) < min {
+			return fmt.Errorf("%s: expected at least %g items, got %d", path, min, len(v))
+		}
+		if max, ok := number(schema["maxItems"]); ok && float64(len(v)) > max {
+			return fmt.Errorf("%s: expected at most %g items, got %d", path, max, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validate(fmt.Sprintf("%s[%d]", path, i), item, items); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if min, ok := number(schema["minLength"]); ok && length < min {
+			return fmt.Errorf("%s: expected at least %g characters", path, min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && length > max {
+			return fmt.Errorf("%s: expected at most %g characters", path, max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern %q: %w", path, pattern, err)
+			}
+			if !re.MatchString(v) {
+				return fmt.Errorf("%s: value does not match pattern %q", path, pattern)
+			}
+		}
+	case float64:
+		if min, ok := number(schema["minimum"]); ok && v < min {
+			return fmt.Errorf("%s: value %g is less than minimum %g", path, v, min)
+		}
+		if max, ok := number(schema["maximum"]); ok && v > max {
+			return fmt.Errorf("%s: value %g is greater than maximum %g", path, v, max)
+		}
+	}
+	return nil
+}
+
+func validateObject(path string, obj map[string]interface{}, schema map[string]interface{}) error {
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propSchema, known := properties[key].(map[string]interface{})
+		if !known {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				return fmt.Errorf("%s: unexpected property %q", path, key)
+			}
+			if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				propSchema = additional
+			}
+		}
+		if err := validate(path+"."+key, obj[key], propSchema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns the allowed types of a schema's "type" keyword.
+func schemaTypes(t interface{}) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	default:
+		return stringList(v)
+	}
+}
+
+// stringList converts []string or []interface{} of strings to []string.
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(value interface{}, enum interface{}) bool {
+	rv := reflect.ValueOf(enum)
+	if rv.Kind() != reflect.Slice {
+		return false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if reflect.DeepEqual(normalize(rv.Index(i).Interface()), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize converts Go numbers to float64 so schema values written in Go
+// compare equal to decoded JSON values.
+func normalize(v interface{}) interface{} {
+	if f, ok := number(v); ok {
+		return f
+	}
+	return v
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"context"
+	"math"
+)
+
+// Embedder defines the interface for turning text into embedding vectors.
+// Users implement this interface with their preferred embedding provider.
+type Embedder interface {
+	// Embed returns one embedding vector per input text, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors in the range [-1, 1].
+// It returns 0 when the vectors differ in length or either of them has zero magnitude.
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
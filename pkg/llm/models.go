@@ -0,0 +1,43 @@
+package llm
+
+import "strings"
+
+// contextWindows holds the context window sizes, in tokens, of well-known
+// model families. More specific prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1-mini", 128000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude-", 200000},
+	{"gemini-1.5", 1048576},
+	{"gemini-2", 1048576},
+	{"mistral-large", 128000},
+	{"llama3.1", 128000},
+	{"llama3", 8192},
+}
+
+// ContextWindow returns the context window size in tokens of a well-known
+// model, or 0 if the model is unknown. A provider prefix such as "openai/" is
+// ignored.
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, window := range contextWindows {
+		if strings.HasPrefix(model, window.prefix) {
+			return window.tokens
+		}
+	}
+	return 0
+}
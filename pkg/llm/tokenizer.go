@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"unicode/utf8"
+)
+
+// Tokenizer counts the number of tokens a piece of text occupies for a model.
+// Users can implement this interface with a provider-specific tokenizer
+// (e.g. tiktoken) when exact counts are required.
+type Tokenizer interface {
+	// CountTokens returns the number of tokens in the given text.
+	CountTokens(text string) int
+}
+
+// ApproxTokenizer estimates token counts from the character length of the text.
+// It is dependency-free and intentionally conservative, which makes it suitable
+// for budgeting when the exact tokenizer of the model is not available.
+type ApproxTokenizer struct {
+	charsPerToken float64
+}
+
+// NewApproxTokenizer creates a new ApproxTokenizer that assumes roughly four
+// characters per token, which is a common approximation for English text.
+func NewApproxTokenizer() *ApproxTokenizer {
+	return &ApproxTokenizer{charsPerToken: 4}
+}
+
+// WithCharsPerToken sets the number of characters assumed per token.
+func (t *ApproxTokenizer) WithCharsPerToken(chars float64) *ApproxTokenizer {
+	if chars > 0 {
+		t.charsPerToken = chars
+	}
+	return t
+}
+
+// CountTokens returns the estimated number of tokens in the given text.
+func (t *ApproxTokenizer) CountTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	count := int(float64(utf8.RuneCountInString(text))/t.charsPerToken + 0.5)
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// messageOverheadTokens approximates the per-message framing tokens
+// (role markers, separators) that providers add around each message.
+const messageOverheadTokens = 4
+
+// CountMessageTokens returns the estimated number of tokens used by the messages,
+// including a small per-message overhead for role and formatting markers.
+func CountMessageTokens(tokenizer Tokenizer, messages []Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += tokenizer.CountTokens(msg.Content) + tokenizer.CountTokens(msg.Name) + messageOverheadTokens
+	}
+	return total
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// SummaryPrefix marks a system message that holds a rolling summary of earlier
+// conversation turns. Strategies recognise it so that repeated compaction folds
+// the previous summary into the next one instead of stacking summaries.
+const SummaryPrefix = "Summary of the earlier conversation:\n"
+
+// Strategy decides how a conversation history is reduced to fit a token budget.
+// Implementations must keep the relative order of messages and must never
+// separate an assistant tool call from the tool results that follow it.
+type Strategy interface {
+	// Fit returns a history that fits within maxTokens. If the history already
+	// fits, it should be returned unchanged.
+	Fit(ctx context.Context, messages []llm.Message, maxTokens int) ([]llm.Message, error)
+}
+
+// Completer performs a completion on behalf of a strategy. Agents store one in
+// the context under constants.KeyCompleter so that the completions of
+// strategies count against their budgets.
+type Completer func(ctx context.Context, client llm.Client, req llm.CompletionRequest) (*llm.CompletionResponse, error)
+
+// complete performs a completion with the Completer stored in the context, or
+// directly with the client if there is none.
+func complete(ctx context.Context, client llm.Client, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	if completer, ok := ctx.Value(constants.KeyCompleter).(Completer); ok && completer != nil {
+		return completer(ctx, client, req)
+	}
+	return client.Complete(ctx, req)
+}
+
+// turn is a contiguous group of messages that must be kept or removed together.
+// A turn starts at a user message and includes every assistant, tool and
+// non-leading system message up to the next user message.
+type turn []llm.Message
+
+// split separates the history into pinned leading system messages, the text of
+// an existing rolling summary (if any), and the remaining conversation turns.
+func split(messages []llm.Message) (pinned []llm.Message, summary string, turns []turn) {
+	i := 0
+	for ; i < len(messages) && messages[i].Role == constants.RoleSystem; i++ {
+		if IsSummary(messages[i]) {
+			summary = strings.TrimPrefix(messages[i].Content, SummaryPrefix)
+			continue
+		}
+		pinned = append(pinned, messages[i])
+	}
+
+	var current turn
+	for _, msg := range messages[i:] {
+		if msg.Role == constants.RoleUser && len(current) > 0 {
+			turns = append(turns, current)
+			current = nil
+		}
+		current = append(current, msg)
+	}
+	if len(current) > 0 {
+		turns = append(turns, current)
+	}
+
+	return pinned, summary, turns
+}
+
+// join flattens turns back into a message slice.
+func join(turns []turn) []llm.Message {
+	var messages []llm.Message
+	for _, t := range turns {
+		messages = append(messages, t...)
+	}
+	return messages
+}
+
+// IsSummary reports whether the message is a rolling summary produced by a Strategy.
+func IsSummary(msg llm.Message) bool {
+	return msg.Role == constants.RoleSystem && strings.HasPrefix(msg.Content, SummaryPrefix)
+}
+
+// NewSummaryMessage creates the system message that carries a rolling summary.
+func NewSummaryMessage(summary string) llm.Message {
+	return llm.Message{
+		Role:    constants.RoleSystem,
+		Content: SummaryPrefix + summary,
+	}
+}
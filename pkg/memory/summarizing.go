@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// DefaultSummaryPrompt is the system prompt used to compress older turns.
+const DefaultSummaryPrompt = `You maintain a running summary of a conversation between a user and an assistant.
+Merge the existing summary (if any) with the new conversation excerpt into a single concise summary.
+Preserve names, identifiers, numbers, decisions, open questions and commitments. Do not invent facts.
+Respond with the summary text only.`
+
+// SummarizingMemory compresses the oldest conversation turns into a rolling
+// system-level summary once the history exceeds its token budget.
+// The most recent turns are always kept verbatim.
+type SummarizingMemory struct {
+	client           llm.Client
+	model            string
+	prompt           string
+	tokenizer        llm.Tokenizer
+	keepRecent       int
+	summaryMaxTokens int
+}
+
+// NewSummarizingMemory creates a new SummarizingMemory that uses the given client
+// and model to produce summaries.
+func NewSummarizingMemory(client llm.Client, model string) *SummarizingMemory {
+	return &SummarizingMemory{
+		client:           client,
+		model:            model,
+		prompt:           DefaultSummaryPrompt,
+		tokenizer:        llm.NewApproxTokenizer(),
+		keepRecent:       4,   // Default number of recent turns kept verbatim
+		summaryMaxTokens: 512, // Default max tokens for the summary itself
+	}
+}
+
+// WithPrompt sets the system prompt used for summarization.
+func (sm *SummarizingMemory) WithPrompt(prompt string) *SummarizingMemory {
+	sm.prompt = prompt
+	return sm
+}
+
+// WithTokenizer sets the tokenizer used to measure the history.
+func (sm *SummarizingMemory) WithTokenizer(tokenizer llm.Tokenizer) *SummarizingMemory {
+	sm.tokenizer = tokenizer
+	return sm
+}
+
+// WithKeepRecent sets how many of the most recent turns are kept verbatim.
+func (sm *SummarizingMemory) WithKeepRecent(turns int) *SummarizingMemory {
+	sm.keepRecent = turns
+	return sm
+}
+
+// WithSummaryMaxTokens sets the maximum number of tokens the summary may use.
+func (sm *SummarizingMemory) WithSummaryMaxTokens(maxTokens int) *SummarizingMemory {
+	sm.summaryMaxTokens = maxTokens
+	return sm
+}
+
+// Fit summarizes the oldest turns when the history exceeds maxTokens.
+// Leading system messages are preserved, an existing summary is folded into the
+// new one, and at least the latest turn is always kept verbatim.
+func (sm *SummarizingMemory) Fit(ctx context.Context, messages []llm.Message, maxTokens int) ([]llm.Message, error) {
+	if maxTokens <= 0 || llm.CountMessageTokens(sm.tokenizer, messages) <= maxTokens {
+		return messages, nil
+	}
+	if sm.client == nil {
+		return nil, fmt.Errorf("summarizing memory: no LLM client configured")
+	}
+
+	pinned, previousSummary, turns := split(messages)
+
+	// Keep as many recent turns as allowed that still leave room for the summary
+	keep := sm.keepRecent
+	if keep > len(turns) {
+		keep = len(turns)
+	}
+	pinnedTokens := llm.CountMessageTokens(sm.tokenizer, pinned)
+	for keep > 1 {
+		recentTokens := llm.CountMessageTokens(sm.tokenizer, join(turns[len(turns)-keep:]))
+		if pinnedTokens+recentTokens+sm.summaryMaxTokens <= maxTokens {
+			break
+		}
+		keep--
+	}
+	if keep < 1 && len(turns) > 0 {
+		keep = 1
+	}
+
+	older := turns[:len(turns)-keep]
+	recent := turns[len(turns)-keep:]
+	if len(older) == 0 {
+		// Nothing left to compress - the recent turns alone exceed the budget
+		return messages, nil
+	}
+
+	summary, err := sm.summarize(ctx, previousSummary, join(older))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]llm.Message, 0, len(pinned)+1+len(recent))
+	result = append(result, pinned...)
+	result = append(result, NewSummaryMessage(summary))
+	result = append(result, join(recent)...)
+	return result, nil
+}
+
+// summarize asks the model to merge the previous summary with the given messages.
+func (sm *SummarizingMemory) summarize(ctx context.Context, previousSummary string, messages []llm.Message) (string, error) {
+	var excerpt strings.Builder
+	if previousSummary != "" {
+		excerpt.WriteString("Existing summary:\n")
+		excerpt.WriteString(previousSummary)
+		excerpt.WriteString("\n\n")
+	}
+	excerpt.WriteString("New conversation excerpt:\n")
+	excerpt.WriteString(FormatTranscript(messages))
+
+	req := llm.CompletionRequest{
+		Model: sm.model,
+		Messages: []llm.Message{
+			{Role: constants.RoleSystem, Content: sm.prompt},
+			{Role: constants.RoleUser, Content: excerpt.String()},
+		},
+		MaxTokens:   sm.summaryMaxTokens,
+		Temperature: 0,
+		Metadata: map[string]interface{}{
+			"memory_strategy": "summarizing",
+		},
+	}
+
+	response, err := complete(ctx, sm.client, req)
+	if err != nil {
+		return "", fmt.Errorf("summarizing memory: summary completion failed: %w", err)
+	}
+
+	return strings.TrimSpace(response.Content), nil
+}
+
+// FormatTranscript renders messages as plain "role: content" lines for prompts.
+func FormatTranscript(messages []llm.Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		role := msg.Role
+		if msg.Role == constants.RoleTool && msg.Name != "" {
+			role = fmt.Sprintf("%s (%s)", msg.Role, msg.Name)
+		}
+		sb.WriteString(role)
+		sb.WriteString(": ")
+		sb.WriteString(msg.Content)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// mockSummaryClient records summary requests and returns a fixed summary
+type mockSummaryClient struct {
+	requests []llm.CompletionRequest
+	summary  string
+}
+
+func (m *mockSummaryClient) Complete(ctx context.Context, req llm.CompletionRequest) (*llm.CompletionResponse, error) {
+	m.requests = append(m.requests, req)
+	return &llm.CompletionResponse{Content: m.summary}, nil
+}
+
+func (m *mockSummaryClient) Close() error {
+	return nil
+}
+
+func longHistory() []llm.Message {
+	filler := strings.Repeat("lorem ipsum ", 40)
+	return []llm.Message{
+		{Role: constants.RoleSystem, Content: "You are a support agent."},
+		{Role: constants.RoleUser, Content: "My order id is 42. " + filler},
+		{Role: constants.RoleAssistant, Content: "Let me check. " + filler},
+		{Role: constants.RoleTool, Name: "lookup_order", Content: "order 42 shipped " + filler},
+		{Role: constants.RoleUser, Content: "When will it arrive? " + filler},
+		{Role: constants.RoleAssistant, Content: "Tomorrow. " + filler},
+		{Role: constants.RoleUser, Content: "Thanks!"},
+		{Role: constants.RoleAssistant, Content: "You're welcome."},
+	}
+}
+
+func TestSummarizingMemory_UnderBudget(t *testing.T) {
+	client := &mockSummaryClient{summary: "unused"}
+	mem := NewSummarizingMemory(client, "test-model")
+
+	history := longHistory()
+	result, err := mem.Fit(context.Background(), history, 100000)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result) != len(history) {
+		t.Errorf("Expected history to be unchanged, got %d messages", len(result))
+	}
+	if len(client.requests) != 0 {
+		t.Errorf("Expected no summary requests, got %d", len(client.requests))
+	}
+}
+
+func TestSummarizingMemory_SummarizesOldestTurns(t *testing.T) {
+	client := &mockSummaryClient{summary: "User asked about order 42."}
+	mem := NewSummarizingMemory(client, "test-model").
+		WithKeepRecent(1).
+		WithSummaryMaxTokens(50)
+
+	result, err := mem.Fit(context.Background(), longHistory(), 200)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(client.requests) != 1 {
+		t.Fatalf("Expected 1 summary request, got %d", len(client.requests))
+	}
+	if client.requests[0].Model != "test-model" {
+		t.Errorf("Expected summary model 'test-model', got '%s'", client.requests[0].Model)
+	}
+
+	// Pinned system prompt, summary, then the last turn verbatim
+	if len(result) != 4 {
+		t.Fatalf("Expected 4 messages, got %d: %+v", len(result), result)
+	}
+	if result[0].Content != "You are a support agent." {
+		t.Errorf("Expected system prompt to be preserved, got '%s'", result[0].Content)
+	}
+	if !IsSummary(result[1]) || !strings.Contains(result[1].Content, "order 42") {
+		t.Errorf("Expected summary message, got '%s'", result[1].Content)
+	}
+	if result[2].Content != "Thanks!" || result[3].Content != "You're welcome." {
+		t.Errorf("Expected most recent turn to be kept verbatim, got %+v", result[2:])
+	}
+}
+
+func TestSummarizingMemory_KeepsToolResultsWithCall(t *testing.T) {
+	client := &mockSummaryClient{summary: "summary"}
+	mem := NewSummarizingMemory(client, "test-model").WithKeepRecent(3)
+
+	result, err := mem.Fit(context.Background(), longHistory(), 400)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i, msg := range result {
+		if msg.Role == constants.RoleTool {
+			if i == 0 || result[i-1].Role != constants.RoleAssistant {
+				t.Errorf("Tool result at %d was separated from its assistant call", i)
+			}
+		}
+	}
+}
+
+func TestSummarizingMemory_FoldsPreviousSummary(t *testing.T) {
+	client := &mockSummaryClient{summary: "merged summary"}
+	mem := NewSummarizingMemory(client, "test-model").WithKeepRecent(1)
+
+	history := append([]llm.Message{NewSummaryMessage("earlier facts")}, longHistory()[1:]...)
+	result, err := mem.Fit(context.Background(), history, 200)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	excerpt := client.requests[0].Messages[1].Content
+	if !strings.Contains(excerpt, "earlier facts") {
+		t.Error("Expected previous summary to be included in the summary request")
+	}
+
+	summaries := 0
+	for _, msg := range result {
+		if IsSummary(msg) {
+			summaries++
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("Expected exactly 1 summary message, got %d", summaries)
+	}
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// TrimmingMemory drops the oldest conversation turns once the history exceeds
+// its token budget. Leading system messages and a rolling summary are always
+// kept, and whole turns are dropped so that tool calls stay with their results.
+type TrimmingMemory struct {
+	tokenizer llm.Tokenizer
+	maxTurns  int
+}
+
+// NewTrimmingMemory creates a new TrimmingMemory that drops the oldest turns
+// until the history fits.
+func NewTrimmingMemory() *TrimmingMemory {
+	return &TrimmingMemory{tokenizer: llm.NewApproxTokenizer()}
+}
+
+// WithTokenizer sets the tokenizer used to measure the history.
+func (tm *TrimmingMemory) WithTokenizer(tokenizer llm.Tokenizer) *TrimmingMemory {
+	tm.tokenizer = tokenizer
+	return tm
+}
+
+// WithMaxTurns keeps at most the given number of recent turns, even when more
+// would fit. Zero keeps as many turns as fit.
+func (tm *TrimmingMemory) WithMaxTurns(turns int) *TrimmingMemory {
+	tm.maxTurns = turns
+	return tm
+}
+
+// Fit drops the oldest turns until the history fits within maxTokens. The
+// latest turn is always kept, so the result may still exceed maxTokens when
+// that turn alone does.
+func (tm *TrimmingMemory) Fit(ctx context.Context, messages []llm.Message, maxTokens int) ([]llm.Message, error) {
+	pinned, summary, turns := split(messages)
+	if summary != "" {
+		pinned = append(pinned, NewSummaryMessage(summary))
+	}
+
+	keep := len(turns)
+	if tm.maxTurns > 0 && keep > tm.maxTurns {
+		keep = tm.maxTurns
+	}
+	if maxTokens > 0 {
+		used := llm.CountMessageTokens(tm.tokenizer, pinned)
+		for _, t := range turns[len(turns)-keep:] {
+			used += llm.CountMessageTokens(tm.tokenizer, t)
+		}
+		for keep > 1 && used > maxTokens {
+			used -= llm.CountMessageTokens(tm.tokenizer, turns[len(turns)-keep])
+			keep--
+		}
+	}
+	if keep == len(turns) {
+		return messages, nil
+	}
+
+	result := make([]llm.Message, 0, len(messages))
+	result = append(result, pinned...)
+	result = append(result, join(turns[len(turns)-keep:])...)
+	return result, nil
+}
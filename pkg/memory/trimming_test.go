@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+func TestTrimmingMemory_DropsOldestTurns(t *testing.T) {
+	history := longHistory()
+	mem := NewTrimmingMemory()
+
+	result, err := mem.Fit(context.Background(), history, 100000)
+	if err != nil || len(result) != len(history) {
+		t.Fatalf("Expected history to be unchanged, got %d messages, %v", len(result), err)
+	}
+
+	// The first turn with its tool call and result is dropped as a whole
+	result, err = mem.Fit(context.Background(), history, 300)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result) != 5 || result[0].Role != constants.RoleSystem || result[1].Content != history[4].Content {
+		t.Fatalf("Expected the system prompt and the last two turns, got %+v", result)
+	}
+	for _, msg := range result {
+		if msg.Role == constants.RoleTool {
+			t.Errorf("Expected the tool result to be dropped with its call, got %+v", msg)
+		}
+	}
+	if tokens := llm.CountMessageTokens(llm.NewApproxTokenizer(), result); tokens > 300 {
+		t.Errorf("Expected the result to fit 300 tokens, got %d", tokens)
+	}
+
+	// The latest turn is kept even when it does not fit
+	result, _ = mem.Fit(context.Background(), history, 10)
+	if len(result) != 3 || result[2].Content != "You're welcome." {
+		t.Errorf("Expected the system prompt and the latest turn, got %+v", result)
+	}
+}
+
+func TestTrimmingMemory_MaxTurns(t *testing.T) {
+	history := append([]llm.Message{NewSummaryMessage("User asked about order 42.")}, longHistory()[1:]...)
+	history = append([]llm.Message{{Role: constants.RoleSystem, Content: "You are a support agent."}}, history...)
+
+	result, err := NewTrimmingMemory().WithMaxTurns(1).Fit(context.Background(), history, 100000)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result) != 4 || !IsSummary(result[1]) || result[2].Content != "Thanks!" {
+		t.Errorf("Expected the system prompt, the summary and the last turn, got %+v", result)
+	}
+}
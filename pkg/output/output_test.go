@@ -0,0 +1,111 @@
+package output
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodeBlockParser(t *testing.T) {
+	content := "Here you go:\n```python\nprint('hi')\n```\nand\n```sql\nSELECT 1;\n```"
+
+	code, err := NewCodeBlockParser("sql").Parse(content)
+	if err != nil || code != "SELECT 1;" {
+		t.Errorf("Expected the sql block, got %q, %v", code, err)
+	}
+	blocks, err := NewCodeBlockParser("").All().Parse(content)
+	if err != nil || len(blocks.([]CodeBlock)) != 2 || blocks.([]CodeBlock)[0].Language != "python" {
+		t.Errorf("Expected both blocks, got %v, %v", blocks, err)
+	}
+	if _, err := NewCodeBlockParser("go").Parse(content); err == nil {
+		t.Error("Expected an error without a go block")
+	}
+}
+
+func TestJSONParser(t *testing.T) {
+	type ticket struct {
+		Category string   `json:"category"`
+		Priority int      `json:"priority"`
+		Tags     []string `json:"tags"`
+	}
+
+	content := `Sure! The ticket {looks} like this: {"category": "billing", "priority": 2, "tags": ["refund"]} Let me know.`
+	value, err := NewJSONParser(&ticket{}).WithRequired("category").Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := value.(*ticket); got.Category != "billing" || got.Priority != 2 || got.Tags[0] != "refund" {
+		t.Errorf("Unexpected ticket %+v", got)
+	}
+
+	value, err = NewJSONParser(nil).Parse("```json\n[1, 2]\n```")
+	if err != nil || len(value.([]interface{})) != 2 {
+		t.Errorf("Expected the fenced array, got %v, %v", value, err)
+	}
+
+	_, err = NewJSONParser(nil).WithRequired("priority").Parse(`{"category": "billing"}`)
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Parser != "json" {
+		t.Errorf("Expected a ParseError for the missing field, got %v", err)
+	}
+	if _, err := NewJSONParser(nil).Parse("no json at all"); err == nil {
+		t.Error("Expected an error without JSON")
+	}
+
+	// Earlier values that do not fit the target are skipped
+	prose := `Per the docs [1], here: {"a": 1}`
+	if value, err := NewJSONParser(nil).WithRequired("a").Parse(prose); err != nil || value.(map[string]interface{})["a"] != 1.0 {
+		t.Errorf("Expected the object after the citation, got %v, %v", value, err)
+	}
+	prose = `Per the docs [1], here: {"category": "billing"}`
+	if value, err := NewJSONParser(&ticket{}).Parse(prose); err != nil || value.(*ticket).Category != "billing" {
+		t.Errorf("Expected the ticket after the citation, got %v, %v", value, err)
+	}
+	_, err = NewJSONParser(nil).WithRequired("a").Parse(`Per the docs [1], here: {"b": 1}`)
+	if err == nil || !strings.Contains(err.Error(), `missing required field "a"`) {
+		t.Errorf("Expected the missing field of the object, got %v", err)
+	}
+}
+
+func TestListParser(t *testing.T) {
+	content := "Steps:\n1. Open the app\n2) Go to settings\n   and pick Billing\n- Cancel\n\nThat's it."
+	items, err := NewListParser().Parse(content)
+	want := []string{"Open the app", "Go to settings and pick Billing", "Cancel"}
+	if err != nil || !reflect.DeepEqual(items, want) {
+		t.Errorf("Expected %v, got %v, %v", want, items, err)
+	}
+	if _, err := NewListParser().WithMinItems(4).Parse(content); err == nil {
+		t.Error("Expected an error for too few items")
+	}
+}
+
+func TestKeyValueParser(t *testing.T) {
+	content := "Here is the summary:\n**Name**: Ada Lovelace\n- Due Date = Friday\nStatus: open"
+	pairs, err := NewKeyValueParser("name", "Due Date").Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	got := pairs.(map[string]string)
+	if got["name"] != "Ada Lovelace" || got["due_date"] != "Friday" || got["status"] != "open" {
+		t.Errorf("Unexpected pairs %v", got)
+	}
+	if _, err := NewKeyValueParser("owner").Parse(content); err == nil {
+		t.Error("Expected an error for a missing key")
+	}
+}
+
+func TestXMLTagParser(t *testing.T) {
+	content := "<thinking>Check the dates.</thinking>\n<answer>\n  Friday\n</answer>"
+	sections, err := NewXMLTagParser("answer").WithOptional("thinking", "sources").Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	got := sections.(map[string]string)
+	if got["answer"] != "Friday" || got["thinking"] != "Check the dates." || len(got) != 2 {
+		t.Errorf("Unexpected sections %v", got)
+	}
+	if _, err := NewXMLTagParser("answer").Parse("<answer>unterminated"); err == nil {
+		t.Error("Expected an error for an unterminated section")
+	}
+}
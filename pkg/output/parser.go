@@ -0,0 +1,63 @@
+// Package output parses the text of model responses into typed values:
+// fenced code blocks, JSON embedded in prose, bullet lists, key-value pairs
+// and XML-tagged sections. Parsers are attached to agents with
+// WithOutputParser, which puts the parsed value into the work report and the
+// WorkContext and can ask the model to fix responses that do not parse.
+package output
+
+import "fmt"
+
+// Parser converts the content of a model response into a typed value.
+type Parser interface {
+	// Parse returns the parsed value, or an error describing why the content
+	// does not have the expected format.
+	Parse(content string) (interface{}, error)
+
+	// FormatInstructions describes the expected format to the model. It is
+	// used when the model is asked to fix a response that did not parse.
+	FormatInstructions() string
+}
+
+// ParseError reports that content did not have the format a parser expects.
+type ParseError struct {
+	Parser  string
+	Content string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s parser: %v", e.Parser, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError creates a ParseError.
+func parseError(parser, content string, format string, args ...interface{}) error {
+	return &ParseError{Parser: parser, Content: content, Err: fmt.Errorf(format, args...)}
+}
+
+// ParserFunc adapts a function to the Parser interface.
+type ParserFunc struct {
+	fn           func(content string) (interface{}, error)
+	instructions string
+}
+
+// NewParserFunc creates a parser from a function and the format instructions
+// given to the model when a response does not parse.
+func NewParserFunc(fn func(content string) (interface{}, error), instructions string) *ParserFunc {
+	return &ParserFunc{fn: fn, instructions: instructions}
+}
+
+// Parse calls the function.
+func (p *ParserFunc) Parse(content string) (interface{}, error) {
+	return p.fn(content)
+}
+
+// FormatInstructions returns the instructions of the parser.
+func (p *ParserFunc) FormatInstructions() string {
+	return p.instructions
+}
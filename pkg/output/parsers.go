@@ -0,0 +1,343 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// fenceRe matches fenced Markdown code blocks and captures the language and code.
+var fenceRe = regexp.MustCompile("(?s)```[ \t]*([\\w+#.-]*)[^\\n]*\\n(.*?)```")
+
+// CodeBlock is a fenced code block of a response.
+type CodeBlock struct {
+	Language string
+	Code     string
+}
+
+// CodeBlockParser extracts fenced code blocks. It returns the code of the
+// first block as a string, or all blocks as []CodeBlock.
+type CodeBlockParser struct {
+	language string
+	all      bool
+}
+
+// NewCodeBlockParser creates a parser for code blocks in the given language.
+// An empty language matches any block.
+func NewCodeBlockParser(language string) *CodeBlockParser {
+	return &CodeBlockParser{language: language}
+}
+
+// All returns every matching block as []CodeBlock instead of the first code.
+func (p *CodeBlockParser) All() *CodeBlockParser {
+	p.all = true
+	return p
+}
+
+// Parse extracts the code blocks.
+func (p *CodeBlockParser) Parse(content string) (interface{}, error) {
+	var blocks []CodeBlock
+	for _, match := range fenceRe.FindAllStringSubmatch(content, -1) {
+		if p.language != "" && !strings.EqualFold(match[1], p.language) {
+			continue
+		}
+		blocks = append(blocks, CodeBlock{Language: match[1], Code: strings.TrimRight(match[2], "\n")})
+	}
+	if len(blocks) == 0 {
+		if p.language != "" {
+			return nil, parseError("code block", content, "no %s code block found", p.language)
+		}
+		return nil, parseError("code block", content, "no code block found")
+	}
+	if p.all {
+		return blocks, nil
+	}
+	return blocks[0].Code, nil
+}
+
+// FormatInstructions describes the expected code block.
+func (p *CodeBlockParser) FormatInstructions() string {
+	if p.language != "" {
+		return fmt.Sprintf("Put the code in a fenced Markdown code block marked as %s (```%s ... ```).", p.language, p.language)
+	}
+	return "Put the code in a fenced Markdown code block (``` ... ```)."
+}
+
+// JSONParser extracts a JSON value from a response, also when it is wrapped
+// in prose or a code block, and decodes it into a new value of the target type.
+type JSONParser struct {
+	target   reflect.Type
+	required []string
+}
+
+// NewJSONParser creates a parser that decodes into a new value of the type
+// of target, e.g. &Ticket{}, and returns a pointer to it. A nil target
+// decodes into map[string]interface{} or []interface{}.
+func NewJSONParser(target interface{}) *JSONParser {
+	p := &JSONParser{}
+	if target != nil {
+		p.target = reflect.TypeOf(target)
+		if p.target.Kind() == reflect.Ptr {
+			p.target = p.target.Elem()
+		}
+	}
+	return p
+}
+
+// WithRequired requires the fields to be present in the JSON object.
+func (p *JSONParser) WithRequired(fields ...string) *JSONParser {
+	p.required = append(p.required, fields...)
+	return p
+}
+
+// Parse decodes the first JSON value found in the content that fits the
+// target type and has the required fields. When no value fits, the error of
+// the largest value is returned.
+func (p *JSONParser) Parse(content string) (interface{}, error) {
+	candidates := findJSON(content)
+	if len(candidates) == 0 {
+		return nil, parseError("json", content, "no JSON value found")
+	}
+
+	var failure error
+	var failureSize int
+	for _, raw := range candidates {
+		value, err := p.decode(raw)
+		if err == nil {
+			return value, nil
+		}
+		if len(raw) > failureSize {
+			failure, failureSize = err, len(raw)
+		}
+	}
+	return nil, parseError("json", content, "%v", failure)
+}
+
+// decode checks the required fields of a JSON value and decodes it into a
+// new value of the target type.
+func (p *JSONParser) decode(raw json.RawMessage) (interface{}, error) {
+	if len(p.required) > 0 {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, fmt.Errorf("expected a JSON object")
+		}
+		for _, field := range p.required {
+			if _, ok := fields[field]; !ok {
+				return nil, fmt.Errorf("missing required field %q", field)
+			}
+		}
+	}
+
+	if p.target == nil {
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	value := reflect.New(p.target)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+// FormatInstructions describes the expected JSON.
+func (p *JSONParser) FormatInstructions() string {
+	instructions := "Respond with a single valid JSON value and nothing else."
+	if len(p.required) > 0 {
+		instructions += fmt.Sprintf(" The JSON object must contain the fields %s.", strings.Join(p.required, ", "))
+	}
+	return instructions
+}
+
+// findJSON returns the complete JSON objects and arrays in the content in
+// order, those of fenced json blocks first. Values nested in another value
+// are not returned on their own.
+func findJSON(content string) []json.RawMessage {
+	sources := []string{}
+	for _, match := range fenceRe.FindAllStringSubmatch(content, -1) {
+		if match[1] == "" || strings.EqualFold(match[1], "json") {
+			sources = append(sources, match[2])
+		}
+	}
+	sources = append(sources, content)
+
+	var values []json.RawMessage
+	for _, source := range sources {
+		for i := 0; i < len(source); i++ {
+			if source[i] != '{' && source[i] != '[' {
+				continue
+			}
+			dec := json.NewDecoder(strings.NewReader(source[i:]))
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err == nil {
+				values = append(values, raw)
+				i += int(dec.InputOffset()) - 1
+			}
+		}
+	}
+	return values
+}
+
+// listItemRe matches bullet and numbered list items.
+var listItemRe = regexp.MustCompile(`^\s*(?:[-*•+]|\d+[.)])\s+(.*)$`)
+
+// ListParser extracts the items of a bullet or numbered list as []string.
+// Indented lines following an item continue it.
+type ListParser struct {
+	minItems int
+}
+
+// NewListParser creates a list parser.
+func NewListParser() *ListParser {
+	return &ListParser{minItems: 1}
+}
+
+// WithMinItems sets the minimum number of items.
+func (p *ListParser) WithMinItems(n int) *ListParser {
+	p.minItems = n
+	return p
+}
+
+// Parse extracts the list items.
+func (p *ListParser) Parse(content string) (interface{}, error) {
+	var items []string
+	continuing := false
+	for _, line := range strings.Split(content, "\n") {
+		if match := listItemRe.FindStringSubmatch(line); match != nil {
+			items = append(items, strings.TrimSpace(match[1]))
+			continuing = true
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continuing = false
+		case continuing && line != trimmed:
+			items[len(items)-1] += " " + trimmed
+		default:
+			continuing = false
+		}
+	}
+	if len(items) < p.minItems {
+		return nil, parseError("list", content, "expected at least %d list items, found %d", p.minItems, len(items))
+	}
+	return items, nil
+}
+
+// FormatInstructions describes the expected list.
+func (p *ListParser) FormatInstructions() string {
+	return "Respond with a bullet list, one item per line starting with \"- \"."
+}
+
+// KeyValueParser extracts "key: value" and "key = value" lines as
+// map[string]string. Keys are lowercased with spaces replaced by
+// underscores, so "Due Date: Friday" is stored under "due_date".
+type KeyValueParser struct {
+	required []string
+}
+
+// NewKeyValueParser creates a key-value parser that requires the given keys.
+func NewKeyValueParser(required ...string) *KeyValueParser {
+	return &KeyValueParser{required: required}
+}
+
+// Parse extracts the key-value pairs.
+func (p *KeyValueParser) Parse(content string) (interface{}, error) {
+	pairs := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		if match := listItemRe.FindStringSubmatch(line); match != nil {
+			line = match[1]
+		}
+		sep := strings.IndexAny(line, ":=")
+		if sep <= 0 {
+			continue
+		}
+		// Long keys are prose that happens to contain a colon
+		key := normalizeKey(line[:sep])
+		if key == "" || len(key) > 40 {
+			continue
+		}
+		pairs[key] = strings.Trim(strings.TrimSpace(line[sep+1:]), "*")
+	}
+	for _, key := range p.required {
+		if _, ok := pairs[normalizeKey(key)]; !ok {
+			return nil, parseError("key-value", content, "missing key %q", key)
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, parseError("key-value", content, "no key-value pairs found")
+	}
+	return pairs, nil
+}
+
+// FormatInstructions describes the expected pairs.
+func (p *KeyValueParser) FormatInstructions() string {
+	instructions := "Respond with one \"key: value\" pair per line."
+	if len(p.required) > 0 {
+		instructions += fmt.Sprintf(" Include the keys %s.", strings.Join(p.required, ", "))
+	}
+	return instructions
+}
+
+// normalizeKey lowercases a key, strips Markdown emphasis and joins words
+// with underscores.
+func normalizeKey(key string) string {
+	key = strings.Trim(strings.TrimSpace(key), "*_`")
+	return strings.ToLower(strings.Join(strings.Fields(key), "_"))
+}
+
+// XMLTagParser extracts the content of XML-tagged sections such as
+// <answer>...</answer> as map[string]string.
+type XMLTagParser struct {
+	tags     []string
+	optional map[string]bool
+}
+
+// NewXMLTagParser creates a parser for the given tags, which are required
+// unless marked optional.
+func NewXMLTagParser(tags ...string) *XMLTagParser {
+	return &XMLTagParser{tags: tags, optional: make(map[string]bool)}
+}
+
+// WithOptional adds tags that may be missing.
+func (p *XMLTagParser) WithOptional(tags ...string) *XMLTagParser {
+	for _, tag := range tags {
+		p.tags = append(p.tags, tag)
+		p.optional[tag] = true
+	}
+	return p
+}
+
+// Parse extracts the tagged sections. When a tag occurs several times the
+// first occurrence is used.
+func (p *XMLTagParser) Parse(content string) (interface{}, error) {
+	sections := make(map[string]string)
+	for _, tag := range p.tags {
+		open, end := "<"+tag+">", "</"+tag+">"
+		start := strings.Index(content, open)
+		if start >= 0 {
+			rest := content[start+len(open):]
+			if stop := strings.Index(rest, end); stop >= 0 {
+				sections[tag] = strings.TrimSpace(rest[:stop])
+				continue
+			}
+		}
+		if !p.optional[tag] {
+			return nil, parseError("xml", content, "missing <%s> section", tag)
+		}
+	}
+	return sections, nil
+}
+
+// FormatInstructions describes the expected sections.
+func (p *XMLTagParser) FormatInstructions() string {
+	var tags []string
+	for _, tag := range p.tags {
+		tags = append(tags, fmt.Sprintf("<%s>...</%s>", tag, tag))
+	}
+	return "Wrap the sections of your response in the tags " + strings.Join(tags, ", ") + "."
+}
@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+func TestTemplate_Variables(t *testing.T) {
+	tmpl := MustNew("support", `Hello {{.name}}.{{if .vip}} Priority support.{{end}}
+{{range .orders}}- {{.id}} {{$.currency}}{{end}}{{with .tier}}{{.level}}{{end}} {{upper .region}}`)
+
+	want := []string{"currency", "name", "orders", "region", "tier", "vip"}
+	if got := tmpl.Variables(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected variables %v, got %v", want, got)
+	}
+}
+
+func TestTemplate_RenderContext(t *testing.T) {
+	type inputs struct {
+		Customer string `prompt:"customer"`
+	}
+	tmpl := MustNew("support", "Help {{.customer}} in {{.language}} about {{.topic}}.").
+		WithDefault("topic", "billing")
+
+	ctx := workflow.NewWorkContext(context.Background())
+	ctx.Set("language", "French")
+	ctx.Set("customer", "ignored")
+
+	got, err := tmpl.RenderContext(ctx, inputs{Customer: "Ada"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got != "Help Ada in French about billing." {
+		t.Errorf("Unexpected rendering: %q", got)
+	}
+}
+
+func TestTemplate_MissingVariables(t *testing.T) {
+	tmpl := MustNew("greeting", "Hi {{.first}} {{.last}}").WithVersion("2")
+
+	_, err := tmpl.Render(map[string]interface{}{"first": "Ada"})
+	var missing *MissingVariablesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("Expected MissingVariablesError, got %v", err)
+	}
+	if missing.Template != "greeting@2" || !reflect.DeepEqual(missing.Missing, []string{"last"}) {
+		t.Errorf("Unexpected error details: %+v", missing)
+	}
+}
+
+func TestRegistry_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"support@1.tmpl":    "You are a support agent.",
+		"support@1.10.tmpl": "---\ndescription: Newest\n---\nYou help {{.customer}}.",
+		"support@1.9.tmpl":  "Older.",
+		"triage.json":       `{"name": "triage", "version": "3", "template": "Route {{.topic}}", "defaults": {"topic": "all"}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := NewRegistry()
+	if err := registry.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+
+	latest, err := registry.Get("support")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if latest.Version() != "1.10" || latest.Description() != "Newest" || latest.Text() != "You help {{.customer}}." {
+		t.Errorf("Expected version 1.10 to be latest, got %s %q", latest.Version(), latest.Text())
+	}
+
+	pinned, err := registry.Get("support@1.9")
+	if err != nil || pinned.Text() != "Older." {
+		t.Errorf("Expected pinned version, got %v, %v", pinned, err)
+	}
+
+	triage, _ := registry.Get("triage@3")
+	if got, _ := triage.Render(nil); got != "Route all" {
+		t.Errorf("Expected JSON defaults to apply, got %q", got)
+	}
+
+	if _, err := registry.Get("support@9"); err == nil {
+		t.Error("Expected error for unknown version")
+	}
+	if err := registry.Register(MustNew("triage", "dup").WithVersion("3")); err == nil {
+		t.Error("Expected error for duplicate registration")
+	}
+}
@@ -0,0 +1,237 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds named, versioned prompt templates. Templates are referenced
+// as "name@version", or by "name" alone for the latest version.
+type Registry struct {
+	mu      sync.RWMutex
+	prompts map[string]map[string]*Template
+}
+
+// NewRegistry creates an empty prompt registry.
+func NewRegistry() *Registry {
+	return &Registry{prompts: make(map[string]map[string]*Template)}
+}
+
+// Register adds a template. Registering the same name and version twice is an error.
+func (r *Registry) Register(t *Template) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.prompts[t.Name()]
+	if !ok {
+		versions = make(map[string]*Template)
+		r.prompts[t.Name()] = versions
+	}
+	if _, exists := versions[t.Version()]; exists {
+		return fmt.Errorf("prompt %s is already registered", t.Ref())
+	}
+	versions[t.Version()] = t
+	return nil
+}
+
+// Get returns the template for a reference, "name@version" or "name" for the latest version.
+func (r *Registry) Get(ref string) (*Template, error) {
+	name, version, pinned := strings.Cut(ref, "@")
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, ok := r.prompts[name]
+	if !ok {
+		return nil, fmt.Errorf("prompt %s not found", name)
+	}
+	if pinned {
+		t, ok := versions[version]
+		if !ok {
+			return nil, fmt.Errorf("prompt %s has no version %s", name, version)
+		}
+		return t, nil
+	}
+
+	sorted := sortedVersions(versions)
+	return versions[sorted[len(sorted)-1]], nil
+}
+
+// Versions returns the versions of a prompt, oldest first.
+func (r *Registry) Versions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedVersions(r.prompts[name])
+}
+
+// Names returns the sorted names of the registered prompts.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.prompts))
+	for name := range r.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadFile loads and registers the prompt in a file.
+func (r *Registry) LoadFile(path string) error {
+	t, err := LoadFile(path)
+	if err != nil {
+		return err
+	}
+	return r.Register(t)
+}
+
+// LoadDir loads and registers every prompt file (.json, .tmpl, .prompt, .txt, .md) in a directory tree.
+func (r *Registry) LoadDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".tmpl", ".prompt", ".txt", ".md":
+			return r.LoadFile(path)
+		}
+		return nil
+	})
+}
+
+// promptFile is the JSON representation of a prompt.
+type promptFile struct {
+	Name        string                 `json:"name"`
+	Version     string                 `json:"version"`
+	Description string                 `json:"description"`
+	Template    string                 `json:"template"`
+	Defaults    map[string]interface{} `json:"defaults"`
+}
+
+// LoadFile loads a prompt from a file. JSON files contain name, version,
+// description, template and defaults fields. Other files contain the template
+// text, optionally preceded by front matter:
+//
+//	---
+//	name: support
+//	version: 2
+//	description: Support agent system prompt
+//	---
+//	You help {{.customer_name}} with their order.
+//
+// The name defaults to the file name, and a file named "support@2.tmpl"
+// has version 2 unless the front matter sets one.
+func LoadFile(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	defaultName, defaultVersion, _ := strings.Cut(base, "@")
+	file := promptFile{Name: defaultName, Version: defaultVersion}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid prompt file %s: %w", path, err)
+		}
+	} else {
+		text, meta := splitFrontMatter(string(data))
+		file.Template = text
+		if name := meta["name"]; name != "" {
+			file.Name = name
+		}
+		if version := meta["version"]; version != "" {
+			file.Version = version
+		}
+		file.Description = meta["description"]
+	}
+
+	t, err := New(file.Name, file.Template)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t.WithVersion(file.Version).WithDescription(file.Description).WithDefaults(file.Defaults), nil
+}
+
+// splitFrontMatter separates "key: value" front matter from the template text.
+func splitFrontMatter(content string) (string, map[string]string) {
+	meta := make(map[string]string)
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return content, meta
+	}
+
+	rest := normalized[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return content, meta
+	}
+
+	for _, line := range strings.Split(rest[:end], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		meta[strings.TrimSpace(key)] = value
+	}
+
+	body := rest[end+len("\n---"):]
+	body = strings.TrimPrefix(body, "\n")
+	return body, meta
+}
+
+// sortedVersions returns versions in ascending order. Dotted numeric
+// versions ("1.10" > "1.9", "v2" > "v1") compare numerically.
+func sortedVersions(versions map[string]*Template) []string {
+	sorted := make([]string, 0, len(versions))
+	for v := range versions {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return compareVersions(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
+
+// compareVersions compares two versions part by part.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		if i >= len(pa) {
+			return -1
+		}
+		if i >= len(pb) {
+			return 1
+		}
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case pa[i] != pb[i]:
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
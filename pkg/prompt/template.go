@@ -0,0 +1,297 @@
+// Package prompt provides prompt templates rendered per run from WorkContext
+// values and typed inputs, and a registry of named, versioned prompts.
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/workflow"
+)
+
+// Funcs are the functions available in prompt templates.
+var Funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// MissingVariablesError is returned when variables used by a template have no value.
+type MissingVariablesError struct {
+	Template string
+	Missing  []string
+}
+
+// Error implements the error interface.
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("prompt %s: missing variables: %s", e.Template, strings.Join(e.Missing, ", "))
+}
+
+// Template is a prompt written as a Go text/template. Top-level fields such as
+// {{.customer_name}} are the template variables.
+type Template struct {
+	name        string
+	version     string
+	description string
+	text        string
+	tmpl        *template.Template
+	variables   []string
+	defaults    map[string]interface{}
+}
+
+// New parses a prompt template.
+func New(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(Funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %s: %w", name, err)
+	}
+
+	return &Template{
+		name:      name,
+		text:      text,
+		tmpl:      tmpl,
+		variables: templateVariables(tmpl),
+		defaults:  make(map[string]interface{}),
+	}, nil
+}
+
+// MustNew parses a prompt template and panics on error. It is intended for
+// templates defined in code.
+func MustNew(name, text string) *Template {
+	t, err := New(name, text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// WithVersion sets the version of the template.
+func (t *Template) WithVersion(version string) *Template {
+	t.version = version
+	return t
+}
+
+// WithDescription sets a description of the template.
+func (t *Template) WithDescription(description string) *Template {
+	t.description = description
+	return t
+}
+
+// WithDefault sets a default value for a variable.
+func (t *Template) WithDefault(variable string, value interface{}) *Template {
+	t.defaults[variable] = value
+	return t
+}
+
+// WithDefaults sets default values for variables.
+func (t *Template) WithDefaults(defaults map[string]interface{}) *Template {
+	for k, v := range defaults {
+		t.defaults[k] = v
+	}
+	return t
+}
+
+// Name returns the name of the template.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Version returns the version of the template.
+func (t *Template) Version() string {
+	return t.version
+}
+
+// Description returns the description of the template.
+func (t *Template) Description() string {
+	return t.description
+}
+
+// Text returns the template source.
+func (t *Template) Text() string {
+	return t.text
+}
+
+// Ref returns the registry reference of the template, "name@version".
+func (t *Template) Ref() string {
+	if t.version == "" {
+		return t.name
+	}
+	return t.name + "@" + t.version
+}
+
+// Variables returns the sorted names of the variables used by the template.
+func (t *Template) Variables() []string {
+	return append([]string(nil), t.variables...)
+}
+
+// Render renders the template with the given inputs. Inputs may be a
+// map[string]interface{} or a struct; struct fields are available by name and
+// by their `prompt` or `json` tag.
+func (t *Template) Render(inputs interface{}) (string, error) {
+	return t.render(nil, inputs)
+}
+
+// RenderContext renders the template for a run. Variables are resolved from
+// the inputs first, then from WorkContext values stored under the variable
+// name, then from the template defaults.
+func (t *Template) RenderContext(wctx workflow.WorkContext, inputs interface{}) (string, error) {
+	return t.render(wctx, inputs)
+}
+
+func (t *Template) render(wctx workflow.WorkContext, inputs interface{}) (string, error) {
+	values, err := toMap(inputs)
+	if err != nil {
+		return "", fmt.Errorf("prompt %s: %w", t.name, err)
+	}
+
+	data := make(map[string]interface{}, len(t.variables))
+	var missing []string
+	for _, variable := range t.variables {
+		if value, ok := values[variable]; ok {
+			data[variable] = value
+			continue
+		}
+		if wctx != nil {
+			if value, ok := wctx.Get(variable); ok {
+				data[variable] = value
+				continue
+			}
+		}
+		if value, ok := t.defaults[variable]; ok {
+			data[variable] = value
+			continue
+		}
+		missing = append(missing, variable)
+	}
+	if len(missing) > 0 {
+		return "", &MissingVariablesError{Template: t.Ref(), Missing: missing}
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", t.Ref(), err)
+	}
+	return buf.String(), nil
+}
+
+// toMap converts template inputs to a map.
+func toMap(inputs interface{}) (map[string]interface{}, error) {
+	if inputs == nil {
+		return map[string]interface{}{}, nil
+	}
+	if m, ok := inputs.(map[string]interface{}); ok {
+		return m, nil
+	}
+	if m, ok := inputs.(map[string]string); ok {
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			out[k] = v
+		}
+		return out, nil
+	}
+
+	v := reflect.ValueOf(inputs)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return map[string]interface{}{}, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("inputs must be a map or a struct, got %T", inputs)
+	}
+
+	out := make(map[string]interface{})
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i).Interface()
+		out[field.Name] = value
+		for _, tag := range []string{"prompt", "json"} {
+			if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+				out[name] = value
+			}
+		}
+	}
+	return out, nil
+}
+
+// templateVariables returns the top-level fields referenced by a template.
+func templateVariables(tmpl *template.Template) []string {
+	seen := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Tree.Root != nil {
+			walk(t.Tree.Root, true, seen)
+		}
+	}
+
+	variables := make([]string, 0, len(seen))
+	for name := range seen {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// walk collects variables. Inside range and with blocks the dot no longer
+// refers to the template data, so only $-rooted fields are collected there.
+func walk(node parse.Node, root bool, seen map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walk(child, root, seen)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, root, seen)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				walk(arg, root, seen)
+			}
+		}
+	case *parse.FieldNode:
+		if root && len(n.Ident) > 0 {
+			seen[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			seen[n.Ident[1]] = true
+		}
+	case *parse.ChainNode:
+		walk(n.Node, root, seen)
+	case *parse.IfNode:
+		walk(n.Pipe, root, seen)
+		walk(n.List, root, seen)
+		walk(n.ElseList, root, seen)
+	case *parse.RangeNode:
+		walk(n.Pipe, root, seen)
+		walk(n.List, false, seen)
+		walk(n.ElseList, root, seen)
+	case *parse.WithNode:
+		walk(n.Pipe, root, seen)
+		walk(n.List, false, seen)
+		walk(n.ElseList, root, seen)
+	case *parse.TemplateNode:
+		walk(n.Pipe, root, seen)
+	}
+}
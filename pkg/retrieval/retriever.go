@@ -0,0 +1,115 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+)
+
+// Chunk is a ranked piece of content returned by a Retriever.
+type Chunk struct {
+	ID       string                 `json:"id"`
+	Content  string                 `json:"content"`
+	Score    float64                `json:"score"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Source returns the "source" metadata value of the chunk, if present.
+func (c Chunk) Source() string {
+	if c.Metadata == nil {
+		return ""
+	}
+	if source, ok := c.Metadata["source"].(string); ok {
+		return source
+	}
+	return ""
+}
+
+// Retriever finds the chunks most relevant to a query.
+// Users implement this interface with their preferred search backend
+// (vector database, search engine, in-memory store, ...).
+type Retriever interface {
+	// Retrieve returns up to limit chunks ranked by descending relevance.
+	Retrieve(ctx context.Context, query string, limit int) ([]Chunk, error)
+}
+
+// RetrieverFunc adapts an ordinary function to the Retriever interface.
+type RetrieverFunc func(ctx context.Context, query string, limit int) ([]Chunk, error)
+
+// Retrieve calls the underlying function.
+func (f RetrieverFunc) Retrieve(ctx context.Context, query string, limit int) ([]Chunk, error) {
+	return f(ctx, query, limit)
+}
+
+// Citation identifies a chunk that was used to ground a response.
+type Citation struct {
+	Index    int                    `json:"index"` // 1-based position in the prompt, e.g. [1]
+	ID       string                 `json:"id"`
+	Source   string                 `json:"source,omitempty"`
+	Score    float64                `json:"score"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Citations builds the citation list for the given chunks in prompt order.
+func Citations(chunks []Chunk) []Citation {
+	citations := make([]Citation, 0, len(chunks))
+	for i, chunk := range chunks {
+		citations = append(citations, Citation{
+			Index:    i + 1,
+			ID:       chunk.ID,
+			Source:   chunk.Source(),
+			Score:    chunk.Score,
+			Metadata: chunk.Metadata,
+		})
+	}
+	return citations
+}
+
+// DefaultPromptTemplate is the template used to put retrieved chunks into the prompt.
+// It receives a PromptData value.
+const DefaultPromptTemplate = `Answer the question using the context below. Cite sources by their number, e.g. [1].
+If the context does not contain the answer, say that you don't know.
+
+Context:
+{{range .Chunks}}[{{.Index}}]{{if .Source}} ({{.Source}}){{end}} {{.Content}}
+{{end}}
+Question: {{.Query}}`
+
+// PromptData is the data passed to the prompt template.
+type PromptData struct {
+	Query  string
+	Chunks []PromptChunk
+}
+
+// PromptChunk is a chunk as exposed to the prompt template.
+type PromptChunk struct {
+	Chunk
+	Index int
+}
+
+// ParseTemplate parses a prompt template for use with RenderPrompt.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("retrieval").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retrieval prompt template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderPrompt renders the template with the query and chunks.
+func RenderPrompt(tmpl *template.Template, query string, chunks []Chunk) (string, error) {
+	data := PromptData{Query: query}
+	for i, chunk := range chunks {
+		data.Chunks = append(data.Chunks, PromptChunk{
+			Chunk: chunk,
+			Index: i + 1,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render retrieval prompt: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps each session as a JSON file in a directory. Writes go to a
+// temporary file that is renamed into place, so a crash never leaves a
+// partially written session. The version check is only safe within one
+// process; use the SQL store when several processes share sessions.
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file of a session. IDs are escaped so that they cannot
+// leave the directory.
+func (f *FileStore) path(id string) string {
+	return filepath.Join(f.dir, url.PathEscape(id)+".json")
+}
+
+// Load reads the session file.
+func (f *FileStore) Load(ctx context.Context, id string) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.read(id)
+}
+
+func (f *FileStore) read(id string) (*Session, error) {
+	data, err := os.ReadFile(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", id, err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode session %s: %w", id, err)
+	}
+	if s.State == nil {
+		s.State = make(map[string]interface{})
+	}
+	return &s, nil
+}
+
+// Save writes the session file.
+func (f *FileStore) Save(ctx context.Context, s *Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var current int64
+	stored, err := f.read(s.ID)
+	switch {
+	case err == nil:
+		current = stored.Version
+	case !errors.Is(err, ErrNotFound):
+		return err
+	}
+	if current != s.Version {
+		return ErrConflict
+	}
+
+	next := *s
+	next.Version++
+	next.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(&next, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", s.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(f.dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session %s: %w", s.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session %s: %w", s.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session %s: %w", s.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path(s.ID)); err != nil {
+		return fmt.Errorf("failed to write session %s: %w", s.ID, err)
+	}
+
+	s.Version, s.UpdatedAt = next.Version, next.UpdatedAt
+	return nil
+}
+
+// Delete removes the session file.
+func (f *FileStore) Delete(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.Remove(f.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete session %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package session persists agent conversations between runs. A Store keeps
+// the message history, agent-specific state and pending tool calls under a
+// session ID, so that agents behind stateless handlers can resume a
+// conversation. Writes use optimistic concurrency: a session saved from a
+// stale version is rejected with ErrConflict.
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+var (
+	// ErrNotFound is returned when no session is stored under an ID.
+	ErrNotFound = errors.New("session not found")
+	// ErrConflict is returned when a session was changed since it was loaded.
+	ErrConflict = errors.New("session was modified concurrently")
+)
+
+// Session is the persisted state of a conversation. State values must be
+// serializable to JSON for the file and SQL stores.
+type Session struct {
+	ID               string                 `json:"id"`
+	Messages         []llm.Message          `json:"messages,omitempty"`
+	State            map[string]interface{} `json:"state,omitempty"`
+	PendingToolCalls []llm.ToolCall         `json:"pending_tool_calls,omitempty"`
+	Version          int64                  `json:"version"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+}
+
+// New creates an empty, unsaved session.
+func New(id string) *Session {
+	return &Session{ID: id, State: make(map[string]interface{})}
+}
+
+// Clone returns a deep copy of the session.
+func (s *Session) Clone() *Session {
+	data, err := json.Marshal(s)
+	if err != nil {
+		// State that cannot be serialized is copied shallowly
+		clone := *s
+		clone.Messages = append([]llm.Message(nil), s.Messages...)
+		clone.PendingToolCalls = append([]llm.ToolCall(nil), s.PendingToolCalls...)
+		clone.State = make(map[string]interface{}, len(s.State))
+		for k, v := range s.State {
+			clone.State[k] = v
+		}
+		return &clone
+	}
+	var clone Session
+	_ = json.Unmarshal(data, &clone)
+	if clone.State == nil {
+		clone.State = make(map[string]interface{})
+	}
+	return &clone
+}
+
+// Store loads and saves sessions.
+type Store interface {
+	// Load returns the session stored under the ID, or ErrNotFound.
+	Load(ctx context.Context, id string) (*Session, error)
+
+	// Save stores the session if the stored version still equals
+	// s.Version, or if it is new (version 0) and no session exists under its
+	// ID. On success s.Version is incremented; otherwise ErrConflict is
+	// returned.
+	Save(ctx context.Context, s *Session) error
+
+	// Delete removes the session stored under the ID.
+	Delete(ctx context.Context, id string) error
+}
+
+// LoadOrNew loads a session, or creates a new one if none is stored.
+func LoadOrNew(ctx context.Context, store Store, id string) (*Session, error) {
+	s, err := store.Load(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return New(id), nil
+	}
+	return s, err
+}
+
+// MemoryStore keeps sessions in memory. It is useful for tests and single
+// process deployments.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]*Session)}
+}
+
+// Load returns a copy of the stored session.
+func (m *MemoryStore) Load(ctx context.Context, id string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.Clone(), nil
+}
+
+// Save stores a copy of the session.
+func (m *MemoryStore) Save(ctx context.Context, s *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current int64
+	if stored, ok := m.sessions[s.ID]; ok {
+		current = stored.Version
+	}
+	if current != s.Version {
+		return ErrConflict
+	}
+	s.Version++
+	s.UpdatedAt = time.Now()
+	m.sessions[s.ID] = s.Clone()
+	return nil
+}
+
+// Delete removes the session.
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
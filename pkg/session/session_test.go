@@ -0,0 +1,187 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/constants"
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// testStore runs the behaviour every store must share.
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "chat/1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	sess, err := LoadOrNew(ctx, store, "chat/1")
+	if err != nil {
+		t.Fatalf("LoadOrNew failed: %v", err)
+	}
+	sess.Messages = append(sess.Messages, llm.Message{Role: constants.RoleUser, Content: "Hello"})
+	sess.State["step"] = "greeting"
+	sess.PendingToolCalls = []llm.ToolCall{{ID: "1", Name: "refund", Args: map[string]interface{}{"amount": 10.0}}}
+	if err := store.Save(ctx, sess); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if sess.Version != 1 {
+		t.Errorf("Expected version 1, got %d", sess.Version)
+	}
+
+	first, err := store.Load(ctx, "chat/1")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	second, _ := store.Load(ctx, "chat/1")
+	if len(first.Messages) != 1 || first.State["step"] != "greeting" || first.PendingToolCalls[0].Args["amount"] != 10.0 {
+		t.Errorf("Unexpected loaded session: %+v", first)
+	}
+
+	first.Messages = append(first.Messages, llm.Message{Role: constants.RoleAssistant, Content: "Hi"})
+	if err := store.Save(ctx, first); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	second.State["step"] = "stale"
+	if err := store.Save(ctx, second); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a stale write, got %v", err)
+	}
+	if err := store.Save(ctx, New("chat/1")); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a second new session, got %v", err)
+	}
+
+	latest, _ := store.Load(ctx, "chat/1")
+	if latest.Version != 2 || len(latest.Messages) != 2 || latest.State["step"] != "greeting" {
+		t.Errorf("Expected the first writer to win, got %+v", latest)
+	}
+
+	if err := store.Delete(ctx, "chat/1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Load(ctx, "chat/1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	testStore(t, store)
+}
+
+func TestSQLStore(t *testing.T) {
+	db, err := sql.Open("sessiontest", "")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	store := NewSQLStore(db, "sessions").WithPlaceholder(DollarPlaceholder)
+	if err := store.CreateTable(context.Background()); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	testStore(t, store)
+}
+
+// fakeDB is a minimal database/sql driver that understands the statements of
+// SQLStore.
+type fakeDB struct {
+	mu   sync.Mutex
+	rows map[string][2]driver.Value // id -> version, data
+}
+
+var testDB = &fakeDB{rows: make(map[string][2]driver.Value)}
+
+func init() {
+	sql.Register("sessiontest", testDB)
+}
+
+func (d *fakeDB) Open(string) (driver.Conn, error) { return &fakeConn{db: d}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(query, "?") {
+		return nil, fmt.Errorf("unexpected placeholder in %q", query)
+	}
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		id := args[0].(string)
+		if _, exists := s.db.rows[id]; exists {
+			return nil, errors.New("duplicate key")
+		}
+		s.db.rows[id] = [2]driver.Value{args[1], args[2]}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "UPDATE"):
+		id := args[3].(string)
+		row, exists := s.db.rows[id]
+		if !exists || row[0] != args[4] {
+			return driver.RowsAffected(0), nil
+		}
+		s.db.rows[id] = [2]driver.Value{args[0], args[1]}
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(s.db.rows, args[0].(string))
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected statement %q", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	rows := &fakeRows{}
+	if row, ok := s.db.rows[args[0].(string)]; ok {
+		rows.values = [][]driver.Value{{row[0], row[1]}}
+	}
+	return rows, nil
+}
+
+type fakeRows struct{ values [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"version", "data"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,143 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Placeholder formats the n-th (1-based) bind parameter of a query.
+type Placeholder func(n int) string
+
+// QuestionPlaceholder formats parameters as "?" (MySQL, SQLite).
+func QuestionPlaceholder(int) string { return "?" }
+
+// DollarPlaceholder formats parameters as "$1", "$2", ... (PostgreSQL).
+func DollarPlaceholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+// SQLStore keeps sessions in a database/sql table with the columns id,
+// version, data and updated_at. The version column makes concurrent writers
+// from several processes safe. The caller provides the driver.
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder Placeholder
+}
+
+// NewSQLStore creates a store backed by the given table.
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	return &SQLStore{
+		db:          db,
+		table:       table,
+		placeholder: QuestionPlaceholder,
+	}
+}
+
+// WithPlaceholder sets the bind parameter style of the driver.
+func (s *SQLStore) WithPlaceholder(placeholder Placeholder) *SQLStore {
+	s.placeholder = placeholder
+	return s
+}
+
+// query replaces the "?" markers of a query with the configured placeholders.
+func (s *SQLStore) query(q string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString(s.placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.ReplaceAll(b.String(), "{table}", s.table)
+}
+
+// CreateTable creates the sessions table if it does not exist.
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.query(`CREATE TABLE IF NOT EXISTS {table} (
+	id VARCHAR(255) PRIMARY KEY,
+	version BIGINT NOT NULL,
+	data TEXT NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+)`))
+	if err != nil {
+		return fmt.Errorf("failed to create session table: %w", err)
+	}
+	return nil
+}
+
+// Load reads the session row.
+func (s *SQLStore) Load(ctx context.Context, id string) (*Session, error) {
+	var version int64
+	var data string
+	err := s.db.QueryRowContext(ctx, s.query(`SELECT version, data FROM {table} WHERE id = ?`), id).Scan(&version, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal([]byte(data), &sess); err != nil {
+		return nil, fmt.Errorf("failed to decode session %s: %w", id, err)
+	}
+	sess.ID, sess.Version = id, version
+	if sess.State == nil {
+		sess.State = make(map[string]interface{})
+	}
+	return &sess, nil
+}
+
+// Save inserts a new session or updates the row if its version still matches.
+func (s *SQLStore) Save(ctx context.Context, sess *Session) error {
+	next := *sess
+	next.Version++
+	next.UpdatedAt = time.Now()
+	data, err := json.Marshal(&next)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", sess.ID, err)
+	}
+
+	if sess.Version == 0 {
+		_, err := s.db.ExecContext(ctx, s.query(`INSERT INTO {table} (id, version, data, updated_at) VALUES (?, ?, ?, ?)`),
+			sess.ID, next.Version, string(data), next.UpdatedAt)
+		if err != nil {
+			// A failed insert of an existing ID is a lost race with another writer
+			if _, loadErr := s.Load(ctx, sess.ID); loadErr == nil {
+				return ErrConflict
+			}
+			return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+		}
+	} else {
+		result, err := s.db.ExecContext(ctx, s.query(`UPDATE {table} SET version = ?, data = ?, updated_at = ? WHERE id = ? AND version = ?`),
+			next.Version, string(data), next.UpdatedAt, sess.ID, sess.Version)
+		if err != nil {
+			return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+		}
+		if rows == 0 {
+			return ErrConflict
+		}
+	}
+
+	sess.Version, sess.UpdatedAt = next.Version, next.UpdatedAt
+	return nil
+}
+
+// Delete removes the session row.
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, s.query(`DELETE FROM {table} WHERE id = ?`), id); err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", id, err)
+	}
+	return nil
+}
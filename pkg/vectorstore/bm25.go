@@ -0,0 +1,108 @@
+package vectorstore
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters using the common defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// bm25Index maintains term statistics for BM25 keyword scoring.
+// It is not safe for concurrent use; the Store guards it with its own lock.
+type bm25Index struct {
+	termFreqs   map[string]map[string]int // doc ID -> term -> frequency
+	docLengths  map[string]int
+	docFreqs    map[string]int // term -> number of documents containing it
+	totalLength int
+}
+
+func newBM25Index() *bm25Index {
+	return &bm25Index{
+		termFreqs:  make(map[string]map[string]int),
+		docLengths: make(map[string]int),
+		docFreqs:   make(map[string]int),
+	}
+}
+
+// add indexes the content of a document.
+func (b *bm25Index) add(id, content string) {
+	terms := Tokenize(content)
+	freqs := make(map[string]int)
+	for _, term := range terms {
+		freqs[term]++
+	}
+	for term := range freqs {
+		b.docFreqs[term]++
+	}
+
+	b.termFreqs[id] = freqs
+	b.docLengths[id] = len(terms)
+	b.totalLength += len(terms)
+}
+
+// remove drops a document from the index.
+func (b *bm25Index) remove(id string) {
+	freqs, ok := b.termFreqs[id]
+	if !ok {
+		return
+	}
+	for term := range freqs {
+		b.docFreqs[term]--
+		if b.docFreqs[term] <= 0 {
+			delete(b.docFreqs, term)
+		}
+	}
+
+	b.totalLength -= b.docLengths[id]
+	delete(b.termFreqs, id)
+	delete(b.docLengths, id)
+}
+
+// score returns the BM25 score of every document containing at least one query term.
+func (b *bm25Index) score(query string) map[string]float64 {
+	scores := make(map[string]float64)
+	docCount := len(b.termFreqs)
+	if docCount == 0 {
+		return scores
+	}
+	avgLength := float64(b.totalLength) / float64(docCount)
+	if avgLength == 0 {
+		return scores
+	}
+
+	seen := make(map[string]bool)
+	for _, term := range Tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		df := b.docFreqs[term]
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(docCount)-float64(df)+0.5)/(float64(df)+0.5))
+
+		for id, freqs := range b.termFreqs {
+			tf := float64(freqs[term])
+			if tf == 0 {
+				continue
+			}
+			length := float64(b.docLengths[id])
+			scores[id] += idf * (tf * (bm25K1 + 1)) / (tf + bm25K1*(1-bm25B+bm25B*length/avgLength))
+		}
+	}
+	return scores
+}
+
+// Tokenize splits text into lowercase alphanumeric terms for keyword scoring.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
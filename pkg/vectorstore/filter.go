@@ -0,0 +1,79 @@
+package vectorstore
+
+import (
+	"fmt"
+)
+
+// Filter restricts a search to documents whose metadata satisfies the condition.
+// A nil Filter matches every document.
+type Filter func(metadata map[string]interface{}) bool
+
+// matches evaluates the filter, treating a nil filter as a match.
+func (f Filter) matches(metadata map[string]interface{}) bool {
+	return f == nil || f(metadata)
+}
+
+// Equals matches documents whose metadata value for key equals value.
+// Values are compared by their string representation so that numbers decoded
+// from a snapshot (float64) still match the ints they were stored as.
+func Equals(key string, value interface{}) Filter {
+	return func(metadata map[string]interface{}) bool {
+		actual, ok := metadata[key]
+		return ok && fmt.Sprint(actual) == fmt.Sprint(value)
+	}
+}
+
+// In matches documents whose metadata value for key is one of the given values.
+func In(key string, values ...interface{}) Filter {
+	return func(metadata map[string]interface{}) bool {
+		actual, ok := metadata[key]
+		if !ok {
+			return false
+		}
+		for _, value := range values {
+			if fmt.Sprint(actual) == fmt.Sprint(value) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Exists matches documents that have a metadata value for key.
+func Exists(key string) Filter {
+	return func(metadata map[string]interface{}) bool {
+		_, ok := metadata[key]
+		return ok
+	}
+}
+
+// And matches documents that satisfy all filters.
+func And(filters ...Filter) Filter {
+	return func(metadata map[string]interface{}) bool {
+		for _, filter := range filters {
+			if !filter.matches(metadata) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches documents that satisfy at least one filter.
+func Or(filters ...Filter) Filter {
+	return func(metadata map[string]interface{}) bool {
+		for _, filter := range filters {
+			if filter.matches(metadata) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches documents that do not satisfy the filter.
+func Not(filter Filter) Filter {
+	return func(metadata map[string]interface{}) bool {
+		return !filter.matches(metadata)
+	}
+}
@@ -0,0 +1,186 @@
+package vectorstore
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Index is an approximate nearest-neighbor index that narrows down the
+// documents scored during a vector search. The Store still computes exact
+// cosine similarity for every candidate the index returns.
+type Index interface {
+	// Add indexes the embedding of a document.
+	Add(id string, embedding []float64)
+
+	// Remove drops a document from the index.
+	Remove(id string)
+
+	// Candidates returns the IDs of documents likely to be near the query.
+	Candidates(query []float64) []string
+
+	// Reset drops all documents, so that embeddings of another dimension can be indexed.
+	Reset()
+}
+
+// LSHIndex is a locality-sensitive hashing index based on random hyperplanes.
+// Each table hashes an embedding to a bucket by the signs of its projections,
+// so vectors with a small angle between them tend to share buckets.
+type LSHIndex struct {
+	mu          sync.RWMutex
+	tables      int
+	bits        int
+	seed        int64
+	dimension   int                   // Length of the indexed embeddings, 0 until the first Add
+	hyperplanes [][][]float64         // table -> bit -> hyperplane
+	buckets     []map[uint64][]string // table -> hash -> IDs
+	hashes      map[string][]uint64   // ID -> hash per table
+}
+
+// NewLSHIndex creates a new LSH index with the given number of hash tables and
+// bits per hash. More tables increase recall, more bits increase precision.
+// The seed makes the random hyperplanes reproducible across snapshots.
+func NewLSHIndex(tables, bits int, seed int64) *LSHIndex {
+	if tables <= 0 {
+		tables = 8
+	}
+	if bits <= 0 || bits > 64 {
+		bits = 12
+	}
+
+	l := &LSHIndex{tables: tables, bits: bits, seed: seed}
+	l.resetLocked()
+	return l
+}
+
+// Add indexes the embedding of a document. The first embedding added to an
+// empty index sets its dimension; embeddings of another dimension are not
+// indexed until the index is empty again or Reset.
+func (l *LSHIndex) Add(id string, embedding []float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.removeLocked(id)
+	if len(embedding) == 0 {
+		return
+	}
+	if len(l.hashes) == 0 && len(embedding) != l.dimension {
+		l.generateHyperplanes(len(embedding))
+	}
+	if len(embedding) != l.dimension {
+		return
+	}
+
+	hashes := make([]uint64, l.tables)
+	for t := 0; t < l.tables; t++ {
+		hash := l.hash(t, embedding)
+		hashes[t] = hash
+		l.buckets[t][hash] = append(l.buckets[t][hash], id)
+	}
+	l.hashes[id] = hashes
+}
+
+// Remove drops a document from the index.
+func (l *LSHIndex) Remove(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.removeLocked(id)
+}
+
+// Reset drops all documents and the hyperplanes.
+func (l *LSHIndex) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.resetLocked()
+}
+
+// resetLocked empties the index. The caller must hold the write lock.
+func (l *LSHIndex) resetLocked() {
+	l.dimension = 0
+	l.hyperplanes = nil
+	l.buckets = make([]map[uint64][]string, l.tables)
+	for i := range l.buckets {
+		l.buckets[i] = make(map[uint64][]string)
+	}
+	l.hashes = make(map[string][]uint64)
+}
+
+// Candidates returns the IDs sharing a bucket with the query in any table.
+func (l *LSHIndex) Candidates(query []float64) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.dimension == 0 || len(query) != l.dimension {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	for t := 0; t < l.tables; t++ {
+		for _, id := range l.buckets[t][l.hash(t, query)] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// removeLocked removes an ID from its buckets. The caller must hold the write lock.
+func (l *LSHIndex) removeLocked(id string) {
+	hashes, ok := l.hashes[id]
+	if !ok {
+		return
+	}
+	for t, hash := range hashes {
+		bucket := l.buckets[t][hash]
+		for i, existing := range bucket {
+			if existing == id {
+				bucket = append(bucket[:i], bucket[i+1:]...)
+				break
+			}
+		}
+		if len(bucket) == 0 {
+			delete(l.buckets[t], hash)
+		} else {
+			l.buckets[t][hash] = bucket
+		}
+	}
+	delete(l.hashes, id)
+}
+
+// generateHyperplanes generates the random hyperplanes for the dimension.
+// The caller must hold the write lock.
+func (l *LSHIndex) generateHyperplanes(dimension int) {
+	l.dimension = dimension
+	rng := rand.New(rand.NewSource(l.seed))
+	l.hyperplanes = make([][][]float64, l.tables)
+	for t := range l.hyperplanes {
+		l.hyperplanes[t] = make([][]float64, l.bits)
+		for b := range l.hyperplanes[t] {
+			plane := make([]float64, dimension)
+			for d := range plane {
+				plane[d] = rng.NormFloat64()
+			}
+			l.hyperplanes[t][b] = plane
+		}
+	}
+}
+
+// hash computes the bucket of a vector in the given table. The vector must
+// have the dimension of the index.
+func (l *LSHIndex) hash(table int, vector []float64) uint64 {
+	var hash uint64
+	for b, plane := range l.hyperplanes[table] {
+		var dot float64
+		for d := range plane {
+			dot += plane[d] * vector[d]
+		}
+		if dot >= 0 {
+			hash |= 1 << uint(b)
+		}
+	}
+	return hash
+}
@@ -0,0 +1,91 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+	"github.com/ratlabs-io/go-agent-kit/pkg/retrieval"
+)
+
+// Retriever adapts a Store to the retrieval.Retriever interface.
+// With an embedder it performs vector or hybrid search, without one it falls
+// back to BM25 keyword search.
+type Retriever struct {
+	store    *Store
+	embedder llm.Embedder
+	filter   Filter
+	hybrid   bool
+	alpha    float64
+	fusion   FusionMethod
+}
+
+// NewRetriever creates a new Retriever over the store. The embedder may be nil
+// for keyword-only retrieval.
+func NewRetriever(store *Store, embedder llm.Embedder) *Retriever {
+	return &Retriever{
+		store:    store,
+		embedder: embedder,
+		alpha:    0.5,
+		fusion:   FusionWeighted,
+	}
+}
+
+// WithFilter restricts retrieval to documents matching the metadata filter.
+func (r *Retriever) WithFilter(filter Filter) *Retriever {
+	r.filter = filter
+	return r
+}
+
+// WithHybrid enables hybrid search combining cosine and BM25 scores.
+// Alpha is the weight of the vector score when using FusionWeighted; 0 ranks
+// by keyword scores only.
+func (r *Retriever) WithHybrid(alpha float64, fusion FusionMethod) *Retriever {
+	r.hybrid = true
+	r.alpha = alpha
+	r.fusion = fusion
+	return r
+}
+
+// Retrieve implements retrieval.Retriever.
+func (r *Retriever) Retrieve(ctx context.Context, query string, limit int) ([]retrieval.Chunk, error) {
+	alpha := r.alpha
+	req := SearchRequest{
+		K:      limit,
+		Filter: r.filter,
+		Alpha:  &alpha,
+		Fusion: r.fusion,
+	}
+
+	if r.embedder != nil {
+		embeddings, err := r.embedder.Embed(ctx, []string{query})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		if len(embeddings) != 1 {
+			return nil, fmt.Errorf("embedder returned %d embeddings for 1 query", len(embeddings))
+		}
+		req.Embedding = embeddings[0]
+		if r.hybrid {
+			req.Query = query
+		}
+	} else {
+		req.Query = query
+	}
+
+	results, err := r.store.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]retrieval.Chunk, 0, len(results))
+	for _, result := range results {
+		chunks = append(chunks, retrieval.Chunk{
+			ID:       result.ID,
+			Content:  result.Content,
+			Score:    result.Score,
+			Metadata: result.Metadata,
+		})
+	}
+	return chunks, nil
+}
@@ -0,0 +1,100 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// snapshotVersion is the current snapshot file format version.
+const snapshotVersion = 1
+
+// snapshot is the on-disk representation of a Store.
+type snapshot struct {
+	Version   int        `json:"version"`
+	Dimension int        `json:"dimension"`
+	Documents []Document `json:"documents"`
+}
+
+// Save writes all documents to a JSON snapshot at path.
+// The file is written atomically by renaming a temporary file.
+func (s *Store) Save(path string) error {
+	s.mu.RLock()
+	snap := snapshot{
+		Version:   snapshotVersion,
+		Dimension: s.dimension,
+		Documents: make([]Document, 0, len(s.docs)),
+	}
+	for _, doc := range s.docs {
+		snap.Documents = append(snap.Documents, doc)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snap.Documents, func(i, j int) bool {
+		return snap.Documents[i].ID < snap.Documents[j].ID
+	})
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load replaces the contents of the store with the snapshot at path.
+// A configured index is rebuilt from the loaded documents.
+func (s *Store) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.docs {
+		s.remove(id)
+	}
+	if s.index != nil {
+		s.index.Reset()
+	}
+	s.dimension = snap.Dimension
+	return s.put(snap.Documents)
+}
+
+// LoadStore creates a new Store from the snapshot at path.
+func LoadStore(path string) (*Store, error) {
+	store := NewStore()
+	if err := store.Load(path); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
@@ -0,0 +1,385 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ratlabs-io/go-agent-kit/pkg/llm"
+)
+
+// Document is a piece of content stored with its embedding and metadata.
+type Document struct {
+	ID        string                 `json:"id"`
+	Content   string                 `json:"content"`
+	Embedding []float64              `json:"embedding,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Result is a document matched by a search, with its combined and per-signal scores.
+type Result struct {
+	Document
+	Score        float64 `json:"score"`
+	VectorScore  float64 `json:"vector_score"`
+	KeywordScore float64 `json:"keyword_score"`
+}
+
+// FusionMethod defines how vector and keyword scores are combined in hybrid search.
+type FusionMethod string
+
+const (
+	// FusionWeighted min-max normalizes both score lists and combines them with Alpha.
+	FusionWeighted FusionMethod = "weighted"
+	// FusionRRF uses reciprocal rank fusion, which ignores the score scales entirely.
+	FusionRRF FusionMethod = "rrf"
+)
+
+// rrfK is the rank offset used by reciprocal rank fusion.
+const rrfK = 60
+
+// SearchRequest describes a search. Providing only Embedding performs a vector
+// search, only Query performs a BM25 keyword search, and both perform a hybrid search.
+type SearchRequest struct {
+	Embedding []float64    // Query embedding for cosine search
+	Query     string       // Query text for BM25 keyword search
+	K         int          // Maximum number of results (default 10)
+	Filter    Filter       // Optional metadata filter
+	Alpha     *float64     // Weight of the vector score in weighted fusion, 0 for keyword scores only (nil means 0.5)
+	Fusion    FusionMethod // Hybrid fusion method (default FusionWeighted)
+	Exact     bool         // Bypass the approximate index even if one is configured
+}
+
+// Store is a thread-safe, in-memory vector store with optional approximate
+// indexing and BM25 keyword scoring.
+type Store struct {
+	mu        sync.RWMutex
+	docs      map[string]Document
+	dimension int
+	index     Index
+	bm25      *bm25Index
+}
+
+// NewStore creates a new empty Store that performs exact cosine search.
+func NewStore() *Store {
+	return &Store{
+		docs: make(map[string]Document),
+		bm25: newBM25Index(),
+	}
+}
+
+// WithIndex sets an approximate nearest-neighbor index used to speed up vector
+// search on larger corpora. Existing documents are added to the index.
+func (s *Store) WithIndex(index Index) *Store {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index = index
+	if index != nil {
+		for id, doc := range s.docs {
+			if len(doc.Embedding) > 0 {
+				index.Add(id, doc.Embedding)
+			}
+		}
+	}
+	return s
+}
+
+// Add inserts new documents. It fails if any ID already exists.
+func (s *Store) Add(docs ...Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, doc := range docs {
+		if _, exists := s.docs[doc.ID]; exists {
+			return fmt.Errorf("document %s already exists", doc.ID)
+		}
+	}
+	return s.put(docs)
+}
+
+// Upsert inserts new documents and replaces existing documents with the same ID.
+func (s *Store) Upsert(docs ...Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.put(docs)
+}
+
+// put validates and stores documents. The caller must hold the write lock.
+func (s *Store) put(docs []Document) error {
+	dimension := s.dimension
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return fmt.Errorf("document ID cannot be empty")
+		}
+		if len(doc.Embedding) == 0 {
+			continue
+		}
+		if dimension == 0 {
+			dimension = len(doc.Embedding)
+		}
+		if len(doc.Embedding) != dimension {
+			return fmt.Errorf("document %s has embedding dimension %d, expected %d", doc.ID, len(doc.Embedding), dimension)
+		}
+	}
+	s.dimension = dimension
+
+	for _, doc := range docs {
+		if _, exists := s.docs[doc.ID]; exists {
+			s.remove(doc.ID)
+		}
+		s.docs[doc.ID] = doc
+		s.bm25.add(doc.ID, doc.Content)
+		if s.index != nil && len(doc.Embedding) > 0 {
+			s.index.Add(doc.ID, doc.Embedding)
+		}
+	}
+	return nil
+}
+
+// Delete removes the documents with the given IDs and returns how many were removed.
+func (s *Store) Delete(ids ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for _, id := range ids {
+		if _, exists := s.docs[id]; exists {
+			s.remove(id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// remove deletes a document from all indexes. The caller must hold the write lock.
+func (s *Store) remove(id string) {
+	delete(s.docs, id)
+	s.bm25.remove(id)
+	if s.index != nil {
+		s.index.Remove(id)
+	}
+}
+
+// Get retrieves a document by ID.
+func (s *Store) Get(id string) (Document, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, ok := s.docs[id]
+	return doc, ok
+}
+
+// Len returns the number of stored documents.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.docs)
+}
+
+// Search returns the documents that best match the request, ranked by descending score.
+func (s *Store) Search(ctx context.Context, req SearchRequest) ([]Result, error) {
+	if len(req.Embedding) == 0 && req.Query == "" {
+		return nil, fmt.Errorf("search request needs an embedding, a query or both")
+	}
+	if req.K <= 0 {
+		req.K = 10
+	}
+	if req.Alpha == nil {
+		alpha := 0.5
+		req.Alpha = &alpha
+	}
+	if req.Fusion == "" {
+		req.Fusion = FusionWeighted
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(req.Embedding) > 0 && s.dimension != 0 && len(req.Embedding) != s.dimension {
+		return nil, fmt.Errorf("query embedding has dimension %d, expected %d", len(req.Embedding), s.dimension)
+	}
+
+	var vectorScores, keywordScores map[string]float64
+	if len(req.Embedding) > 0 {
+		vectorScores = s.vectorScores(req)
+	}
+	if req.Query != "" {
+		keywordScores = s.keywordScores(req)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	switch {
+	case vectorScores != nil && keywordScores != nil:
+		results = s.fuse(vectorScores, keywordScores, req)
+	case vectorScores != nil:
+		for id, score := range vectorScores {
+			results = append(results, Result{Document: s.docs[id], Score: score, VectorScore: score})
+		}
+	default:
+		for id, score := range keywordScores {
+			results = append(results, Result{Document: s.docs[id], Score: score, KeywordScore: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].ID < results[j].ID
+		}
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > req.K {
+		results = results[:req.K]
+	}
+	return results, nil
+}
+
+// candidates returns the IDs of the documents with an embedding that match
+// the filter of a vector search.
+func (s *Store) candidates(req SearchRequest) []string {
+	if s.index != nil && !req.Exact {
+		ids := s.searchable(s.index.Candidates(req.Embedding), req.Filter)
+		// Fall back to an exact scan when the index cannot supply enough candidates
+		if len(ids) >= req.K {
+			return ids
+		}
+	}
+
+	ids := make([]string, 0, len(s.docs))
+	for id := range s.docs {
+		ids = append(ids, id)
+	}
+	return s.searchable(ids, req.Filter)
+}
+
+// searchable keeps the IDs of stored documents with an embedding that match the filter.
+func (s *Store) searchable(ids []string, filter Filter) []string {
+	var kept []string
+	for _, id := range ids {
+		doc, ok := s.docs[id]
+		if ok && len(doc.Embedding) > 0 && filter.matches(doc.Metadata) {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// vectorScores computes the cosine similarity for the candidate documents.
+func (s *Store) vectorScores(req SearchRequest) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, id := range s.candidates(req) {
+		scores[id] = llm.CosineSimilarity(req.Embedding, s.docs[id].Embedding)
+	}
+	return scores
+}
+
+// keywordScores computes the BM25 score for documents matching the query terms.
+func (s *Store) keywordScores(req SearchRequest) map[string]float64 {
+	scores := make(map[string]float64)
+	for id, score := range s.bm25.score(req.Query) {
+		if !req.Filter.matches(s.docs[id].Metadata) {
+			continue
+		}
+		scores[id] = score
+	}
+	return scores
+}
+
+// fuse combines vector and keyword scores into hybrid results.
+func (s *Store) fuse(vectorScores, keywordScores map[string]float64, req SearchRequest) []Result {
+	combined := make(map[string]*Result)
+	get := func(id string) *Result {
+		if r, ok := combined[id]; ok {
+			return r
+		}
+		r := &Result{Document: s.docs[id]}
+		combined[id] = r
+		return r
+	}
+	for id, score := range vectorScores {
+		get(id).VectorScore = score
+	}
+	for id, score := range keywordScores {
+		get(id).KeywordScore = score
+	}
+
+	switch req.Fusion {
+	case FusionRRF:
+		vectorRanks := ranks(vectorScores)
+		keywordRanks := ranks(keywordScores)
+		for id, r := range combined {
+			if rank, ok := vectorRanks[id]; ok {
+				r.Score += 1.0 / float64(rrfK+rank)
+			}
+			if rank, ok := keywordRanks[id]; ok {
+				r.Score += 1.0 / float64(rrfK+rank)
+			}
+		}
+	default:
+		vectorNorm := normalize(vectorScores)
+		keywordNorm := normalize(keywordScores)
+		for id, r := range combined {
+			r.Score = *req.Alpha*vectorNorm[id] + (1-*req.Alpha)*keywordNorm[id]
+		}
+	}
+
+	results := make([]Result, 0, len(combined))
+	for _, r := range combined {
+		results = append(results, *r)
+	}
+	return results
+}
+
+// normalize min-max scales scores into [0, 1].
+func normalize(scores map[string]float64) map[string]float64 {
+	normalized := make(map[string]float64, len(scores))
+	if len(scores) == 0 {
+		return normalized
+	}
+
+	first := true
+	var min, max float64
+	for _, score := range scores {
+		if first || score < min {
+			min = score
+		}
+		if first || score > max {
+			max = score
+		}
+		first = false
+	}
+
+	for id, score := range scores {
+		if max == min {
+			normalized[id] = 1
+			continue
+		}
+		normalized[id] = (score - min) / (max - min)
+	}
+	return normalized
+}
+
+// ranks returns the 1-based rank of each ID when ordered by descending score.
+func ranks(scores map[string]float64) map[string]int {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] == scores[ids[j]] {
+			return ids[i] < ids[j]
+		}
+		return scores[ids[i]] > scores[ids[j]]
+	})
+
+	result := make(map[string]int, len(ids))
+	for i, id := range ids {
+		result[id] = i + 1
+	}
+	return result
+}
@@ -0,0 +1,319 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"testing"
+)
+
+// hashEmbedder is an offline embedder that hashes terms into a fixed-size bag-of-words vector
+type hashEmbedder struct {
+	dimension int
+}
+
+func (h *hashEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		vec := make([]float64, h.dimension)
+		for _, term := range Tokenize(text) {
+			hasher := fnv.New32a()
+			hasher.Write([]byte(term))
+			vec[int(hasher.Sum32())%h.dimension]++
+		}
+		embeddings[i] = vec
+	}
+	return embeddings, nil
+}
+
+func newTestStore(t *testing.T) (*Store, *hashEmbedder) {
+	t.Helper()
+	embedder := &hashEmbedder{dimension: 64}
+	contents := map[string]string{
+		"cats":    "cats are small furry pets that purr",
+		"dogs":    "dogs are loyal pets that bark",
+		"go":      "go is a compiled programming language",
+		"rust":    "rust is a systems programming language",
+		"weather": "the weather today is sunny and warm",
+	}
+	categories := map[string]string{"cats": "animals", "dogs": "animals", "go": "tech", "rust": "tech", "weather": "misc"}
+
+	store := NewStore()
+	for id, content := range contents {
+		emb, _ := embedder.Embed(context.Background(), []string{content})
+		err := store.Add(Document{ID: id, Content: content, Embedding: emb[0], Metadata: map[string]interface{}{"category": categories[id]}})
+		if err != nil {
+			t.Fatalf("Failed to add document %s: %v", id, err)
+		}
+	}
+	return store, embedder
+}
+
+func embedQuery(t *testing.T, embedder *hashEmbedder, query string) []float64 {
+	t.Helper()
+	emb, err := embedder.Embed(context.Background(), []string{query})
+	if err != nil {
+		t.Fatalf("Failed to embed query: %v", err)
+	}
+	return emb[0]
+}
+
+func TestStore_AddUpsertDelete(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	if store.Len() != 5 {
+		t.Fatalf("Expected 5 documents, got %d", store.Len())
+	}
+
+	if err := store.Add(Document{ID: "cats", Content: "duplicate"}); err == nil {
+		t.Error("Expected error adding duplicate ID")
+	}
+
+	if err := store.Upsert(Document{ID: "cats", Content: "cats sleep all day", Embedding: make([]float64, 64)}); err != nil {
+		t.Errorf("Upsert failed: %v", err)
+	}
+	if doc, _ := store.Get("cats"); doc.Content != "cats sleep all day" {
+		t.Errorf("Expected upserted content, got '%s'", doc.Content)
+	}
+
+	if err := store.Upsert(Document{ID: "bad", Embedding: []float64{1, 2}}); err == nil {
+		t.Error("Expected error for mismatched embedding dimension")
+	}
+
+	if removed := store.Delete("cats", "missing"); removed != 1 {
+		t.Errorf("Expected 1 removed document, got %d", removed)
+	}
+	if _, ok := store.Get("cats"); ok {
+		t.Error("Document still present after delete")
+	}
+}
+
+func TestStore_VectorSearch(t *testing.T) {
+	store, embedder := newTestStore(t)
+
+	results, err := store.Search(context.Background(), SearchRequest{
+		Embedding: embedQuery(t, embedder, "which programming language is compiled"),
+		K:         2,
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].ID != "go" {
+		t.Errorf("Expected 'go' as top result, got '%s'", results[0].ID)
+	}
+	if results[0].Score < results[1].Score {
+		t.Error("Results are not sorted by descending score")
+	}
+}
+
+func TestStore_KeywordSearch(t *testing.T) {
+	store, _ := newTestStore(t)
+
+	results, err := store.Search(context.Background(), SearchRequest{Query: "bark", K: 3})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if len(results) != 1 || results[0].ID != "dogs" {
+		t.Errorf("Expected only 'dogs' for keyword 'bark', got %+v", results)
+	}
+	if results[0].KeywordScore <= 0 {
+		t.Error("Expected positive BM25 score")
+	}
+}
+
+func TestStore_HybridSearchAndFilter(t *testing.T) {
+	store, embedder := newTestStore(t)
+
+	for _, fusion := range []FusionMethod{FusionWeighted, FusionRRF} {
+		results, err := store.Search(context.Background(), SearchRequest{
+			Embedding: embedQuery(t, embedder, "pets that purr"),
+			Query:     "purr",
+			K:         5,
+			Fusion:    fusion,
+			Filter:    Equals("category", "animals"),
+		})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("%s: expected 2 filtered results, got %d", fusion, len(results))
+		}
+		if results[0].ID != "cats" {
+			t.Errorf("%s: expected 'cats' as top result, got '%s'", fusion, results[0].ID)
+		}
+		for _, r := range results {
+			if r.Metadata["category"] != "animals" {
+				t.Errorf("%s: filter not applied, got category %v", fusion, r.Metadata["category"])
+			}
+		}
+	}
+}
+
+func TestStore_KeywordOnlyFusion(t *testing.T) {
+	store, embedder := newTestStore(t)
+
+	keywordOnly := 0.0
+	results, err := store.Search(context.Background(), SearchRequest{
+		Embedding: embedQuery(t, embedder, "pets that purr"),
+		Query:     "purr",
+		K:         5,
+		Alpha:     &keywordOnly,
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	for _, r := range results {
+		if r.KeywordScore == 0 && r.Score != 0 {
+			t.Errorf("Expected vector scores to be ignored, got %v for %s", r.Score, r.ID)
+		}
+	}
+	if results[0].KeywordScore == 0 {
+		t.Errorf("Expected a keyword match first, got %s", results[0].ID)
+	}
+}
+
+func TestStore_LSHIndex(t *testing.T) {
+	embedder := &hashEmbedder{dimension: 32}
+	exact := NewStore()
+	approx := NewStore().WithIndex(NewLSHIndex(16, 4, 42))
+
+	for i := 0; i < 200; i++ {
+		content := fmt.Sprintf("document number %d about topic %d", i, i%7)
+		emb, _ := embedder.Embed(context.Background(), []string{content})
+		doc := Document{ID: fmt.Sprintf("doc-%03d", i), Content: content, Embedding: emb[0]}
+		if err := exact.Add(doc); err != nil {
+			t.Fatalf("Failed to add: %v", err)
+		}
+		if err := approx.Add(doc); err != nil {
+			t.Fatalf("Failed to add: %v", err)
+		}
+	}
+
+	query := embedQuery(t, embedder, "document number 13 about topic 6")
+	want, _ := exact.Search(context.Background(), SearchRequest{Embedding: query, K: 1})
+	got, err := approx.Search(context.Background(), SearchRequest{Embedding: query, K: 1})
+	if err != nil {
+		t.Fatalf("Approximate search failed: %v", err)
+	}
+
+	if len(got) != 1 || got[0].ID != want[0].ID {
+		t.Errorf("Expected approximate top result %s, got %+v", want[0].ID, got)
+	}
+
+	approx.Delete(want[0].ID)
+	got, _ = approx.Search(context.Background(), SearchRequest{Embedding: query, K: 1})
+	if len(got) == 1 && got[0].ID == want[0].ID {
+		t.Error("Deleted document still returned by indexed search")
+	}
+}
+
+func TestStore_LSHIndexFilteredSearch(t *testing.T) {
+	embedder := &hashEmbedder{dimension: 32}
+	store := NewStore().WithIndex(NewLSHIndex(4, 8, 42))
+	for i := 0; i < 200; i++ {
+		content := fmt.Sprintf("document number %d about topic %d", i, i%7)
+		emb, _ := embedder.Embed(context.Background(), []string{content})
+		doc := Document{ID: fmt.Sprintf("doc-%03d", i), Content: content, Embedding: emb[0], Metadata: map[string]interface{}{"topic": fmt.Sprint(i % 7)}}
+		if err := store.Add(doc); err != nil {
+			t.Fatalf("Failed to add: %v", err)
+		}
+	}
+
+	// Few of the candidates near the query match the filter
+	results, err := store.Search(context.Background(), SearchRequest{
+		Embedding: embedQuery(t, embedder, "document number 13 about topic 6"),
+		K:         10,
+		Filter:    Equals("topic", "2"),
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("Expected 10 filtered results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Metadata["topic"] != "2" {
+			t.Errorf("Expected only topic 2, got %s", r.ID)
+		}
+	}
+}
+
+func TestStore_LoadResetsIndex(t *testing.T) {
+	snapshotStore, embedder := newTestStore(t)
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := snapshotStore.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// The index holds longer embeddings than the snapshot
+	store := NewStore().WithIndex(NewLSHIndex(4, 4, 42))
+	if err := store.Add(Document{ID: "wide", Embedding: make([]float64, 128)}); err != nil {
+		t.Fatalf("Failed to add: %v", err)
+	}
+	if err := store.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	results, err := store.Search(context.Background(), SearchRequest{Embedding: embedQuery(t, embedder, "sunny weather"), K: 1})
+	if err != nil || len(results) != 1 || results[0].ID != "weather" {
+		t.Errorf("Unexpected search result after load: %+v, %v", results, err)
+	}
+	if err := store.Add(Document{ID: "wide", Embedding: make([]float64, 128)}); err == nil {
+		t.Error("Expected an error for an embedding of another dimension")
+	}
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	store, embedder := newTestStore(t)
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadStore(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Len() != store.Len() {
+		t.Errorf("Expected %d documents after load, got %d", store.Len(), loaded.Len())
+	}
+
+	results, err := loaded.Search(context.Background(), SearchRequest{
+		Embedding: embedQuery(t, embedder, "sunny weather"),
+		K:         1,
+		Filter:    Equals("category", "misc"),
+	})
+	if err != nil || len(results) != 1 || results[0].ID != "weather" {
+		t.Errorf("Unexpected search result after load: %+v, %v", results, err)
+	}
+}
+
+func TestRetriever(t *testing.T) {
+	store, embedder := newTestStore(t)
+
+	retriever := NewRetriever(store, embedder).
+		WithHybrid(0.5, FusionRRF).
+		WithFilter(Equals("category", "tech"))
+
+	chunks, err := retriever.Retrieve(context.Background(), "systems language", 1)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0].ID != "rust" {
+		t.Errorf("Expected 'rust' chunk, got %+v", chunks)
+	}
+
+	keywordOnly := NewRetriever(store, nil)
+	chunks, err = keywordOnly.Retrieve(context.Background(), "furry", 3)
+	if err != nil || len(chunks) != 1 || chunks[0].ID != "cats" {
+		t.Errorf("Expected keyword-only retrieval of 'cats', got %+v, %v", chunks, err)
+	}
+}
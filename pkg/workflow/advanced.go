@@ -59,6 +59,11 @@ func (cb *CircuitBreaker) Name() string {
 	return cb.name
 }
 
+// Children returns the protected action.
+func (cb *CircuitBreaker) Children() []Action {
+	return actions(cb.action)
+}
+
 // Run executes the action with circuit breaker protection.
 func (cb *CircuitBreaker) Run(wctx WorkContext) WorkReport {
 	if cb.action == nil {
@@ -185,6 +190,11 @@ func (tw *TimeoutWrapper) Name() string {
 	return tw.name
 }
 
+// Children returns the wrapped action.
+func (tw *TimeoutWrapper) Children() []Action {
+	return actions(tw.action)
+}
+
 // Run executes the action with timeout protection.
 func (tw *TimeoutWrapper) Run(wctx WorkContext) WorkReport {
 	if tw.action == nil {
@@ -266,6 +276,11 @@ func (pec *ParallelErrorCollector) Name() string {
 	return pec.name
 }
 
+// Children returns the collected actions.
+func (pec *ParallelErrorCollector) Children() []Action {
+	return actions(pec.actions...)
+}
+
 // Run executes all actions in parallel and collects all errors.
 func (pec *ParallelErrorCollector) Run(wctx WorkContext) WorkReport {
 	if len(pec.actions) == 0 {
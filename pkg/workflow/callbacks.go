@@ -19,6 +19,7 @@ const (
 	EventToolCalled        EventType = "tool.called"
 	EventToolCompleted     EventType = "tool.completed"
 	EventToolFailed        EventType = "tool.failed"
+	EventAgentHandoff      EventType = "agent.handoff"
 )
 
 // Event represents something that happened during workflow/agent execution.
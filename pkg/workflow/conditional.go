@@ -34,6 +34,11 @@ func (cf *ConditionalFlow) Name() string {
 	return cf.FlowName
 }
 
+// Children returns the actions of both branches.
+func (cf *ConditionalFlow) Children() []Action {
+	return actions(cf.IfTrue, cf.IfFalse)
+}
+
 // Run executes the ConditionalFlow by evaluating the condition and running the appropriate action.
 // If the condition evaluates to true, runs the ifTrue action.
 // If the condition evaluates to false and ifFalse is provided, runs the ifFalse action.
@@ -64,6 +64,28 @@ func NewWorkContextWithLogger(ctx context.Context, logger Logger) WorkContext {
 	}
 }
 
+// NewChildWorkContext creates an empty WorkContext that shares the base context,
+// logger and callbacks of the parent. Nested runs use it to keep their data
+// separate from the parent while still reporting events to its callbacks.
+// A budget of the parent is shared as well, so nested runs count against it.
+func NewChildWorkContext(parent WorkContext) WorkContext {
+	child := &DefaultWorkContext{
+		ctx:         parent.Context(),
+		contextData: make(map[interface{}]interface{}),
+		logger:      parent.Logger(),
+	}
+	if budget, ok := parent.Get(constants.KeyBudget); ok {
+		child.contextData[constants.KeyBudget] = budget
+	}
+
+	if p, ok := parent.(*DefaultWorkContext); ok && p.callbacks != nil {
+		child.callbacks = p.callbacks
+		child.ctx = context.WithValue(child.ctx, constants.KeyWorkContext, child)
+	}
+
+	return child
+}
+
 // Context returns the underlying context.
 func (wc *DefaultWorkContext) Context() context.Context {
 	return wc.ctx
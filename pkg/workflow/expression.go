@@ -0,0 +1,582 @@
+package workflow
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Expression is a compiled boolean expression over WorkContext values, used
+// as a Predicate where writing a Go function is inconvenient, for example in
+// declarative workflow definitions.
+//
+// Identifiers are WorkContext keys, with dotted paths and indexes into maps,
+// slices and structs (order.total, items[0].name, scores["overall"]).
+// Missing values are null. Supported syntax:
+//
+//	literals      42  -0.5  "text"  'text'  true  false  null
+//	comparison    ==  !=  <  <=  >  >=
+//	membership    a contains b   b in a   (substrings, list items, map keys)
+//	logic         &&  ||  !  and  or  not  (...)
+//	functions     len(x)  lower(s)  upper(s)
+//
+// Non-boolean results are truthy unless they are null, false, zero, an empty
+// string or an empty collection.
+type Expression struct {
+	source string
+	eval   evalFunc
+}
+
+// evalFunc evaluates a compiled expression node.
+type evalFunc func(wctx WorkContext) (interface{}, error)
+
+// ParseExpression compiles an expression.
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	p := &exprParser{tokens: tokens}
+	eval, err := p.or()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos+1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	return &Expression{source: source, eval: eval}, nil
+}
+
+// MustParseExpression compiles an expression and panics on error.
+func MustParseExpression(source string) *Expression {
+	expr, err := ParseExpression(source)
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
+
+// ExpressionPredicate compiles an expression into a Predicate.
+func ExpressionPredicate(source string) (Predicate, error) {
+	expr, err := ParseExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	return expr.Predicate(), nil
+}
+
+// String returns the expression source.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against a WorkContext.
+func (e *Expression) Eval(wctx WorkContext) (interface{}, error) {
+	value, err := e.eval(wctx)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", e.source, err)
+	}
+	return value, nil
+}
+
+// Predicate returns a Predicate that reports whether the expression is truthy.
+func (e *Expression) Predicate() Predicate {
+	return func(wctx WorkContext) (bool, error) {
+		value, err := e.Eval(wctx)
+		if err != nil {
+			return false, err
+		}
+		return truthy(value), nil
+	}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type exprToken struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// tokenizeExpression splits an expression into tokens.
+func tokenizeExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(source); {
+		c := source[i]
+		r, _ := utf8.DecodeRuneInString(source[i:])
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(source) && source[j] != c; j++ {
+				if source[j] == '\\' && j+1 < len(source) {
+					j++
+				}
+				sb.WriteByte(source[j])
+			}
+			if j >= len(source) {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: source[i : j+1], value: sb.String(), pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(source) && (source[j] >= '0' && source[j] <= '9' || source[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(source[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", source[i:j], i+1)
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: source[i:j], value: n, pos: i})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			// Identifiers may contain any Unicode letter, so advance by runes
+			j := i
+			for j < len(source) {
+				next, size := utf8.DecodeRuneInString(source[j:])
+				if next != '_' && !unicode.IsLetter(next) && !unicode.IsDigit(next) {
+					break
+				}
+				j += size
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: source[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ".", ",", "-"} {
+				if strings.HasPrefix(source[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i+1)
+			}
+			tokens = append(tokens, exprToken{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF, text: "end of expression", pos: len(source)}), nil
+}
+
+// exprParser is a recursive descent parser producing evaluation closures.
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the given operators or keywords.
+func (p *exprParser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if t.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		t := p.peek()
+		return fmt.Errorf("expected %q at position %d, got %q", text, t.pos+1, t.text)
+	}
+	return nil
+}
+
+func (p *exprParser) or() (evalFunc, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||", "or"); !ok {
+			return left, nil
+		}
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(wctx WorkContext) (interface{}, error) {
+			a, err := l(wctx)
+			if err != nil || truthy(a) {
+				return truthy(a), err
+			}
+			b, err := right(wctx)
+			return truthy(b), err
+		}
+	}
+}
+
+func (p *exprParser) and() (evalFunc, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&", "and"); !ok {
+			return left, nil
+		}
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(wctx WorkContext) (interface{}, error) {
+			a, err := l(wctx)
+			if err != nil || !truthy(a) {
+				return false, err
+			}
+			b, err := right(wctx)
+			return truthy(b), err
+		}
+	}
+}
+
+func (p *exprParser) not() (evalFunc, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(wctx WorkContext) (interface{}, error) {
+			v, err := operand(wctx)
+			return !truthy(v), err
+		}, nil
+	}
+	return p.comparison()
+}
+
+func (p *exprParser) comparison() (evalFunc, error) {
+	left, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "contains", "in")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	return func(wctx WorkContext) (interface{}, error) {
+		a, err := left(wctx)
+		if err != nil {
+			return nil, err
+		}
+		b, err := right(wctx)
+		if err != nil {
+			return nil, err
+		}
+		return compareValues(op, a, b)
+	}, nil
+}
+
+func (p *exprParser) primary() (evalFunc, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber, tokString:
+		value := t.value
+		return func(WorkContext) (interface{}, error) { return value, nil }, nil
+	case tokOp:
+		if t.text == "-" && p.peek().kind == tokNumber {
+			value := -p.next().value.(float64)
+			return func(WorkContext) (interface{}, error) { return value, nil }, nil
+		}
+		if t.text == "(" {
+			inner, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		}
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			value := t.text == "true"
+			return func(WorkContext) (interface{}, error) { return value, nil }, nil
+		case "null", "nil":
+			return func(WorkContext) (interface{}, error) { return nil, nil }, nil
+		}
+		if p.peek().text == "(" {
+			return p.call(t)
+		}
+		return p.path(t.text)
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+}
+
+// call parses a function call.
+func (p *exprParser) call(name exprToken) (evalFunc, error) {
+	p.next()
+	arg, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	switch name.text {
+	case "len":
+		return func(wctx WorkContext) (interface{}, error) {
+			v, err := arg(wctx)
+			if err != nil || v == nil {
+				return 0.0, err
+			}
+			rv := reflect.ValueOf(v)
+			switch rv.Kind() {
+			case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+				return float64(rv.Len()), nil
+			}
+			return nil, fmt.Errorf("len: unsupported type %T", v)
+		}, nil
+	case "lower", "upper":
+		convert := strings.ToLower
+		if name.text == "upper" {
+			convert = strings.ToUpper
+		}
+		return func(wctx WorkContext) (interface{}, error) {
+			v, err := arg(wctx)
+			if err != nil || v == nil {
+				return v, err
+			}
+			return convert(fmt.Sprint(v)), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos+1)
+}
+
+// path parses a WorkContext key followed by field and index accessors.
+func (p *exprParser) path(root string) (evalFunc, error) {
+	var accessors []interface{}
+	for {
+		if _, ok := p.accept("."); ok {
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name at position %d", t.pos+1)
+			}
+			accessors = append(accessors, t.text)
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			t := p.next()
+			if t.kind != tokNumber && t.kind != tokString {
+				return nil, fmt.Errorf("expected index at position %d", t.pos+1)
+			}
+			accessors = append(accessors, t.value)
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		break
+	}
+
+	return func(wctx WorkContext) (interface{}, error) {
+		value, ok := wctx.Get(root)
+		if !ok {
+			return nil, nil
+		}
+		for _, accessor := range accessors {
+			value = access(value, accessor)
+			if value == nil {
+				return nil, nil
+			}
+		}
+		return value, nil
+	}, nil
+}
+
+// access returns a map entry, slice element or struct field, or nil.
+func access(value interface{}, accessor interface{}) interface{} {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		entry := rv.MapIndex(reflect.ValueOf(fmt.Sprint(accessor)).Convert(rv.Type().Key()))
+		if !entry.IsValid() {
+			return nil
+		}
+		return entry.Interface()
+	case reflect.Slice, reflect.Array:
+		index, ok := accessor.(float64)
+		if !ok || index < 0 || int(index) >= rv.Len() {
+			return nil
+		}
+		return rv.Index(int(index)).Interface()
+	case reflect.Struct:
+		name, ok := accessor.(string)
+		if !ok {
+			return nil
+		}
+		field := rv.FieldByName(name)
+		if !field.IsValid() || !field.CanInterface() {
+			return nil
+		}
+		return field.Interface()
+	}
+	return nil
+}
+
+// compareValues applies a comparison or membership operator.
+func compareValues(op string, a, b interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return valuesEqual(a, b), nil
+	case "!=":
+		return !valuesEqual(a, b), nil
+	case "contains":
+		return containsValue(a, b), nil
+	case "in":
+		return containsValue(b, a), nil
+	}
+
+	var cmp int
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %T with %T", a, b)
+		}
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	} else if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %T with %T", a, b)
+		}
+		cmp = strings.Compare(x, y)
+	} else {
+		return nil, fmt.Errorf("cannot compare %T with %T", a, b)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// valuesEqual compares values, treating all numeric types as numbers.
+func valuesEqual(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// containsValue reports whether a string contains a substring, a list
+// contains an item or a map contains a key.
+func containsValue(collection, item interface{}) bool {
+	if collection == nil {
+		return false
+	}
+	if s, ok := collection.(string); ok {
+		sub, ok := item.(string)
+		return ok && strings.Contains(s, sub)
+	}
+
+	rv := reflect.ValueOf(collection)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if valuesEqual(rv.Index(i).Interface(), item) {
+				return true
+			}
+		}
+	case reflect.Map:
+		for _, key := range rv.MapKeys() {
+			if valuesEqual(key.Interface(), item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toFloat converts numeric values to float64.
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// truthy reports whether a value counts as true.
+func truthy(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	type order struct {
+		Total float64
+		Items []string
+	}
+
+	wctx := NewWorkContext(context.Background())
+	wctx.Set("category", "billing")
+	wctx.Set("score", 0.85)
+	wctx.Set("attempts", 2)
+	wctx.Set("tags", []string{"vip", "eu"})
+	wctx.Set("result", map[string]interface{}{"status": "ok", "checks": []interface{}{"a", "b"}})
+	wctx.Set("order", &order{Total: 120, Items: []string{"book"}})
+	wctx.Set("größe", 3)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`category == "billing"`, true},
+		{`category != 'billing'`, false},
+		{`score >= 0.8 && attempts < 3`, true},
+		{`score > 0.9 or attempts == 2`, true},
+		{`not (score > 0.9)`, true},
+		{`"vip" in tags`, true},
+		{`tags contains "us"`, false},
+		{`result.status == "ok" && len(result.checks) == 2`, true},
+		{`result["checks"][1] == "b"`, true},
+		{`order.Total > 100 and order.Items[0] == "book"`, true},
+		{`missing == null`, true},
+		{`missing.field`, false},
+		{`upper(category) == "BILLING"`, true},
+		{`category contains "bill"`, true},
+		{`attempts > -1`, true},
+		{`tags`, true},
+		{`größe > 2`, true},
+	}
+	for _, tt := range tests {
+		pred, err := ExpressionPredicate(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error: %v", tt.expr, err)
+		}
+		got, err := pred(wctx)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.expr, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestExpression_Errors(t *testing.T) {
+	for _, expr := range []string{`score >`, `(a == 1`, `a = 1`, `"open`, `unknown(a)`, `a == 1 b`, `a == 1 → b`} {
+		if _, err := ParseExpression(expr); err == nil {
+			t.Errorf("%s: expected parse error", expr)
+		}
+	}
+
+	wctx := NewWorkContext(context.Background())
+	wctx.Set("name", "ada")
+	if _, err := MustParseExpression(`name > 3`).Eval(wctx); err == nil {
+		t.Error("Expected error comparing a string with a number")
+	}
+}
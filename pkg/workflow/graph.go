@@ -0,0 +1,53 @@
+package workflow
+
+import "reflect"
+
+// Composite is implemented by actions that run other actions. It lets
+// callers walk a workflow graph, e.g. to collect the tools of nested agents.
+type Composite interface {
+	// Children returns the actions this action may run.
+	Children() []Action
+}
+
+// Walk calls fn for the action and, depth first, for every action nested in
+// it through Composite. Returning false from fn skips the children of that
+// action. Actions reachable on several paths are visited once.
+func Walk(action Action, fn func(Action) bool) {
+	visited := make(map[Action]bool)
+	var walk func(Action)
+	walk = func(a Action) {
+		if a == nil {
+			return
+		}
+		if reflect.TypeOf(a).Comparable() {
+			if visited[a] {
+				return
+			}
+			visited[a] = true
+		}
+		if !fn(a) {
+			return
+		}
+		if composite, ok := a.(Composite); ok {
+			for _, child := range composite.Children() {
+				walk(child)
+			}
+		}
+	}
+	walk(action)
+}
+
+// actions returns the non-nil actions of the list.
+func actions(list ...Action) []Action {
+	var children []Action
+	for _, a := range list {
+		if a == nil {
+			continue
+		}
+		if v := reflect.ValueOf(a); v.Kind() == reflect.Ptr && v.IsNil() {
+			continue
+		}
+		children = append(children, a)
+	}
+	return children
+}
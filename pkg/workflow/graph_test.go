@@ -0,0 +1,34 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	step := func(name string) Action {
+		return NewActionFunc(name, func(WorkContext) WorkReport { return NewCompletedWorkReport() })
+	}
+	shared := step("shared")
+
+	flow := NewSequentialFlow("root",
+		step("a"),
+		NewParallelFlow("parallel", shared, NewLoop("loop", 2).WithAction(step("b"))),
+		NewSwitchFlow("switch", []Case{{Action: shared}}, step("default")),
+		NewTryCatch("try").
+			WithTryAction(NewTimeoutWrapper("timeout", 0).WithAction(step("c"))).
+			CatchAny(NewDefaultErrorHandlerAction("handler", nil)).
+			Finally(step("finally")),
+	)
+
+	var names []string
+	Walk(flow, func(action Action) bool {
+		names = append(names, action.Name())
+		return action.Name() != "try"
+	})
+
+	want := "root,a,parallel,shared,loop,b,switch,default,try"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -71,6 +71,11 @@ func (l *Loop) Name() string {
 	return l.name
 }
 
+// Children returns the action run on every iteration.
+func (l *Loop) Children() []Action {
+	return actions(l.action)
+}
+
 // Run executes the loop with the given work context.
 func (l *Loop) Run(wctx WorkContext) WorkReport {
 	if l.action == nil {
@@ -27,6 +27,11 @@ func (pf *ParallelFlow) Name() string {
 	return pf.FlowName
 }
 
+// Children returns the actions of the flow.
+func (pf *ParallelFlow) Children() []Action {
+	return actions(pf.Actions...)
+}
+
 // Execute adds an action to the ParallelFlow.
 // This method allows for adding actions that will be executed concurrently.
 func (pf *ParallelFlow) Execute(action Action) *ParallelFlow {
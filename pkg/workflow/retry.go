@@ -70,6 +70,11 @@ func (r *Retry) Name() string {
 	return r.name
 }
 
+// Children returns the retried action.
+func (r *Retry) Children() []Action {
+	return actions(r.action)
+}
+
 // Run executes the retry logic with the given work context.
 func (r *Retry) Run(wctx WorkContext) WorkReport {
 	if r.action == nil {
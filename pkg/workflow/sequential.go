@@ -29,6 +29,11 @@ func (sf *SequentialFlow) Name() string {
 	return sf.FlowName
 }
 
+// Children returns the actions of the flow in order.
+func (sf *SequentialFlow) Children() []Action {
+	return actions(sf.Actions...)
+}
+
 // Then appends a subsequent action to the SequentialFlow.
 // This method allows for chaining actions to be executed in sequence.
 func (sf *SequentialFlow) Then(action Action) *SequentialFlow {
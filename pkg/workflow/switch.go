@@ -74,6 +74,15 @@ func (sf *SwitchFlow) Name() string {
 	return sf.FlowName
 }
 
+// Children returns the actions of the cases and the default action.
+func (sf *SwitchFlow) Children() []Action {
+	children := make([]Action, 0, len(sf.Cases)+1)
+	for _, c := range sf.Cases {
+		children = append(children, c.Action)
+	}
+	return actions(append(children, sf.DefaultAction)...)
+}
+
 // Run executes the SwitchFlow by evaluating conditions in order and running the first matching action.
 // If no conditions match and a default action is provided, runs the default action.
 // If no conditions match and no default action is provided, returns a skipped report.
@@ -101,6 +101,18 @@ func (tc *TryCatch) Name() string {
 	return tc.name
 }
 
+// Children returns the try action, the catch handlers and the finally action.
+func (tc *TryCatch) Children() []Action {
+	children := []Action{tc.tryAction}
+	for _, h := range tc.catchHandlers {
+		children = append(children, h.action)
+	}
+	if tc.catchAllAction != nil {
+		children = append(children, tc.catchAllAction)
+	}
+	return actions(append(children, tc.finallyAction)...)
+}
+
 // Run executes the try-catch-finally logic.
 func (tc *TryCatch) Run(wctx WorkContext) WorkReport {
 	if tc.tryAction == nil {
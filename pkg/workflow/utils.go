@@ -27,3 +27,10 @@ func extractContent(data interface{}) string {
 	// Fallback to string representation
 	return fmt.Sprintf("%v", data)
 }
+
+// ExtractContent returns the text content of an action's report data.
+// It reads the Content field of structs such as llm.CompletionResponse and
+// falls back to the string representation of the data.
+func ExtractContent(data interface{}) string {
+	return extractContent(data)
+}